@@ -0,0 +1,37 @@
+package main
+
+import (
+	"path/filepath"
+	"sort"
+)
+
+// agentFileNames are instruction files that coding agents load into every
+// session they start in that directory.
+var agentFileNames = []string{"CLAUDE.md", "AGENTS.md", ".cursorrules"}
+
+// checkAgentFiles reports agent instruction files costing more than
+// budget tokens. It looks in the current directory and in every directory
+// containing one of files, since agents also pick up nested instructions.
+func checkAgentFiles(files []string, budget int, count func([]byte) int, read func(string) ([]byte, error)) []finding {
+	dirs := map[string]bool{".": true}
+	for _, path := range files {
+		dirs[filepath.Dir(path)] = true
+	}
+
+	var findings []finding
+	for dir := range dirs {
+		for _, name := range agentFileNames {
+			path := filepath.Join(dir, name)
+			content, err := read(path)
+			if err != nil {
+				continue
+			}
+			if tokens := count(content); tokens > budget {
+				findings = append(findings, finding{rule: "agent-files", path: path, key: "agentFileOver", args: []any{name, tokens, budget}})
+			}
+		}
+	}
+
+	sort.Slice(findings, func(i, j int) bool { return findings[i].path < findings[j].path })
+	return findings
+}
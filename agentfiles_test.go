@@ -0,0 +1,41 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckAgentFiles(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	files := map[string]string{
+		"CLAUDE.md":        strings.Repeat("x", 200),
+		"AGENTS.md":        "short",
+		"pkg/a.go":         "package pkg\n",
+		"pkg/.cursorrules": strings.Repeat("y", 150),
+		"other/CLAUDE.md":  strings.Repeat("z", 500), // no scanned files here
+	}
+	for name, content := range files {
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	count := func(b []byte) int { return len(b) }
+	got := checkAgentFiles([]string{filepath.Join("pkg", "a.go")}, 100, count, os.ReadFile)
+	if len(got) != 2 {
+		t.Fatalf("findings = %v, want 2", got)
+	}
+	if got[0].path != "CLAUDE.md" || got[1].path != filepath.Join("pkg", ".cursorrules") {
+		t.Errorf("paths = %s, %s", got[0].path, got[1].path)
+	}
+	want := "CLAUDE.md is ~200 tokens, over the 100 token budget for agent instructions; every agent session pays for it"
+	if msg := got[0].message(newMessages("en")); msg != want {
+		t.Errorf("message = %q, want %q", msg, want)
+	}
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/befabri/token-lint/pkg/tokenlint"
+)
+
+// prepSection is one source file rendered for a context directory.
+type prepSection struct {
+	path   string
+	text   string
+	tokens int
+}
+
+// prepChunk is one file of a context directory in the manifest.
+type prepChunk struct {
+	Name   string   `json:"name"`
+	Tokens int      `json:"tokens"`
+	Files  []string `json:"files"`
+}
+
+// prepOmitted is a selected file left out for lack of budget.
+type prepOmitted struct {
+	Path   string `json:"path"`
+	Tokens int    `json:"tokens"`
+}
+
+// prepManifest describes a context directory written by agent-prep.
+type prepManifest struct {
+	Target        []string      `json:"target"`
+	Budget        int           `json:"budget"`
+	Tokens        int           `json:"tokens"`
+	Outline       string        `json:"outline"`
+	OutlineTokens int           `json:"outlineTokens"`
+	Chunks        []prepChunk   `json:"chunks"`
+	Omitted       []prepOmitted `json:"omitted,omitempty"`
+}
+
+// runAgentPrep implements `token-lint agent-prep`, which prepares an area
+// of the repository for an LLM session: it selects the Go files under the
+// target, writes an outline, packs as many files as fit the budget and
+// splits them into chunks, plus a manifest listing what went where.
+func runAgentPrep(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("token-lint agent-prep", flag.ContinueOnError)
+	var targets patternList
+	fs.Var(&targets, "target", "directory or package pattern to prepare (repeatable, default ./...)")
+	budget := fs.Int("budget", 120000, "maximum tokens for the whole context directory")
+	chunk := fs.Int("chunk", defaultThreshold, "maximum tokens per chunk file")
+	outlineBudget := fs.Int("outline-budget", 8000, "maximum tokens for the outline")
+	tests := fs.Bool("tests", false, "include _test.go files")
+	output := fs.String("o", "", "directory to write the context into (required)")
+	ratio := fs.Float64("ratio", defaultRatio, "tokens per character ratio")
+
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 1
+	}
+	pol, err := loadPolicy(fs, "", "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	if *ratio <= 0 {
+		fmt.Fprintln(os.Stderr, "error: ratio must be positive")
+		return 1
+	}
+	if *budget <= 0 || *chunk <= 0 || *outlineBudget <= 0 {
+		fmt.Fprintln(os.Stderr, "error: budget, chunk and outline-budget must be positive")
+		return 1
+	}
+	if *output == "" {
+		fmt.Fprintln(os.Stderr, "error: -o is required")
+		return 1
+	}
+	if err := checkEmptyDir(*output); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+
+	paths := []string(targets)
+	paths = append(paths, fs.Args()...)
+	if len(paths) == 0 {
+		paths = []string{"./..."}
+	}
+	for i, p := range paths {
+		if info, err := os.Stat(p); err == nil && info.IsDir() {
+			paths[i] = strings.TrimSuffix(filepath.ToSlash(p), "/") + "/..."
+		}
+	}
+
+	// Selection.
+	files, err := pol.expand(ctx, paths)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	var selected []string
+	for _, path := range files {
+		if *tests || !strings.HasSuffix(path, "_test.go") {
+			selected = append(selected, path)
+		}
+	}
+	tok := tokenlint.RatioTokenizer(*ratio)
+	opts, err := pol.options(defaultThreshold, *ratio)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	results, _ := analyzeFiles(ctx, selected, opts)
+	if len(results) == 0 {
+		fmt.Fprintln(os.Stderr, "no Go files found")
+		return 0
+	}
+
+	// Outlining, from the non-test files only.
+	var sources []fileResult
+	for _, r := range results {
+		if !strings.HasSuffix(r.path, "_test.go") {
+			sources = append(sources, r)
+		}
+	}
+	outline, fits := fitOutline(buildOutline(sources), min(*outlineBudget, *budget), tok)
+	if !fits {
+		fmt.Fprintf(os.Stderr, "warning: outline exceeds the %d token budget even without symbols; narrow the target\n", *outlineBudget)
+	}
+
+	// Packing and chunking.
+	var sections []prepSection
+	for _, r := range results {
+		content, err := os.ReadFile(r.path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 1
+		}
+		s := prepSection{path: filepath.ToSlash(r.path)}
+		fence := "```"
+		for strings.Contains(string(content), fence) {
+			fence += "`" // outlast backtick runs in raw strings
+		}
+		s.text = fmt.Sprintf("## %s\n\n%sgo\n%s\n%s\n\n", s.path, fence, strings.TrimRight(string(content), "\n"), fence)
+		s.tokens = tok.Count([]byte(s.text))
+		sections = append(sections, s)
+	}
+	m := prepManifest{
+		Target:        paths,
+		Budget:        *budget,
+		Outline:       "OUTLINE.md",
+		OutlineTokens: tok.Count([]byte(outline)),
+	}
+	chunks, omitted := packSections(sections, *budget-m.OutlineTokens, *chunk)
+
+	if err := os.MkdirAll(*output, 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	if err := os.WriteFile(filepath.Join(*output, m.Outline), []byte(outline), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	m.Tokens = m.OutlineTokens
+	for i, c := range chunks {
+		pc := prepChunk{Name: fmt.Sprintf("chunk-%03d.md", i+1)}
+		var b strings.Builder
+		for _, s := range c {
+			b.WriteString(s.text)
+			pc.Tokens += s.tokens
+			pc.Files = append(pc.Files, s.path)
+		}
+		if err := os.WriteFile(filepath.Join(*output, pc.Name), []byte(b.String()), 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 1
+		}
+		m.Tokens += pc.Tokens
+		m.Chunks = append(m.Chunks, pc)
+	}
+	for _, s := range omitted {
+		m.Omitted = append(m.Omitted, prepOmitted{s.path, s.tokens})
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	if err := os.WriteFile(filepath.Join(*output, "manifest.json"), append(data, '\n'), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("wrote %s: outline and %d chunk(s), ~%d of %d tokens, %d of %d files\n",
+		quotePath(*output), len(m.Chunks), m.Tokens, *budget, len(results)-len(omitted), len(results))
+	if len(omitted) > 0 {
+		fmt.Fprintf(os.Stderr, "warning: %d file(s) did not fit the budget; see manifest.json\n", len(omitted))
+	}
+	return 0
+}
+
+// packSections keeps sections, in order, while they fit budget, skipping
+// any that would overflow it, and groups the kept ones into consecutive
+// chunks of at most chunk tokens. A section larger than chunk gets a chunk
+// of its own. It returns the chunks and the sections left out.
+func packSections(sections []prepSection, budget, chunk int) (chunks [][]prepSection, omitted []prepSection) {
+	used, size := 0, 0
+	for _, s := range sections {
+		if used+s.tokens > budget {
+			omitted = append(omitted, s)
+			continue
+		}
+		used += s.tokens
+		if len(chunks) == 0 || size+s.tokens > chunk {
+			chunks = append(chunks, nil)
+			size = 0
+		}
+		chunks[len(chunks)-1] = append(chunks[len(chunks)-1], s)
+		size += s.tokens
+	}
+	return chunks, omitted
+}
+
+// checkEmptyDir returns an error if dir exists and is not an empty
+// directory, so a rerun never mixes stale chunks with new ones.
+func checkEmptyDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(entries) > 0 {
+		return fmt.Errorf("%s is not empty", dir)
+	}
+	return nil
+}
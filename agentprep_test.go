@@ -0,0 +1,35 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPackSections(t *testing.T) {
+	sections := []prepSection{
+		{path: "a.go", tokens: 40},
+		{path: "b.go", tokens: 50},
+		{path: "huge.go", tokens: 500}, // over the remaining budget
+		{path: "c.go", tokens: 120},    // over the chunk size
+		{path: "d.go", tokens: 30},
+		{path: "e.go", tokens: 80}, // would overflow the budget
+		{path: "f.go", tokens: 10},
+	}
+	chunks, omitted := packSections(sections, 260, 100)
+
+	var got [][]string
+	for _, c := range chunks {
+		var names []string
+		for _, s := range c {
+			names = append(names, s.path)
+		}
+		got = append(got, names)
+	}
+	want := [][]string{{"a.go", "b.go"}, {"c.go"}, {"d.go", "f.go"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("chunks = %v, want %v", got, want)
+	}
+	if len(omitted) != 2 || omitted[0].path != "huge.go" || omitted[1].path != "e.go" {
+		t.Errorf("omitted = %v, want huge.go and e.go", omitted)
+	}
+}
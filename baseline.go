@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// baselineVersion is the format version of baseline files.
+const baselineVersion = 1
+
+// baselineFile is the on-disk form of a baseline: the token count of each
+// violating file when the baseline was written, keyed by slash-separated
+// path relative to the baseline file.
+type baselineFile struct {
+	Version int            `json:"version"`
+	Files   map[string]int `json:"files"`
+}
+
+// baseline grandfathers existing violations.
+type baseline struct {
+	dir   string
+	files map[string]int
+}
+
+func loadBaseline(path string) (*baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f baselineFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if f.Version != baselineVersion {
+		return nil, fmt.Errorf("%s: unsupported baseline version %d", path, f.Version)
+	}
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+	return &baseline{dir: dir, files: f.Files}, nil
+}
+
+// writeBaseline records violations in a new baseline file at path.
+func writeBaseline(path string, violations []fileResult) error {
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	b := &baseline{dir: dir}
+	f := baselineFile{Version: baselineVersion, Files: make(map[string]int)}
+	for _, v := range violations {
+		f.Files[b.key(v.path)] = v.tokens
+	}
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// key returns the baseline entry name for path.
+func (b *baseline) key(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	rel, err := filepath.Rel(b.dir, abs)
+	if err != nil {
+		return filepath.ToSlash(abs)
+	}
+	return filepath.ToSlash(rel)
+}
+
+// markFile flags a violation recorded in the baseline that has not grown
+// since, and notes the recorded size if it has.
+func (b *baseline) markFile(v *fileResult) {
+	if recorded, ok := b.files[b.key(v.path)]; ok {
+		v.baselineTokens = recorded
+		v.baselined = v.tokens <= recorded
+	}
+}
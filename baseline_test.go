@@ -0,0 +1,50 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBaseline(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "baseline.json")
+	old := []fileResult{
+		{path: filepath.Join(dir, "pkg", "big.go"), tokens: 30000},
+		{path: filepath.Join(dir, "grows.go"), tokens: 26000},
+	}
+	if err := writeBaseline(path, old); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"pkg/big.go": 30000`) {
+		t.Errorf("baseline file:\n%s", data)
+	}
+
+	b, err := loadBaseline(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	violations := []fileResult{
+		{path: filepath.Join(dir, "pkg", "big.go"), tokens: 29000},
+		{path: filepath.Join(dir, "grows.go"), tokens: 27000},
+		{path: filepath.Join(dir, "new.go"), tokens: 26000},
+	}
+	for i := range violations {
+		b.markFile(&violations[i])
+	}
+
+	if !violations[0].baselined || violations[1].baselined || violations[2].baselined {
+		t.Errorf("baselined = %v %v %v, want true false false", violations[0].baselined, violations[1].baselined, violations[2].baselined)
+	}
+	if violations[1].baselineTokens != 26000 {
+		t.Errorf("grown file baselineTokens = %d, want 26000", violations[1].baselineTokens)
+	}
+	if n := countFailing(violations, false); n != 2 {
+		t.Errorf("countFailing = %d, want 2", n)
+	}
+}
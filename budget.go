@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+
+	"github.com/befabri/token-lint/pkg/tokenlint"
+)
+
+// prTokens sums the tokens of the Go files changed relative to base that
+// the main check would scan: files out of scope under pol are skipped, and
+// so are generated ones unless includeGenerated is set. It returns the sum
+// and the number of files counted.
+func prTokens(ctx context.Context, base string, pol *policy, includeGenerated bool, opts analyzeOptions) (int, int, error) {
+	root, err := gitOutput("rev-parse", "--show-toplevel")
+	if err != nil {
+		return 0, 0, err
+	}
+	changed, err := changedFiles(root, base)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var files []string
+	for _, rel := range changed {
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		if !strings.HasSuffix(rel, ".go") || !pol.inScope(path) {
+			continue
+		}
+		if !includeGenerated && (pol.generated.Match(path) || tokenlint.HasGeneratedHeader(path)) {
+			continue
+		}
+		files = append(files, path)
+	}
+
+	results, _ := analyzeFiles(ctx, files, opts)
+	total := 0
+	for _, r := range results {
+		total += r.tokens
+	}
+	return total, len(results), nil
+}
+
+// branchModifiedFiles returns the files added or modified since the merge
+// base of ref, uncommitted changes included.
+func branchModifiedFiles(ref string) (gitFileSet, error) {
+	root, err := gitOutput("rev-parse", "--show-toplevel")
+	if err != nil {
+		return gitFileSet{}, err
+	}
+	modified, err := modifiedFiles(root, ref)
+	if err != nil {
+		return gitFileSet{}, err
+	}
+
+	s := gitFileSet{root: root, files: make(map[string]bool, len(modified))}
+	for _, rel := range modified {
+		s.files[rel] = true
+	}
+	return s, nil
+}
+
+// gitFileSet is a set of files git listed relative to the repository
+// root.
+type gitFileSet struct {
+	root  string
+	files map[string]bool // slash-separated paths relative to root
+}
+
+// has reports whether path, absolute or relative to the working
+// directory, is in s. Symlinks are resolved, since git reports the real
+// path of the root even when the working directory is a symlink.
+func (s gitFileSet) has(path string) bool {
+	rel, err := relToRoot(s.root, path)
+	return err == nil && s.files[rel]
+}
+
+// branchAddedFiles returns the files added since base.
+func branchAddedFiles(base string) (gitFileSet, error) {
+	root, err := gitOutput("rev-parse", "--show-toplevel")
+	if err != nil {
+		return gitFileSet{}, err
+	}
+	added, err := addedFiles(root, base)
+	if err != nil {
+		return gitFileSet{}, err
+	}
+
+	s := gitFileSet{root: root, files: make(map[string]bool, len(added))}
+	for _, rel := range added {
+		s.files[rel] = true
+	}
+	return s, nil
+}
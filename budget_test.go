@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/befabri/token-lint/pkg/tokenlint"
+)
+
+func TestPRBudgetJSONL(t *testing.T) {
+	dir := newTestRepo(t)
+	testCommit(t, dir, "", map[string]string{"a.go": "package a\n"})
+	testGit(t, dir, "", "checkout", "--quiet", "-b", "feature")
+	testCommit(t, dir, "", map[string]string{"b.go": "package a\n\nfunc B() {}\n"})
+	t.Chdir(dir)
+
+	var out bytes.Buffer
+	if code := run([]string{"-format", "jsonl", "-pr-budget", "1", "-base", "main", "./..."}, &out, io.Discard); code != 1 {
+		t.Errorf("exit code = %d, want 1", code)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if n := strings.Count(out.String(), `"type":"file"`); n != 2 {
+		t.Errorf("got %d file records, want 2:\n%s", n, out.String())
+	}
+	if last := lines[len(lines)-1]; !strings.Contains(last, `"type":"summary"`) {
+		t.Errorf("last record = %s, want the summary", last)
+	}
+}
+
+func TestPRTokensScope(t *testing.T) {
+	dir := newTestRepo(t)
+	testCommit(t, dir, "", map[string]string{"a.go": "package a\n", ".tokenlintignore": "ignored.go\n"})
+	testGit(t, dir, "", "checkout", "--quiet", "-b", "feature")
+	testCommit(t, dir, "", map[string]string{
+		"kept.go":       "package a\n",
+		" lead.go":      "package a\n", // survives git -z output untrimmed
+		"ignored.go":    "package a\n",
+		"view_templ.go": "package a\n",
+		"api.pb.go":     "package a\n",
+		"header.go":     "// Code generated by gen. DO NOT EDIT.\n\npackage a\n",
+	})
+	t.Chdir(dir)
+
+	ig, err := tokenlint.LoadIgnoreFile(filepath.Join(dir, ".tokenlintignore"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	generated, err := newGeneratedRules(nil, []string{"*_templ.go"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pol := &policy{ignore: ig, generated: generated}
+	opts := analyzeOptions{threshold: defaultThreshold, ratio: defaultRatio}
+
+	if _, n, err := prTokens(context.Background(), "main", pol, false, opts); err != nil || n != 2 {
+		t.Errorf("prTokens counted %d files (err %v), want kept.go and \" lead.go\"", n, err)
+	}
+	if _, n, err := prTokens(context.Background(), "main", pol, true, opts); err != nil || n != 5 {
+		t.Errorf("prTokens with generated files counted %d files (err %v), want 5", n, err)
+	}
+}
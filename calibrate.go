@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/befabri/token-lint/pkg/tokenlint"
+)
+
+// runCalibrate implements `token-lint calibrate`, which fits the
+// tokens-per-character ratio to exact counts on a sample of files, overall
+// and for each file category.
+func runCalibrate(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("token-lint calibrate", flag.ContinueOnError)
+	tokenizer := fs.String("tokenizer", "bpe", "exact tokenizer to calibrate against: "+strings.Join(tokenizerNames(), ", "))
+	encoding := fs.String("encoding", "cl100k_base", "vocabulary for -tokenizer bpe")
+	apiModel := fs.String("api-model", defaultClaudeModel, "model for -tokenizer claude-api")
+	apiQPS := fs.Float64("api-qps", defaultAPIQPS, "maximum requests per second for API tokenizers (0 means no limit)")
+	apiConcurrency := fs.Int("api-concurrency", defaultAPIConcurrency, "maximum concurrent requests for API tokenizers (0 means no limit)")
+	apiBudget := fs.Int("api-budget", 0, "maximum requests per run for API tokenizers, retries included; later files are left out of the fit (0 means no limit)")
+	sample := fs.String("sample", "20%", "share of files to count exactly")
+	seedFlag := fs.String("seed", "", "seed for -sample; the same seed and files give the same sample (default: current commit SHA)")
+	fs.StringVar(seedFlag, "sample-seed", "", "alias for -seed")
+
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 1
+	}
+	explicit := false
+	fs.Visit(func(f *flag.Flag) { explicit = explicit || f.Name == "tokenizer" })
+	pol, err := loadPolicy(fs, "", "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	if *tokenizer == "ratio" {
+		if explicit {
+			fmt.Fprintln(os.Stderr, "error: calibrate needs an exact tokenizer, not ratio")
+			return 1
+		}
+		// A config that estimates still calibrates against the default.
+		*tokenizer = "bpe"
+	}
+
+	fraction, err := parseSample(*sample)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	// Files past the budget count as 0 rather than as an estimate, so they
+	// can be left out of the fit; batching is off because batched files
+	// share one count in proportion to their size, which is no measure of
+	// their own ratio.
+	tok, err := newTokenizer(*tokenizer, tokenizerConfig{
+		encoding:    *encoding,
+		model:       *apiModel,
+		qps:         *apiQPS,
+		concurrency: *apiConcurrency,
+		budget:      *apiBudget,
+		batch:       1,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{"./..."}
+	}
+	// Generated files are sampled too, to fit their category ratios.
+	expand := pol.expandOptions()
+	expand.IncludeGenerated = true
+	files, err := tokenlint.Expand(ctx, paths, expand)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	files = pol.filter(files)
+
+	seed := *seedFlag
+	if seed == "" {
+		seed = sampleSeed()
+	}
+	sampled := stratifiedSample(files, fileSize, fraction, seed)
+	if len(sampled) == 0 {
+		// Small repositories may sample nothing; use every file instead.
+		sampled = files
+	}
+
+	results, _ := analyzeFiles(ctx, sampled, analyzeOptions{threshold: math.MaxInt, tokenizer: tok})
+	if err := tokenizerErr(tok); err != nil {
+		fmt.Fprintf(os.Stderr, "error: -tokenizer %s: %v\n", *tokenizer, err)
+		return 1
+	}
+	if ctx.Err() != nil {
+		fmt.Fprintln(os.Stderr, "calibration interrupted")
+		return exitCanceled
+	}
+	if estimated, budget := tokenizerFallbacks(tok); estimated > 0 {
+		fmt.Fprintf(os.Stderr, "warning: API request budget of %d exhausted; %d file(s) left out of the fit\n", budget, estimated)
+		counted := results[:0]
+		for _, r := range results {
+			if r.tokens > 0 || r.chars == 0 {
+				counted = append(counted, r)
+			}
+		}
+		results = counted
+	}
+
+	// Generated files are not scanned by default, so they stay out of
+	// the overall ratio.
+	var code []fileResult
+	for _, r := range results {
+		if r.category != "generated" && r.category != "protobuf" {
+			code = append(code, r)
+		}
+	}
+	fitted := fitRatio(code)
+	if fitted == 0 {
+		fmt.Fprintln(os.Stderr, "no Go files with content found")
+		return 0
+	}
+
+	fmt.Printf("Calibrated on %d of %d files (%s stratified sample, seed %s) with -tokenizer %s\n\n", len(results), len(files), *sample, seed, *tokenizer)
+	fmt.Printf("  %-16s %.3f tokens per character\n", "fitted ratio:", fitted)
+	fmt.Printf("  %-16s mean error %.1f%%\n", fmt.Sprintf("default %g:", defaultRatio), ratioError(code, defaultRatio)*100)
+	fmt.Printf("  %-16s mean error %.1f%%\n\n", fmt.Sprintf("fitted %.3f:", fitted), ratioError(code, fitted)*100)
+
+	ratios := fitCategoryRatios(results)
+	if len(ratios) > 0 {
+		fmt.Println("Per category:")
+		for _, c := range tokenlint.Categories {
+			if ratio, ok := ratios[c]; ok {
+				fmt.Printf("  %-16s %.3f tokens per character\n", c+":", ratio)
+			}
+		}
+		fmt.Println()
+	}
+
+	fmt.Println("Use it with:")
+	fmt.Printf("  token-lint -ratio %.3f ./...\n", fitted)
+	fmt.Printf("\nor in %s:\n", configFileName)
+	fmt.Printf("  ratio: %.3f\n", fitted)
+	if len(ratios) > 0 {
+		fmt.Println("  ratios:")
+		for _, c := range tokenlint.Categories {
+			if ratio, ok := ratios[c]; ok {
+				fmt.Printf("    %s: %.3f\n", c, ratio)
+			}
+		}
+	}
+	return 0
+}
+
+// fitCategoryRatios fits a ratio for each file category present in
+// results. Ordinary code has no category and is left out.
+func fitCategoryRatios(results []fileResult) map[string]float64 {
+	groups := make(map[string][]fileResult)
+	for _, r := range results {
+		if r.category != "" {
+			groups[r.category] = append(groups[r.category], r)
+		}
+	}
+	ratios := make(map[string]float64)
+	for c, g := range groups {
+		if ratio := fitRatio(g); ratio > 0 {
+			ratios[c] = ratio
+		}
+	}
+	return ratios
+}
+
+// fileSize returns the size of path in bytes, or 0 if it cannot be read.
+func fileSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+// fitRatio returns the tokens-per-character ratio that reproduces the
+// total token count of results, or 0 if they contain no characters.
+func fitRatio(results []fileResult) float64 {
+	var tokens, chars int
+	for _, r := range results {
+		tokens += r.tokens
+		chars += r.chars
+	}
+	if chars == 0 {
+		return 0
+	}
+	return float64(tokens) / float64(chars)
+}
+
+// ratioError returns the mean relative error of estimating each result's
+// exact token count with ratio.
+func ratioError(results []fileResult, ratio float64) float64 {
+	var sum float64
+	var n int
+	for _, r := range results {
+		if r.tokens == 0 {
+			continue
+		}
+		estimate := float64(r.chars) * ratio
+		sum += math.Abs(estimate-float64(r.tokens)) / float64(r.tokens)
+		n++
+	}
+	if n == 0 {
+		return 0
+	}
+	return sum / float64(n)
+}
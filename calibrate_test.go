@@ -0,0 +1,40 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFitRatio(t *testing.T) {
+	results := []fileResult{
+		{chars: 100, tokens: 30},
+		{chars: 300, tokens: 90},
+		{chars: 0, tokens: 0},
+	}
+
+	if got := fitRatio(results); math.Abs(got-0.3) > 1e-9 {
+		t.Errorf("fitRatio = %g, want 0.3", got)
+	}
+	if got := ratioError(results, 0.3); got > 1e-9 {
+		t.Errorf("ratioError(0.3) = %g, want 0", got)
+	}
+	if got := ratioError(results, 0.6); math.Abs(got-1) > 1e-9 {
+		t.Errorf("ratioError(0.6) = %g, want 1", got)
+	}
+	if got := fitRatio(nil); got != 0 {
+		t.Errorf("fitRatio(nil) = %g, want 0", got)
+	}
+}
+
+func TestFitCategoryRatios(t *testing.T) {
+	results := []fileResult{
+		{chars: 100, tokens: 30},
+		{chars: 100, tokens: 50, category: "generated"},
+		{chars: 300, tokens: 150, category: "generated"},
+		{chars: 200, tokens: 40, category: "test"},
+	}
+	got := fitCategoryRatios(results)
+	if len(got) != 2 || math.Abs(got["generated"]-0.5) > 1e-9 || math.Abs(got["test"]-0.2) > 1e-9 {
+		t.Errorf("fitCategoryRatios = %v, want generated 0.5 and test 0.2", got)
+	}
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// campaign is the remediation plan written by `token-lint campaign`, for
+// project tooling to turn into tickets.
+type campaign struct {
+	Generated         string         `yaml:"generated"`
+	Threshold         int            `yaml:"threshold"`
+	PerWeek           int            `yaml:"per_week"`
+	Files             int            `yaml:"files"`
+	ExpectedReduction int            `yaml:"expected_reduction"`
+	Teams             []campaignTeam `yaml:"teams"`
+}
+
+// campaignTeam is one owning team's share of a campaign.
+type campaignTeam struct {
+	Team              string         `yaml:"team"`
+	Files             int            `yaml:"files"`
+	ExpectedReduction int            `yaml:"expected_reduction"`
+	Weeks             []campaignWeek `yaml:"weeks"`
+}
+
+// campaignWeek is the batch of files a team is asked to fix in one week.
+type campaignWeek struct {
+	Week              int            `yaml:"week"`
+	Start             string         `yaml:"start"`
+	ExpectedReduction int            `yaml:"expected_reduction"`
+	Files             []campaignFile `yaml:"files"`
+}
+
+type campaignFile struct {
+	Path    string `yaml:"path"`
+	Tokens  int    `yaml:"tokens"`
+	Over    int    `yaml:"over"`
+	Commits int    `yaml:"commits"`
+	Score   int    `yaml:"score"`
+}
+
+// runCampaign implements `token-lint campaign`, which turns the current
+// violations into a prioritized plan of weekly batches per owning team.
+func runCampaign(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("token-lint campaign", flag.ContinueOnError)
+	threshold := fs.Int("threshold", defaultThreshold, "maximum tokens per file")
+	ratio := fs.Float64("ratio", defaultRatio, "tokens per character ratio")
+	since := fs.String("since", "90 days ago", "rank by commits after this date (any format git accepts)")
+	ownersFile := fs.String("codeowners", "", "CODEOWNERS file (default: .github/CODEOWNERS, CODEOWNERS or docs/CODEOWNERS)")
+	perWeek := fs.Int("per-week", 2, "files each team is asked to fix per week")
+	startFlag := fs.String("start", "", "first day of week 1 as YYYY-MM-DD (default: next Monday)")
+	output := fs.String("o", "", "write the campaign to this file instead of stdout")
+
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 1
+	}
+	pol, err := loadPolicy(fs, "", "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	if *ratio <= 0 || *threshold <= 0 || *perWeek <= 0 {
+		fmt.Fprintln(os.Stderr, "error: ratio, threshold and per-week must be positive")
+		return 1
+	}
+	start := nextMonday(time.Now())
+	if *startFlag != "" {
+		var err error
+		if start, err = time.Parse(time.DateOnly, *startFlag); err != nil {
+			fmt.Fprintf(os.Stderr, "error: -start: %v\n", err)
+			return 1
+		}
+	}
+
+	root, err := repoRoot()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	owners, err := openCodeowners(root, *ownersFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{"./..."}
+	}
+	files, err := pol.expand(ctx, paths)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	opts, err := pol.options(*threshold, *ratio)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	_, violations := analyzeFiles(ctx, files, opts)
+
+	counts, err := commitCounts(*since)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	// Plans refer to files relative to the repository root, wherever the
+	// command was run from.
+	for i, v := range violations {
+		if rel, err := relToRoot(root, v.path); err == nil {
+			violations[i].path = rel
+		}
+	}
+	ranked := rankByChurn(violations, *threshold, func(rel string) int { return counts[rel] })
+
+	c := planCampaign(ranked, owners, *perWeek, start)
+	c.Generated = time.Now().Format(time.DateOnly)
+	c.Threshold = *threshold
+
+	w := io.Writer(os.Stdout)
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		w = f
+	}
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(c); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	if err := enc.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	if *output != "" {
+		fmt.Fprintf(os.Stderr, "campaign: %d files for %d teams written to %s\n", c.Files, len(c.Teams), *output)
+	}
+	return 0
+}
+
+// planCampaign groups ranked violations by owning team, keeping their
+// priority order, and batches each team's files perWeek at a time in
+// weeks starting at start. The expected reduction of a file is the tokens
+// it must lose to get under the threshold. Teams with the most to reduce
+// come first.
+func planCampaign(ranked []priority, owners codeowners, perWeek int, start time.Time) campaign {
+	c := campaign{PerWeek: perWeek, Teams: []campaignTeam{}}
+	byTeam := make(map[string]*campaignTeam)
+	var order []string
+	for _, p := range ranked {
+		name := owners.team(p.path)
+		team, ok := byTeam[name]
+		if !ok {
+			team = &campaignTeam{Team: name}
+			byTeam[name] = team
+			order = append(order, name)
+		}
+		if team.Files%perWeek == 0 {
+			week := len(team.Weeks) + 1
+			team.Weeks = append(team.Weeks, campaignWeek{
+				Week:  week,
+				Start: start.AddDate(0, 0, 7*(week-1)).Format(time.DateOnly),
+			})
+		}
+		w := &team.Weeks[len(team.Weeks)-1]
+		w.Files = append(w.Files, campaignFile{Path: p.path, Tokens: p.tokens, Over: p.over, Commits: p.commits, Score: p.score})
+		w.ExpectedReduction += p.over
+		team.Files++
+		team.ExpectedReduction += p.over
+		c.Files++
+		c.ExpectedReduction += p.over
+	}
+
+	for _, name := range order {
+		c.Teams = append(c.Teams, *byTeam[name])
+	}
+	sort.SliceStable(c.Teams, func(i, j int) bool { return c.Teams[i].ExpectedReduction > c.Teams[j].ExpectedReduction })
+	return c
+}
+
+// nextMonday returns the date of the first Monday after t.
+func nextMonday(t time.Time) time.Time {
+	days := (8 - int(t.Weekday())) % 7
+	if days == 0 {
+		days = 7
+	}
+	y, m, d := t.AddDate(0, 0, days).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
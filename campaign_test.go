@@ -0,0 +1,62 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPlanCampaign(t *testing.T) {
+	owners, err := parseCodeowners(strings.NewReader("/api/ @api\n/web/ @web\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	violations := []fileResult{
+		{path: "api/a.go", tokens: 30000},
+		{path: "api/b.go", tokens: 27000},
+		{path: "api/c.go", tokens: 26000},
+		{path: "web/d.go", tokens: 50000},
+		{path: "tools/e.go", tokens: 25500},
+	}
+	commits := map[string]int{"api/a.go": 3, "api/b.go": 10, "api/c.go": 1, "web/d.go": 1}
+	ranked := rankByChurn(violations, 25000, func(path string) int { return commits[path] })
+
+	start := time.Date(2026, 11, 2, 0, 0, 0, 0, time.UTC)
+	c := planCampaign(ranked, owners, 2, start)
+	if c.Files != 5 || c.ExpectedReduction != 33500 {
+		t.Errorf("campaign covers %d files, %d tokens; want 5 files, 33500 tokens", c.Files, c.ExpectedReduction)
+	}
+
+	var teams []string
+	for _, team := range c.Teams {
+		teams = append(teams, team.Team)
+	}
+	if got := strings.Join(teams, ","); got != "@web,@api,(unowned)" {
+		t.Fatalf("teams = %s, want @web,@api,(unowned)", got)
+	}
+
+	api := c.Teams[1]
+	if len(api.Weeks) != 2 || api.ExpectedReduction != 8000 {
+		t.Fatalf("api plan = %+v, want 2 weeks reducing 8000 tokens", api)
+	}
+	week1 := api.Weeks[0]
+	if week1.Start != "2026-11-02" || len(week1.Files) != 2 || week1.Files[0].Path != "api/b.go" || week1.Files[1].Path != "api/a.go" {
+		t.Errorf("api week 1 = %+v, want b.go then a.go from 2026-11-02", week1)
+	}
+	if week2 := api.Weeks[1]; week2.Start != "2026-11-09" || week2.ExpectedReduction != 1000 {
+		t.Errorf("api week 2 = %+v, want 1000 tokens from 2026-11-09", week2)
+	}
+}
+
+func TestNextMonday(t *testing.T) {
+	for day, want := range map[string]string{
+		"2026-10-16": "2026-10-19", // Friday
+		"2026-10-19": "2026-10-26", // Monday
+		"2026-10-18": "2026-10-19", // Sunday
+	} {
+		d, _ := time.Parse(time.DateOnly, day)
+		if got := nextMonday(d).Format(time.DateOnly); got != want {
+			t.Errorf("nextMonday(%s) = %s, want %s", day, got, want)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"go/scanner"
+	"go/token"
+)
+
+// tokenClasses splits a file's tokens into code, comments and string
+// literals, telling authors whether to trim docs, extract embedded data
+// or split logic.
+type tokenClasses struct {
+	Code     int `json:"code"`
+	Comments int `json:"comments"`
+	Strings  int `json:"strings"`
+}
+
+// classifyTokens estimates each class's tokens as its share of src's
+// bytes times tokens, so it works with every tokenizer without extra
+// requests. It returns nil if src does not scan as Go.
+func classifyTokens(src []byte, tokens int) *tokenClasses {
+	if len(src) == 0 {
+		return nil
+	}
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+
+	var s scanner.Scanner
+	s.Init(file, src, nil, scanner.ScanComments)
+	var comments, strs int
+	for {
+		_, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		switch tok {
+		case token.COMMENT:
+			comments += len(lit)
+		case token.STRING, token.CHAR:
+			strs += len(lit)
+		}
+	}
+	if s.ErrorCount > 0 {
+		return nil
+	}
+
+	share := func(n int) int { return int(float64(tokens)*float64(n)/float64(len(src)) + 0.5) }
+	c := &tokenClasses{Comments: share(comments), Strings: share(strs)}
+	c.Code = max(tokens-c.Comments-c.Strings, 0)
+	return c
+}
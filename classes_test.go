@@ -0,0 +1,19 @@
+package main
+
+import "testing"
+
+func TestClassifyTokens(t *testing.T) {
+	// An 18 byte comment and string literals of 14, 5 and 3 bytes.
+	src := []byte("// 17 byte comment\npackage p\n\nvar s = \"string of 15\" + `abc`\nvar r = 'x'\n\nfunc f() int { return 1 }\n")
+	c := classifyTokens(src, len(src))
+	if c == nil {
+		t.Fatal("classifyTokens returned nil")
+	}
+	if c.Comments != 18 || c.Strings != 22 || c.Code != len(src)-40 {
+		t.Errorf("classes = %+v, want 18 comments, 22 strings and %d code", *c, len(src)-40)
+	}
+
+	if c := classifyTokens([]byte("package p\nvar s = \"unterminated\n"), 10); c != nil {
+		t.Errorf("classes of unscannable source = %+v, want nil", *c)
+	}
+}
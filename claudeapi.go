@@ -0,0 +1,383 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/befabri/token-lint/pkg/tokenlint"
+)
+
+const (
+	claudeAPIURL       = "https://api.anthropic.com"
+	claudeAPIVersion   = "2023-06-01"
+	defaultClaudeModel = "claude-sonnet-4-5"
+
+	// claudeBatchFileBytes is the size below which files are counted in
+	// batches; at the default ratio such a file is a few thousand tokens,
+	// far below any useful threshold.
+	claudeBatchFileBytes = 4 << 10
+)
+
+// errBudgetExhausted stops requests once the per-run budget is spent.
+var errBudgetExhausted = errors.New("request budget exhausted")
+
+// claudeTokenizer counts tokens exactly with Anthropic's count_tokens
+// endpoint. Requests are spaced at least interval apart, at most cap(sem)
+// run at once, and failures from rate limiting and server errors are
+// retried with exponential backoff. The first permanent failure is kept in
+// err and stops further requests. Once budget requests have been made,
+// the remaining files are estimated with fallback instead.
+//
+// Small files are batched: up to batchSize of them share one request, as
+// separate content blocks of one message, and each gets a share of the
+// exact total in proportion to its size. Totals stay exact while small
+// repositories need a fraction of the requests.
+type claudeTokenizer struct {
+	client   *http.Client
+	baseURL  string
+	apiKey   string
+	model    string
+	interval time.Duration // minimum gap between requests
+	retries  int
+	backoff  time.Duration // first retry delay, doubled on each attempt
+	sem      chan struct{} // nil for unlimited concurrency
+	budget   int           // 0 for unlimited
+	fallback tokenlint.Tokenizer
+
+	batchSize int           // small files per request; 1 or less disables batching
+	linger    time.Duration // how long a batch waits for more files
+
+	mu        sync.Mutex
+	next      time.Time // earliest time the next request may start
+	requests  int
+	estimated int // counts answered by fallback
+	err       error
+	pending   *claudeBatch // batch still taking files
+}
+
+// claudeBatch is a group of small files counted with one request.
+type claudeBatch struct {
+	ctx    context.Context // of the count that opened the batch
+	files  [][]byte
+	done   chan struct{} // closed once counts and err are set
+	counts []int
+	err    error
+}
+
+func newClaudeTokenizer(c tokenizerConfig) (tokenlint.Tokenizer, error) {
+	key := os.Getenv("ANTHROPIC_API_KEY")
+	if key == "" {
+		return nil, errors.New("-tokenizer claude-api requires ANTHROPIC_API_KEY")
+	}
+	baseURL := os.Getenv("ANTHROPIC_BASE_URL")
+	if baseURL == "" {
+		baseURL = claudeAPIURL
+	}
+	model := c.model
+	if model == "" {
+		model = defaultClaudeModel
+	}
+	if c.qps < 0 || c.concurrency < 0 || c.budget < 0 || c.batch < 0 {
+		return nil, errors.New("API limits must not be negative")
+	}
+	t := &claudeTokenizer{
+		client:   &http.Client{Timeout: 30 * time.Second},
+		baseURL:  strings.TrimSuffix(baseURL, "/"),
+		apiKey:   key,
+		model:    model,
+		retries:  5,
+		backoff:  time.Second,
+		budget:   c.budget,
+		fallback: tokenlint.RatioTokenizer(c.ratio),
+
+		batchSize: c.batch,
+		linger:    20 * time.Millisecond,
+	}
+	if c.qps > 0 {
+		t.interval = time.Duration(float64(time.Second) / c.qps)
+	}
+	if c.concurrency > 0 {
+		t.sem = make(chan struct{}, c.concurrency)
+	}
+	return t, nil
+}
+
+func (t *claudeTokenizer) Count(content []byte) int {
+	return t.CountContext(context.Background(), content)
+}
+
+// CountContext counts content, giving up when ctx is done. A canceled
+// count returns 0 without marking the tokenizer as failed; the caller
+// reports the cancellation.
+func (t *claudeTokenizer) CountContext(ctx context.Context, content []byte) int {
+	// The API rejects empty and whitespace-only messages.
+	if len(bytes.TrimSpace(content)) == 0 {
+		return 0
+	}
+	if t.Err() != nil {
+		return 0
+	}
+	var n int
+	var err error
+	if t.batchSize > 1 && len(content) < claudeBatchFileBytes {
+		n, err = t.countBatched(ctx, content)
+	} else {
+		n, err = t.countTokens(ctx, [][]byte{content})
+	}
+	if errors.Is(err, errBudgetExhausted) {
+		t.mu.Lock()
+		t.estimated++
+		t.mu.Unlock()
+		return t.fallback.Count(content)
+	}
+	if err != nil && ctx.Err() != nil {
+		return 0
+	}
+	if err != nil {
+		t.mu.Lock()
+		if t.err == nil {
+			t.err = err
+		}
+		t.mu.Unlock()
+		return 0
+	}
+	return n
+}
+
+// Err returns the first error that made a count fail, if any.
+func (t *claudeTokenizer) Err() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.err
+}
+
+// Fallbacks returns how many counts were estimated after the request
+// budget ran out, and the budget.
+func (t *claudeTokenizer) Fallbacks() (estimated, budget int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.estimated, t.budget
+}
+
+// retryableError is a transient API failure worth another attempt.
+type retryableError struct {
+	err   error
+	after time.Duration // server-requested delay; 0 uses backoff
+}
+
+func (e *retryableError) Error() string { return e.err.Error() }
+
+// countBatched adds content to the pending batch and returns its share of
+// the batch's count. The batch is sent once it holds batchSize files or
+// linger after it was opened, whichever comes first.
+func (t *claudeTokenizer) countBatched(ctx context.Context, content []byte) (int, error) {
+	t.mu.Lock()
+	b := t.pending
+	if b == nil {
+		b = &claudeBatch{ctx: ctx, done: make(chan struct{})}
+		t.pending = b
+		time.AfterFunc(t.linger, func() { t.flush(b) })
+	}
+	i := len(b.files)
+	b.files = append(b.files, content)
+	full := len(b.files) >= t.batchSize
+	t.mu.Unlock()
+	if full {
+		t.flush(b)
+	}
+
+	select {
+	case <-b.done:
+		if b.err != nil {
+			return 0, b.err
+		}
+		return b.counts[i], nil
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+// flush counts batch b unless it has been sent already.
+func (t *claudeTokenizer) flush(b *claudeBatch) {
+	t.mu.Lock()
+	if t.pending != b {
+		t.mu.Unlock()
+		return
+	}
+	t.pending = nil
+	t.mu.Unlock()
+
+	total, err := t.countTokens(b.ctx, b.files)
+	b.counts, b.err = apportion(total, b.files), err
+	close(b.done)
+}
+
+// apportion splits total over files in proportion to their sizes, so that
+// the shares add up to total.
+func apportion(total int, files [][]byte) []int {
+	size := 0
+	for _, f := range files {
+		size += len(f)
+	}
+	counts := make([]int, len(files))
+	if size == 0 {
+		return counts
+	}
+	seen, given := 0, 0
+	for i, f := range files {
+		seen += len(f)
+		upTo := total * seen / size
+		counts[i] = upTo - given
+		given = upTo
+	}
+	return counts
+}
+
+// countTokens counts files with one request, as a message with one
+// content block per file.
+func (t *claudeTokenizer) countTokens(ctx context.Context, files [][]byte) (int, error) {
+	var content any = string(files[0])
+	if len(files) > 1 {
+		blocks := make([]any, len(files))
+		for i, f := range files {
+			blocks[i] = map[string]any{"type": "text", "text": string(f)}
+		}
+		content = blocks
+	}
+	body, err := json.Marshal(map[string]any{
+		"model": t.model,
+		"messages": []any{map[string]any{
+			"role":    "user",
+			"content": content,
+		}},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	delay := t.backoff
+	for attempt := 0; ; attempt++ {
+		if err := t.wait(ctx); err != nil {
+			return 0, err
+		}
+		if t.sem != nil {
+			select {
+			case t.sem <- struct{}{}:
+			case <-ctx.Done():
+				return 0, ctx.Err()
+			}
+		}
+		n, err := t.post(ctx, body)
+		if t.sem != nil {
+			<-t.sem
+		}
+		var retry *retryableError
+		if err == nil || !errors.As(err, &retry) || attempt >= t.retries {
+			return n, err
+		}
+		pause := delay
+		if retry.after > 0 {
+			pause = retry.after
+		}
+		if err := sleep(ctx, pause); err != nil {
+			return 0, err
+		}
+		delay *= 2
+	}
+}
+
+// sleep pauses for d or until ctx is done, returning ctx.Err() then.
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// wait blocks until the rate limit allows another request and charges it
+// to the budget.
+func (t *claudeTokenizer) wait(ctx context.Context) error {
+	t.mu.Lock()
+	if t.budget > 0 && t.requests >= t.budget {
+		t.mu.Unlock()
+		return errBudgetExhausted
+	}
+	t.requests++
+	now := time.Now()
+	start := now
+	if t.next.After(now) {
+		start = t.next
+	}
+	t.next = start.Add(t.interval)
+	t.mu.Unlock()
+	return sleep(ctx, start.Sub(now))
+}
+
+func (t *claudeTokenizer) post(ctx context.Context, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.baseURL+"/v1/messages/count_tokens", bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("content-type", "application/json")
+	req.Header.Set("x-api-key", t.apiKey)
+	req.Header.Set("anthropic-version", claudeAPIVersion)
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return 0, &retryableError{err: err}
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, &retryableError{err: err}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		err := fmt.Errorf("count_tokens: %s: %s", resp.Status, apiErrorMessage(data))
+		// 429 is rate limiting, 529 is overload; both are transient.
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			secs, _ := strconv.Atoi(resp.Header.Get("retry-after"))
+			return 0, &retryableError{err: err, after: time.Duration(secs) * time.Second}
+		}
+		return 0, err
+	}
+
+	var out struct {
+		InputTokens int `json:"input_tokens"`
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return 0, fmt.Errorf("count_tokens: %w", err)
+	}
+	return out.InputTokens, nil
+}
+
+// apiErrorMessage extracts the message from an API error body, falling
+// back to the raw body.
+func apiErrorMessage(data []byte) string {
+	var e struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(data, &e) == nil && e.Error.Message != "" {
+		return e.Error.Message
+	}
+	return strings.TrimSpace(string(data))
+}
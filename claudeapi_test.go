@@ -0,0 +1,232 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClaudeTokenizer(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages/count_tokens" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		if r.Header.Get("x-api-key") != "test-key" || r.Header.Get("anthropic-version") == "" {
+			t.Errorf("missing auth headers: %v", r.Header)
+		}
+		// Rate limit the first attempt to exercise retries.
+		if calls.Add(1) == 1 {
+			w.Header().Set("retry-after", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		var req struct {
+			Model    string `json:"model"`
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		json.NewEncoder(w).Encode(map[string]int{"input_tokens": len(req.Messages[0].Content)})
+	}))
+	defer srv.Close()
+
+	t.Setenv("ANTHROPIC_API_KEY", "test-key")
+	t.Setenv("ANTHROPIC_BASE_URL", srv.URL)
+	tok, err := newTokenizer("claude-api", tokenizerConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct := tok.(*claudeTokenizer)
+	ct.interval, ct.backoff = 0, 0
+
+	if n := tok.Count([]byte("package main")); n != 12 {
+		t.Errorf("Count = %d, want 12", n)
+	}
+	if n := tok.Count([]byte("  \n")); n != 0 {
+		t.Errorf("Count(blank) = %d, want 0", n)
+	}
+	if err := ct.Err(); err != nil {
+		t.Errorf("Err = %v", err)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("calls = %d, want 2", got)
+	}
+}
+
+func TestClaudeTokenizerError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key"}}`))
+	}))
+	defer srv.Close()
+
+	t.Setenv("ANTHROPIC_API_KEY", "bad")
+	t.Setenv("ANTHROPIC_BASE_URL", srv.URL)
+	tok, err := newTokenizer("claude-api", tokenizerConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tok.Count([]byte("package main"))
+	err = tokenizerErr(tok)
+	if err == nil || err.Error() != "count_tokens: 401 Unauthorized: invalid x-api-key" {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestClaudeTokenizerNoKey(t *testing.T) {
+	t.Setenv("ANTHROPIC_API_KEY", "")
+	if _, err := newTokenizer("claude-api", tokenizerConfig{}); err == nil {
+		t.Error("newTokenizer succeeded without an API key")
+	}
+}
+
+func TestClaudeTokenizerBudget(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Fail the first attempt so the retry is charged to the budget.
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]int{"input_tokens": 7})
+	}))
+	defer srv.Close()
+
+	t.Setenv("ANTHROPIC_API_KEY", "test-key")
+	t.Setenv("ANTHROPIC_BASE_URL", srv.URL)
+	tok, err := newTokenizer("claude-api", tokenizerConfig{ratio: 0.5, concurrency: 2, budget: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tok.(*claudeTokenizer).backoff = 0
+
+	var got []int
+	for range 4 {
+		got = append(got, tok.Count([]byte("0123456789")))
+	}
+	want := []int{7, 7, 5, 5} // then the ratio estimate of 10 bytes
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("counts = %v, want %v", got, want)
+			break
+		}
+	}
+	if estimated, budget := tokenizerFallbacks(tok); estimated != 2 || budget != 3 {
+		t.Errorf("Fallbacks = %d, %d; want 2, 3", estimated, budget)
+	}
+	if err := tokenizerErr(tok); err != nil {
+		t.Errorf("Err = %v, want nil after budget exhaustion", err)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("calls = %d, want 3", got)
+	}
+}
+
+func TestClaudeTokenizerBatch(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		var req struct {
+			Messages []struct {
+				Content []struct {
+					Type string `json:"type"`
+					Text string `json:"text"`
+				} `json:"content"`
+			} `json:"messages"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		n := 0
+		for _, b := range req.Messages[0].Content {
+			n += len(b.Text)
+		}
+		json.NewEncoder(w).Encode(map[string]int{"input_tokens": n})
+	}))
+	defer srv.Close()
+
+	t.Setenv("ANTHROPIC_API_KEY", "test-key")
+	t.Setenv("ANTHROPIC_BASE_URL", srv.URL)
+	tok, err := newTokenizer("claude-api", tokenizerConfig{batch: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Only a full batch is sent.
+	tok.(*claudeTokenizer).linger = time.Hour
+
+	sizes := []int{10, 20, 30}
+	got := make([]int, len(sizes))
+	var wg sync.WaitGroup
+	for i, size := range sizes {
+		wg.Go(func() { got[i] = tok.Count([]byte(strings.Repeat("x", size))) })
+	}
+	wg.Wait()
+	for i := range sizes {
+		if got[i] != sizes[i] {
+			t.Errorf("counts = %v, want %v", got, sizes)
+			break
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("calls = %d, want 1 for the batch", n)
+	}
+	if err := tokenizerErr(tok); err != nil {
+		t.Errorf("Err = %v", err)
+	}
+}
+
+func TestApportion(t *testing.T) {
+	got := apportion(10, [][]byte{[]byte("a"), []byte("bb"), []byte("ccc")})
+	sum := 0
+	for _, n := range got {
+		sum += n
+	}
+	if sum != 10 || got[0] > got[1] || got[1] > got[2] {
+		t.Errorf("apportion = %v, want shares growing with size and adding up to 10", got)
+	}
+}
+
+func TestClaudeTokenizerCanceled(t *testing.T) {
+	// The server never answers until the test is over.
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	t.Setenv("ANTHROPIC_API_KEY", "test-key")
+	t.Setenv("ANTHROPIC_BASE_URL", srv.URL)
+	for _, name := range []string{"request", "rate limit"} {
+		tok, err := newTokenizer("claude-api", tokenizerConfig{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ct := tok.(*claudeTokenizer)
+		if name == "rate limit" {
+			ct.next = time.Now().Add(time.Hour)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		start := time.Now()
+		n := ct.CountContext(ctx, []byte("package main"))
+		cancel()
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("%s: canceled count took %v", name, elapsed)
+		}
+		if n != 0 || ct.Err() != nil {
+			t.Errorf("%s: CountContext = %d, Err = %v; want 0 and no error", name, n, ct.Err())
+		}
+	}
+}
@@ -0,0 +1,17 @@
+// Command tokenvet runs token-lint's token limit check as a go/analysis
+// pass, for use as a vet tool:
+//
+//	go install github.com/befabri/token-lint/cmd/tokenvet@latest
+//	go vet -vettool=$(which tokenvet) -threshold=20000 ./...
+//
+// It can also run on its own, as tokenvet ./...
+package main
+
+import (
+	"github.com/befabri/token-lint/pkg/analyzer"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(analyzer.Analyzer)
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/befabri/token-lint/pkg/tokenlint"
+)
+
+// codeownersRule is one pattern line of a CODEOWNERS file.
+type codeownersRule struct {
+	re     *regexp.Regexp
+	owners []string
+}
+
+// codeowners holds CODEOWNERS rules in file order; the last match wins.
+type codeowners []codeownersRule
+
+// codeownersLocations are the paths GitHub searches, relative to the
+// repository root, in order.
+var codeownersLocations = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// loadCodeowners reads the first CODEOWNERS file found under root. It
+// returns nil rules and no error if there is none.
+func loadCodeowners(root string) (codeowners, error) {
+	for _, loc := range codeownersLocations {
+		f, err := os.Open(filepath.Join(root, loc))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return parseCodeowners(f)
+	}
+	return nil, nil
+}
+
+func parseCodeowners(r io.Reader) (codeowners, error) {
+	var rules codeowners
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line := sc.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		re, err := tokenlint.CompileGlob(fields[0])
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, codeownersRule{re: re, owners: fields[1:]})
+	}
+	return rules, sc.Err()
+}
+
+// openCodeowners parses the CODEOWNERS file at path, or the first one
+// found under root if path is empty.
+func openCodeowners(root, path string) (codeowners, error) {
+	if path == "" {
+		return loadCodeowners(root)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parseCodeowners(f)
+}
+
+// owners returns the owners of a slash-separated path relative to the
+// repository root, or nil if no rule matches.
+func (c codeowners) owners(rel string) []string {
+	for i := len(c) - 1; i >= 0; i-- {
+		if c[i].re.MatchString(rel) {
+			return c[i].owners
+		}
+	}
+	return nil
+}
+
+// team returns a display name for the owners of rel.
+func (c codeowners) team(rel string) string {
+	owners := c.owners(rel)
+	if len(owners) == 0 {
+		return "(unowned)"
+	}
+	return strings.Join(owners, " ")
+}
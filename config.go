@@ -0,0 +1,421 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/befabri/token-lint/pkg/tokenlint"
+	"gopkg.in/yaml.v3"
+)
+
+// configFileName is the repository policy file, looked up from the
+// working directory upward.
+const configFileName = ".token-lint.yaml"
+
+// ignoreFileName lists paths to leave out of the check, in gitignore
+// syntax. Like the config file, it is looked up from the working
+// directory upward.
+const ignoreFileName = ".tokenlintignore"
+
+// config is the contents of a .token-lint.yaml file. Settings fill in
+// flags that were not given on the command line; path patterns are
+// gitignore-style and relative to the directory holding the file.
+type config struct {
+	Threshold     thresholdConfig    `yaml:"threshold"`
+	TestThreshold int                `yaml:"test_threshold"`
+	Ratio         float64            `yaml:"ratio"`
+	Ratios        map[string]float64 `yaml:"ratios"`
+	Tokenizer     string             `yaml:"tokenizer"`
+	Encoding      string             `yaml:"encoding"`
+	Model         string             `yaml:"model"`
+	Baseline      string             `yaml:"baseline"`
+	Exclude       []string           `yaml:"exclude"`
+	Generated     []string           `yaml:"generated"`
+	// GeneratedBuiltin set to false makes Generated replace the built-in
+	// generated path heuristics instead of extending them.
+	GeneratedBuiltin *bool          `yaml:"generated_builtin"`
+	Overrides        pathThresholds `yaml:"overrides"`
+	SMTP             smtpSettings   `yaml:"smtp"`
+
+	dir     string // directory containing the file
+	exclude []*regexp.Regexp
+}
+
+// smtpSettings configure mail delivery for `report -email`. The password
+// is a secret and is only read from the environment.
+type smtpSettings struct {
+	Host string `yaml:"host"`
+	Port int    `yaml:"port"`
+	User string `yaml:"user"`
+	From string `yaml:"from"`
+}
+
+// thresholdConfig is either a fixed threshold or a ramp:
+//
+//	threshold: {start: 40000, target: 25000, from: 2026-01-01, by: 2026-06-01}
+type thresholdConfig struct {
+	value int
+	ramp  string // in -ramp syntax
+}
+
+func (t *thresholdConfig) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind == yaml.ScalarNode {
+		return n.Decode(&t.value)
+	}
+	var r struct {
+		Start  int    `yaml:"start"`
+		Target int    `yaml:"target"`
+		From   string `yaml:"from"`
+		By     string `yaml:"by"`
+	}
+	if err := n.Decode(&r); err != nil {
+		return err
+	}
+	for _, key := range []struct {
+		name    string
+		missing bool
+	}{
+		{"start", r.Start == 0},
+		{"target", r.Target == 0},
+		{"from", r.From == ""},
+		{"by", r.By == ""},
+	} {
+		if key.missing {
+			return fmt.Errorf("line %d: threshold.%s is required for a ramp", n.Line, key.name)
+		}
+	}
+	t.ramp = fmt.Sprintf("%d@%s,%d@%s", r.Start, r.From, r.Target, r.By)
+	if _, err := parseRamp(t.ramp); err != nil {
+		return fmt.Errorf("line %d: threshold: %v", n.Line, err)
+	}
+	return nil
+}
+
+// pathThreshold sets the threshold for files matching a pattern.
+type pathThreshold struct {
+	pattern   string
+	re        *regexp.Regexp
+	threshold int
+}
+
+// pathThresholds are per-path threshold overrides in order; the last
+// matching pattern wins, as in CODEOWNERS.
+type pathThresholds []pathThreshold
+
+func (p *pathThresholds) UnmarshalYAML(n *yaml.Node) error {
+	if n.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: overrides must map path patterns to thresholds", n.Line)
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		var threshold int
+		if err := n.Content[i+1].Decode(&threshold); err != nil {
+			return err
+		}
+		if err := p.add(n.Content[i].Value, threshold); err != nil {
+			return fmt.Errorf("line %d: %v", n.Content[i].Line, err)
+		}
+	}
+	return nil
+}
+
+func (p *pathThresholds) add(pattern string, threshold int) error {
+	if threshold <= 0 {
+		return fmt.Errorf("threshold for %s must be positive", pattern)
+	}
+	re, err := tokenlint.CompileGlob(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid pattern %q: %v", pattern, err)
+	}
+	*p = append(*p, pathThreshold{pattern: pattern, re: re, threshold: threshold})
+	return nil
+}
+
+// Set implements flag.Value for repeated -rule PATTERN=THRESHOLD flags.
+func (p *pathThresholds) Set(s string) error {
+	pattern, value, ok := strings.Cut(s, "=")
+	if !ok {
+		return fmt.Errorf("want PATTERN=THRESHOLD, got %q", s)
+	}
+	threshold, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("invalid threshold %q", value)
+	}
+	return p.add(pattern, threshold)
+}
+
+func (p *pathThresholds) String() string {
+	var rules []string
+	for _, r := range *p {
+		rules = append(rules, fmt.Sprintf("%s=%d", r.pattern, r.threshold))
+	}
+	return strings.Join(rules, ",")
+}
+
+// match returns the threshold of the last pattern matching rel.
+func (p pathThresholds) match(rel string) (int, bool) {
+	for i := len(p) - 1; i >= 0; i-- {
+		if p[i].re.MatchString(rel) {
+			return p[i].threshold, true
+		}
+	}
+	return 0, false
+}
+
+// findConfig returns the path of the nearest config file in dir or its
+// parents, or "" if there is none.
+func findConfig(dir string) (string, error) {
+	return findUp(dir, configFileName)
+}
+
+// findUp returns the path of the nearest file called name in dir or its
+// parents, or "" if there is none.
+func findUp(dir, name string) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
+
+// loadConfig reads and validates a config file. Unknown keys are errors,
+// so typos do not silently weaken the policy.
+func loadConfig(path string) (*config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+	c, err := parseConfig(data, dir)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return c, nil
+}
+
+// parseConfig parses the contents of a config file in dir.
+func parseConfig(data []byte, dir string) (*config, error) {
+	c := &config{dir: dir}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if err := checkCategoryRatios(c.Ratios); err != nil {
+		return nil, fmt.Errorf("ratios: %v", err)
+	}
+	for _, pattern := range c.Exclude {
+		re, err := tokenlint.CompileGlob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exclude pattern %q: %v", pattern, err)
+		}
+		c.exclude = append(c.exclude, re)
+	}
+	return c, nil
+}
+
+// apply sets the flags c configures, unless they were given explicitly.
+// Settings for flags fs does not define, as in subcommands that take only
+// some of them, are skipped.
+func (c *config) apply(fs *flag.FlagSet) error {
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+
+	values := map[string]string{
+		"tokenizer": c.Tokenizer,
+		"encoding":  c.Encoding,
+		"model":     c.Model,
+		"ramp":      c.Threshold.ramp,
+	}
+	if c.Threshold.value != 0 {
+		values["threshold"] = strconv.Itoa(c.Threshold.value)
+	}
+	if c.Threshold.ramp != "" && fs.Lookup("ramp") == nil {
+		// Subcommands without -ramp take today's threshold.
+		r, err := parseRamp(c.Threshold.ramp)
+		if err != nil {
+			return fmt.Errorf("config threshold: %v", err)
+		}
+		values["threshold"] = strconv.Itoa(r.at(time.Now()))
+	}
+	if c.Baseline != "" {
+		// Relative to the config file, like every other path in it.
+		values["baseline"] = c.Baseline
+		if !filepath.IsAbs(c.Baseline) {
+			values["baseline"] = filepath.Join(c.dir, c.Baseline)
+		}
+	}
+	if c.TestThreshold != 0 {
+		values["test-threshold"] = strconv.Itoa(c.TestThreshold)
+	}
+	if c.Ratio != 0 {
+		values["ratio"] = strconv.FormatFloat(c.Ratio, 'g', -1, 64)
+	}
+	// A threshold or ramp on the command line replaces either in the file.
+	if explicit["ramp"] || explicit["threshold"] {
+		delete(values, "threshold")
+		delete(values, "ramp")
+	}
+	for name, value := range values {
+		if value == "" || explicit[name] || fs.Lookup(name) == nil {
+			continue
+		}
+		if err := fs.Set(name, value); err != nil {
+			return fmt.Errorf("config %s: %v", name, err)
+		}
+	}
+	// Without -model to carry it, the preset applies here, after the
+	// settings above so that those still win.
+	if c.Model != "" && fs.Lookup("model") == nil {
+		if err := applyModelPreset(fs, c.Model); err != nil {
+			return fmt.Errorf("config model: %v", err)
+		}
+	}
+	return nil
+}
+
+// tokenizer returns the tokenizer c selects, directly or through its
+// model preset, for commands without a -tokenizer flag. The name is empty
+// if c selects none. API limits are the main command's defaults.
+func (c *config) tokenizer() (string, tokenizerConfig) {
+	name := c.Tokenizer
+	tc := tokenizerConfig{
+		encoding:    "cl100k_base",
+		model:       defaultClaudeModel,
+		qps:         defaultAPIQPS,
+		concurrency: defaultAPIConcurrency,
+		batch:       defaultAPIBatch,
+	}
+	if p, ok := modelPresets[c.Model]; ok {
+		if name == "" {
+			name = p.tokenizer
+		}
+		if p.encoding != "" {
+			tc.encoding = p.encoding
+		}
+		if p.apiModel != "" {
+			tc.model = p.apiModel
+		}
+	}
+	if c.Encoding != "" {
+		tc.encoding = c.Encoding
+	}
+	return name, tc
+}
+
+// rel returns path relative to the config directory, or false if it lies
+// outside of it.
+func (c *config) rel(path string) (string, bool) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", false
+	}
+	rel, err := filepath.Rel(c.dir, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// excluded reports whether path matches an exclude pattern.
+func (c *config) excluded(path string) bool {
+	rel, ok := c.rel(path)
+	if !ok {
+		return false
+	}
+	for _, re := range c.exclude {
+		if re.MatchString(rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// pathThresholdFunc combines config overrides with -rule flags, whose
+// patterns are relative to the working directory and take precedence. It
+// returns nil if there are no overrides at all.
+func pathThresholdFunc(c *config, rules pathThresholds) func(string) (int, bool) {
+	if len(rules) == 0 {
+		if c == nil || len(c.Overrides) == 0 {
+			return nil
+		}
+		return c.pathThreshold
+	}
+	return func(path string) (int, bool) {
+		if rel, err := relToWorkingDir(path); err == nil {
+			if t, ok := rules.match(rel); ok {
+				return t, true
+			}
+		}
+		if c != nil {
+			return c.pathThreshold(path)
+		}
+		return 0, false
+	}
+}
+
+// relToWorkingDir returns path relative to the working directory as a
+// slash-separated path.
+func relToWorkingDir(path string) (string, error) {
+	if !filepath.IsAbs(path) {
+		return filepath.ToSlash(filepath.Clean(path)), nil
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(cwd, path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// pathThreshold returns the override threshold for path, if any.
+func (c *config) pathThreshold(path string) (int, bool) {
+	rel, ok := c.rel(path)
+	if !ok {
+		return 0, false
+	}
+	return c.Overrides.match(rel)
+}
+
+// checkCategoryRatios validates per-category ratios from the config.
+func checkCategoryRatios(ratios map[string]float64) error {
+	for name, ratio := range ratios {
+		known := false
+		for _, c := range tokenlint.Categories {
+			known = known || c == name
+		}
+		if !known {
+			return fmt.Errorf("unknown file category %q (want one of %v)", name, tokenlint.Categories)
+		}
+		if ratio <= 0 {
+			return fmt.Errorf("ratio for %s must be positive", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, configFileName)
+	content := `threshold: 30000
+test_threshold: 50000
+ratio: 0.3
+tokenizer: bpe
+exclude:
+  - internal/legacy/**
+  - "*_mock.go"
+overrides:
+  cmd/**: 15000
+  cmd/big/**: 40000
+`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	sub := filepath.Join(dir, "cmd", "tool")
+	if err := os.MkdirAll(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	found, err := findConfig(sub)
+	if err != nil || found != path {
+		t.Fatalf("findConfig = %q, %v; want %q", found, err, path)
+	}
+
+	c, err := loadConfig(found)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Threshold.value != 30000 || c.TestThreshold != 50000 || c.Ratio != 0.3 || c.Tokenizer != "bpe" {
+		t.Errorf("config = %+v", c)
+	}
+
+	for _, tt := range []struct {
+		path     string
+		excluded bool
+	}{
+		{"internal/legacy/old.go", true},
+		{"pkg/client_mock.go", true},
+		{"internal/new.go", false},
+	} {
+		if got := c.excluded(filepath.Join(dir, tt.path)); got != tt.excluded {
+			t.Errorf("excluded(%s) = %v, want %v", tt.path, got, tt.excluded)
+		}
+	}
+
+	for _, tt := range []struct {
+		path string
+		want int
+	}{
+		{"cmd/tool/main.go", 15000},
+		{"cmd/big/main.go", 40000}, // later patterns win
+		{"pkg/a.go", 0},
+	} {
+		if got, _ := c.pathThreshold(filepath.Join(dir, tt.path)); got != tt.want {
+			t.Errorf("pathThreshold(%s) = %d, want %d", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestRuleFlags(t *testing.T) {
+	var rules pathThresholds
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Var(&rules, "rule", "")
+	if err := fs.Parse([]string{"-rule", "cmd/**=15000", "-rule", "internal/legacy/**=40000"}); err != nil {
+		t.Fatal(err)
+	}
+	if rules.String() != "cmd/**=15000,internal/legacy/**=40000" {
+		t.Errorf("rules = %s", rules.String())
+	}
+
+	threshold := pathThresholdFunc(nil, rules)
+	for _, tt := range []struct {
+		path string
+		want int
+	}{
+		{filepath.Join("cmd", "tool", "main.go"), 15000},
+		{filepath.Join("internal", "legacy", "x.go"), 40000},
+		{"main.go", 0},
+	} {
+		if got, _ := threshold(tt.path); got != tt.want {
+			t.Errorf("threshold(%s) = %d, want %d", tt.path, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"cmd", "cmd=x", "cmd=0"} {
+		if err := rules.Set(bad); err == nil {
+			t.Errorf("Set(%q) succeeded", bad)
+		}
+	}
+	if pathThresholdFunc(nil, nil) != nil {
+		t.Error("pathThresholdFunc without overrides is not nil")
+	}
+}
+
+func TestConfigApply(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, configFileName)
+	content := "threshold: {start: 40000, target: 25000, from: 2026-01-01, by: 2026-06-01}\nratio: 0.4\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := loadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	ramp := fs.String("ramp", "", "")
+	ratio := fs.Float64("ratio", defaultRatio, "")
+	for _, name := range []string{"threshold", "tokenizer", "encoding", "model"} {
+		fs.String(name, "", "")
+	}
+	if err := fs.Parse([]string{"-ratio", "0.5"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.apply(fs); err != nil {
+		t.Fatal(err)
+	}
+	if *ramp != "40000@2026-01-01,25000@2026-06-01" {
+		t.Errorf("ramp = %q", *ramp)
+	}
+	if *ratio != 0.5 {
+		t.Errorf("ratio = %g, want the explicit 0.5", *ratio)
+	}
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	for _, content := range []string{
+		"treshold: 1000\n",
+		"overrides: [cmd]\n",
+		"overrides:\n  cmd/**: 0\n",
+		"threshold: {start: 40000, target: 25000, by: 2026-06-01}\n",
+	} {
+		path := filepath.Join(t.TempDir(), configFileName)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := loadConfig(path); err == nil {
+			t.Errorf("loadConfig accepted %q", strings.TrimSpace(content))
+		}
+	}
+}
+
+func TestLoadConfigRampMissingKey(t *testing.T) {
+	_, err := parseConfig([]byte("threshold: {start: 40000, target: 25000, by: 2026-06-01}\n"), "/repo")
+	if err == nil || !strings.Contains(err.Error(), "threshold.from is required") {
+		t.Errorf("parseConfig error = %v, want threshold.from is required", err)
+	}
+}
+
+func FuzzParseConfig(f *testing.F) {
+	f.Add([]byte("threshold: 25000\ntest_threshold: 50000\nratio: 0.65\nexclude:\n  - internal/legacy/**\noverrides:\n  cmd/**: 15000\n"))
+	f.Add([]byte("threshold: {start: 40000, target: 25000, from: 2026-01-01, by: 2026-06-01}\n"))
+	f.Add([]byte("ratios:\n  test: 0.2\ngenerated:\n  - '*_templ.go'\ngenerated_builtin: false\n"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		c, err := parseConfig(data, "/repo")
+		if err != nil {
+			return
+		}
+		c.excluded("/repo/internal/legacy/a.go")
+		c.pathThreshold("/repo/cmd/main.go")
+		fs := flag.NewFlagSet("fuzz", flag.ContinueOnError)
+		fs.Int("threshold", defaultThreshold, "")
+		fs.Int("test-threshold", 0, "")
+		fs.Float64("ratio", defaultRatio, "")
+		fs.String("tokenizer", "", "")
+		fs.String("encoding", "", "")
+		fs.String("model", "", "")
+		fs.String("ramp", "", "")
+		fs.String("baseline", "", "")
+		c.apply(fs)
+	})
+}
+
+func TestFindUpIgnoreFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ignoreFileName)
+	if err := os.WriteFile(path, []byte("fixtures/\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sub := filepath.Join(dir, "pkg")
+	if err := os.Mkdir(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	found, err := findUp(sub, ignoreFileName)
+	if err != nil || found != path {
+		t.Fatalf("findUp = %q, %v; want %q", found, err, path)
+	}
+}
+
+func TestCheckCategoryRatios(t *testing.T) {
+	if err := checkCategoryRatios(map[string]float64{"test": 0.3, "protobuf": 0.5}); err != nil {
+		t.Error(err)
+	}
+	if err := checkCategoryRatios(map[string]float64{"tests": 0.3}); err == nil {
+		t.Error("unknown category accepted")
+	}
+	if err := checkCategoryRatios(map[string]float64{"test": 0}); err == nil {
+		t.Error("zero ratio accepted")
+	}
+}
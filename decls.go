@@ -0,0 +1,124 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// declInfo describes one top-level declaration of a Go file.
+type declInfo struct {
+	name  string // e.g. "func run", "method (*T).String", "type T"
+	start int    // byte offset, including the doc comment
+	end   int    // byte offset just past the declaration
+	line  int    // line of start
+}
+
+// fileDecls returns the top-level declarations of f in source order.
+func fileDecls(fset *token.FileSet, f *ast.File) []declInfo {
+	var decls []declInfo
+	for _, d := range f.Decls {
+		start := d.Pos()
+		switch d := d.(type) {
+		case *ast.FuncDecl:
+			if d.Doc != nil {
+				start = d.Doc.Pos()
+			}
+		case *ast.GenDecl:
+			if d.Doc != nil {
+				start = d.Doc.Pos()
+			}
+		}
+		pos := fset.Position(start)
+		decls = append(decls, declInfo{
+			name:  declName(d),
+			start: pos.Offset,
+			end:   fset.Position(d.End()).Offset,
+			line:  pos.Line,
+		})
+	}
+	return decls
+}
+
+// declSize is a declaration with its estimated share of a file's tokens.
+type declSize struct {
+	declInfo
+	tokens int
+}
+
+// largestDecls returns up to n top-level declarations of src, largest
+// first. Each is estimated as its share of the file's tokens by size, so
+// no extra counting is needed. It returns nil if src does not parse.
+func largestDecls(filename string, src []byte, tokens, n int) []declSize {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil || len(src) == 0 {
+		return nil
+	}
+	var sizes []declSize
+	for _, d := range fileDecls(fset, f) {
+		sizes = append(sizes, declSize{d, int(int64(tokens) * int64(d.end-d.start) / int64(len(src)))})
+	}
+	sort.SliceStable(sizes, func(i, j int) bool { return sizes[i].tokens > sizes[j].tokens })
+	if len(sizes) > n {
+		sizes = sizes[:n]
+	}
+	return sizes
+}
+
+// declName returns a short human-readable name for a declaration.
+func declName(d ast.Decl) string {
+	switch d := d.(type) {
+	case *ast.FuncDecl:
+		if d.Recv != nil && len(d.Recv.List) > 0 {
+			return fmt.Sprintf("method (%s).%s", exprString(d.Recv.List[0].Type), d.Name.Name)
+		}
+		return "func " + d.Name.Name
+	case *ast.GenDecl:
+		kind := d.Tok.String()
+		var names []string
+		for _, spec := range d.Specs {
+			switch s := spec.(type) {
+			case *ast.TypeSpec:
+				names = append(names, s.Name.Name)
+			case *ast.ValueSpec:
+				for _, n := range s.Names {
+					names = append(names, n.Name)
+				}
+			}
+		}
+		switch {
+		case len(names) == 0:
+			return kind
+		case len(names) > 3:
+			return fmt.Sprintf("%s %s, ... (%d names)", kind, strings.Join(names[:3], ", "), len(names))
+		default:
+			return kind + " " + strings.Join(names, ", ")
+		}
+	}
+	return "declaration"
+}
+
+// exprString renders simple type expressions such as receivers.
+func exprString(e ast.Expr) string {
+	switch e := e.(type) {
+	case *ast.Ident:
+		return e.Name
+	case *ast.StarExpr:
+		return "*" + exprString(e.X)
+	case *ast.IndexExpr:
+		return exprString(e.X) + "[" + exprString(e.Index) + "]"
+	case *ast.IndexListExpr:
+		var params []string
+		for _, p := range e.Indices {
+			params = append(params, exprString(p))
+		}
+		return exprString(e.X) + "[" + strings.Join(params, ", ") + "]"
+	case *ast.SelectorExpr:
+		return exprString(e.X) + "." + e.Sel.Name
+	}
+	return "?"
+}
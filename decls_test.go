@@ -0,0 +1,38 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLargestDecls(t *testing.T) {
+	src := `package a
+
+// Big does a lot.
+func Big() {
+	` + strings.Repeat("_ = 1\n\t", 50) + `
+}
+
+type T struct{ A, B int }
+
+func (t *T) Medium() {
+	` + strings.Repeat("_ = 1\n\t", 10) + `
+}
+
+var x = 1
+`
+	got := largestDecls("a.go", []byte(src), 1000, 2)
+	if len(got) != 2 {
+		t.Fatalf("largestDecls returned %d declarations, want 2", len(got))
+	}
+	if got[0].name != "func Big" || got[0].line != 3 || got[1].name != "method (*T).Medium" {
+		t.Errorf("largestDecls = %+v, want func Big (line 3) then method (*T).Medium", got)
+	}
+	if got[0].tokens <= got[1].tokens || got[0].tokens+got[1].tokens > 1000 {
+		t.Errorf("token shares %d, %d do not fit the file's 1000 tokens", got[0].tokens, got[1].tokens)
+	}
+
+	if got := largestDecls("bad.go", []byte("not go"), 10, 5); got != nil {
+		t.Errorf("largestDecls on invalid source = %v, want nil", got)
+	}
+}
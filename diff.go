@@ -0,0 +1,240 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// fileDelta is the token change of one file between two refs.
+type fileDelta struct {
+	Path    string   `json:"path"`
+	OldPath string   `json:"oldPath,omitempty"` // set for renames
+	Before  int      `json:"before"`
+	After   int      `json:"after"`
+	Delta   int      `json:"delta"`
+	Growth  *float64 `json:"growth,omitempty"` // percent; nil for added files
+	Failed  string   `json:"failed,omitempty"` // why the file fails the check
+
+	threshold  int    // effective threshold of the new version
+	suppressed string // //tokenlint:ignore reason in the new version
+}
+
+// runDiff implements `token-lint diff`, which reports how the token count
+// of every changed Go file differs between two refs.
+func runDiff(args []string) int {
+	fs := flag.NewFlagSet("token-lint diff", flag.ContinueOnError)
+	threshold := fs.Int("threshold", defaultThreshold, "fail if a file grows and ends up over this many tokens")
+	ratio := fs.Float64("ratio", defaultRatio, "tokens per character ratio")
+	maxGrowth := fs.Float64("max-growth", 0, "fail if a file grows by more than this many percent (0 disables)")
+	format := fs.String("format", "text", "output format: text or json")
+
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 1
+	}
+	pol, err := loadPolicy(fs, "", "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	if *ratio <= 0 || *threshold <= 0 {
+		fmt.Fprintln(os.Stderr, "error: ratio and threshold must be positive")
+		return 1
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "error: unknown diff format %q\n", *format)
+		return 1
+	}
+	if fs.NArg() < 2 {
+		fmt.Fprintln(os.Stderr, "usage: token-lint diff [flags] OLD NEW [PATH...]")
+		return 1
+	}
+	from, to := fs.Arg(0), fs.Arg(1)
+	opts, err := pol.options(*threshold, *ratio)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	root, err := repoRoot()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+
+	changes, err := refChanges(from, to, fs.Args()[2:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	var kept []refChange
+	var oldPaths, newPaths []string
+	for _, c := range changes {
+		name := c.path
+		if name == "" {
+			name = c.oldPath
+		}
+		if !strings.HasSuffix(name, ".go") || pol.skipsRel(root, name) {
+			continue
+		}
+		kept = append(kept, c)
+		if c.oldPath != "" {
+			oldPaths = append(oldPaths, c.oldPath)
+		}
+		if c.path != "" {
+			newPaths = append(newPaths, c.path)
+		}
+	}
+	before, err := measureAt(from, root, oldPaths, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	after, err := measureAt(to, root, newPaths, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+
+	var deltas []fileDelta
+	for _, c := range kept {
+		d := fileDelta{Path: c.path}
+		if c.path == "" {
+			d.Path = c.oldPath // deleted
+		} else if c.oldPath != c.path {
+			d.OldPath = c.oldPath
+		}
+		if c.oldPath != "" {
+			d.Before = before[c.oldPath].tokens
+		}
+		if c.path != "" {
+			r := after[c.path]
+			d.After, d.threshold, d.suppressed = r.tokens, r.threshold, r.suppressed
+		}
+		deltas = append(deltas, d)
+	}
+	failed := checkDeltas(deltas, *maxGrowth)
+
+	if *format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if deltas == nil {
+			deltas = []fileDelta{}
+		}
+		if err := enc.Encode(deltas); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 1
+		}
+	} else {
+		printDeltas(os.Stdout, deltas, from, to)
+	}
+	if failed > 0 {
+		fmt.Fprintf(os.Stderr, "%d file(s) grew too much between %s and %s\n", failed, from, to)
+		return 1
+	}
+	return 0
+}
+
+// measureAt analyzes the files at ref, given relative to root, the way the
+// main check does, so that directives and path overrides set each file's
+// threshold. It returns the results by relative path.
+func measureAt(ref, root string, rels []string, opts analyzeOptions) (map[string]fileResult, error) {
+	src := refSource{ref: ref, root: root, rel: make(map[string]string, len(rels))}
+	files := make([]string, 0, len(rels))
+	for _, rel := range rels {
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		src.rel[path] = rel
+		files = append(files, path)
+	}
+	opts.read = src.read
+	results, _ := analyzeFiles(context.Background(), files, opts)
+	byRel := make(map[string]fileResult, len(results))
+	for _, r := range results {
+		byRel[src.rel[r.path]] = r
+	}
+	for _, rel := range rels {
+		if _, ok := byRel[rel]; !ok {
+			return nil, fmt.Errorf("cannot analyze %s at %s", rel, ref)
+		}
+	}
+	return byRel, nil
+}
+
+// checkDeltas computes each delta and growth, marks the files that grow
+// and end up over their threshold or grow by more than maxGrowth percent,
+// and sorts deltas by descending absolute change. Files suppressed with
+// //tokenlint:ignore never fail. It returns the number of failing files.
+func checkDeltas(deltas []fileDelta, maxGrowth float64) int {
+	failed := 0
+	for i := range deltas {
+		d := &deltas[i]
+		d.Delta = d.After - d.Before
+		if d.Before > 0 {
+			g := float64(d.Delta) / float64(d.Before) * 100
+			d.Growth = &g
+		}
+		switch {
+		case d.Delta <= 0 || d.suppressed != "":
+		case d.After > d.threshold:
+			d.Failed = fmt.Sprintf("over the %d token threshold", d.threshold)
+		case maxGrowth > 0 && d.Growth != nil && *d.Growth > maxGrowth:
+			d.Failed = fmt.Sprintf("grew more than %g%%", maxGrowth)
+		}
+		if d.Failed != "" {
+			failed++
+		}
+	}
+	sort.SliceStable(deltas, func(i, j int) bool {
+		a, b := deltas[i].Delta, deltas[j].Delta
+		if abs(a) != abs(b) {
+			return abs(a) > abs(b)
+		}
+		return deltas[i].Path < deltas[j].Path
+	})
+	return failed
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+func printDeltas(w io.Writer, deltas []fileDelta, from, to string) {
+	if len(deltas) == 0 {
+		fmt.Fprintf(w, "No Go files changed between %s and %s\n", from, to)
+		return
+	}
+	fmt.Fprintf(w, "%-60s %8s %8s %8s %8s\n", "FILE", "BEFORE", "AFTER", "DELTA", "CHANGE")
+	fmt.Fprintln(w, strings.Repeat("-", 96))
+	total := 0
+	for _, d := range deltas {
+		name := quotePath(d.Path)
+		if d.OldPath != "" {
+			name = quotePath(d.OldPath) + " -> " + name
+		}
+		change := "new"
+		switch {
+		case d.After == 0 && d.Before > 0:
+			change = "deleted"
+		case d.Growth != nil:
+			change = fmt.Sprintf("%+.1f%%", *d.Growth)
+		}
+		fmt.Fprintf(w, "%-60s %8d %8d %+8d %8s", name, d.Before, d.After, d.Delta, change)
+		if d.Failed != "" {
+			fmt.Fprintf(w, " <- %s", d.Failed)
+		}
+		fmt.Fprintln(w)
+		total += d.Delta
+	}
+	fmt.Fprintf(w, "\nTotal: %+d tokens across %d file(s)\n", total, len(deltas))
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRefChanges(t *testing.T) {
+	dir := newTestRepo(t)
+	body := "package a\n\n// A long enough body for git to detect the rename.\nfunc F() int { return 1 }\n"
+	testCommit(t, dir, "", map[string]string{"old.go": body, "edit.go": "package a\n", "gone.go": "package a\n\nvar gone = 1\n"})
+	testGit(t, dir, "", "mv", "old.go", "new.go")
+	testGit(t, dir, "", "rm", "--quiet", "gone.go")
+	if err := os.WriteFile(filepath.Join(dir, "edit.go"), []byte("package a // edited\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	testCommit(t, dir, "", map[string]string{"added.go": "package a\n\nfunc Added() {}\n"})
+	t.Chdir(dir)
+
+	got, err := refChanges("HEAD~1", "HEAD", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []refChange{
+		{path: "added.go"},
+		{oldPath: "edit.go", path: "edit.go"},
+		{oldPath: "gone.go"},
+		{oldPath: "old.go", path: "new.go"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("refChanges = %+v, want %+v", got, want)
+	}
+
+	got, err = refChanges("HEAD~1", "HEAD", []string{"edit.go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0].path != "edit.go" {
+		t.Errorf("refChanges with pathspec = %+v, want only edit.go", got)
+	}
+}
+
+func TestCheckDeltas(t *testing.T) {
+	deltas := []fileDelta{
+		{Path: "small.go", Before: 100, After: 150, threshold: 1000},    // +50%
+		{Path: "over.go", Before: 900, After: 1100, threshold: 1000},    // crosses 1000
+		{Path: "shrunk.go", Before: 2000, After: 1500, threshold: 1000}, // over but shrinking
+		{Path: "added.go", After: 400, threshold: 1000},
+		{Path: "steady.go", Before: 500, After: 510, threshold: 1000},        // +2%
+		{Path: "big_test.go", Before: 1800, After: 1900, threshold: 4000},    // under its own threshold
+		{Path: "ignored.go", After: 1200, threshold: 1000, suppressed: "ok"}, // suppressed
+	}
+	if n := checkDeltas(deltas, 10); n != 2 {
+		t.Errorf("checkDeltas failed %d files, want 2", n)
+	}
+
+	var order []string
+	failed := make(map[string]bool)
+	for _, d := range deltas {
+		order = append(order, d.Path)
+		failed[d.Path] = d.Failed != ""
+	}
+	want := []string{"ignored.go", "shrunk.go", "added.go", "over.go", "big_test.go", "small.go", "steady.go"}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("order = %v, want %v", order, want)
+	}
+	if !failed["over.go"] || !failed["small.go"] || failed["shrunk.go"] || failed["added.go"] || failed["steady.go"] {
+		t.Errorf("failed = %v, want over.go and small.go", failed)
+	}
+	if failed["big_test.go"] || failed["ignored.go"] {
+		t.Errorf("failed = %v, want per-file thresholds and suppressions honored", failed)
+	}
+	if deltas[2].Growth != nil {
+		t.Errorf("added.go growth = %v, want nil", *deltas[2].Growth)
+	}
+}
+
+func TestPrintDeltasDeleted(t *testing.T) {
+	deltas := []fileDelta{
+		{Path: "gone.go", Before: 300, Delta: -300},
+		{Path: "added.go", After: 400, Delta: 400, threshold: 1000},
+	}
+	checkDeltas(deltas, 0)
+	var out strings.Builder
+	printDeltas(&out, deltas, "a", "b")
+	for _, line := range strings.Split(out.String(), "\n") {
+		if strings.HasPrefix(line, "gone.go") && !strings.HasSuffix(line, " deleted") {
+			t.Errorf("deleted file line = %q, want it marked deleted", line)
+		}
+		if strings.HasPrefix(line, "added.go") && !strings.HasSuffix(line, " new") {
+			t.Errorf("added file line = %q, want it marked new", line)
+		}
+	}
+}
+
+func TestMeasureAt(t *testing.T) {
+	dir := newTestRepo(t)
+	testCommit(t, dir, "", map[string]string{
+		"a.go":      "package a\n",
+		"a_test.go": "package a\n",
+		"big.go":    "//tokenlint:threshold=9000\npackage a\n",
+		"skip.go":   "//tokenlint:ignore lookup tables\npackage a\n",
+	})
+	t.Chdir(dir)
+
+	opts := analyzeOptions{threshold: 100, testThreshold: 400, ratio: defaultRatio, stderr: io.Discard}
+	got, err := measureAt("HEAD", dir, []string{"a.go", "a_test.go", "big.go", "skip.go"}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rel, want := range map[string]int{"a.go": 100, "a_test.go": 400, "big.go": 9000} {
+		if got[rel].threshold != want {
+			t.Errorf("%s threshold = %d, want %d", rel, got[rel].threshold, want)
+		}
+	}
+	if got["skip.go"].suppressed != "lookup tables" {
+		t.Errorf("skip.go suppressed = %q, want the directive reason", got["skip.go"].suppressed)
+	}
+
+	if _, err := measureAt("HEAD", dir, []string{"missing.go"}, opts); err == nil {
+		t.Error("measureAt of a missing file succeeded")
+	}
+}
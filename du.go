@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// duEntry is the token weight of one directory in `token-lint du`.
+type duEntry struct {
+	dir    string
+	files  int
+	tokens int
+}
+
+// runDu implements `token-lint du`, which summarizes total tokens per
+// directory as a quick overview of where a repository's weight is,
+// separate from the violation check.
+func runDu(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("token-lint du", flag.ContinueOnError)
+	depth := fs.Int("depth", 1, "summarize directories at most this many levels below the arguments' common root")
+	ratio := fs.Float64("ratio", defaultRatio, "tokens per character ratio")
+
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 1
+	}
+	pol, err := loadPolicy(fs, "", "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	if *ratio <= 0 {
+		fmt.Fprintln(os.Stderr, "error: ratio must be positive")
+		return 1
+	}
+	if *depth < 0 {
+		fmt.Fprintln(os.Stderr, "error: depth must not be negative")
+		return 1
+	}
+
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{"./..."}
+	}
+	files, err := pol.expand(ctx, paths)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+
+	opts, err := pol.options(defaultThreshold, *ratio)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	results, _ := analyzeFiles(ctx, files, opts)
+	if len(results) == 0 {
+		fmt.Fprintln(os.Stderr, "no Go files found")
+		return 0
+	}
+	printDu(os.Stdout, diskUsage(results, *depth))
+	return 0
+}
+
+// diskUsage totals results for every directory up to depth levels below
+// their common root, like du -d: each directory includes everything below
+// it, and the root comes first with the grand total. Entries are sorted
+// by descending tokens.
+func diskUsage(results []fileResult, depth int) []duEntry {
+	root := buildTree(results)
+	var entries []duEntry
+	var walk func(n *treeNode, dir string, level int)
+	walk = func(n *treeNode, dir string, level int) {
+		name := strings.TrimSuffix(dir, "/")
+		if name == "" {
+			name = n.name
+		}
+		entries = append(entries, duEntry{name, n.files, n.tokens})
+		if level == depth {
+			return
+		}
+		for _, c := range n.sorted() {
+			if c.children != nil {
+				walk(c, dir+c.name+"/", level+1)
+			}
+		}
+	}
+	prefix := root.name
+	if prefix == "." {
+		prefix = ""
+	}
+	walk(root, prefix, 0)
+
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].tokens > entries[j].tokens })
+	return entries
+}
+
+func printDu(w io.Writer, entries []duEntry) {
+	fmt.Fprintf(w, "%10s %7s  %s\n", "TOKENS", "FILES", "DIRECTORY")
+	for _, e := range entries {
+		fmt.Fprintf(w, "%10d %7d  %s\n", e.tokens, e.files, quotePath(filepath.FromSlash(e.dir)))
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestDiskUsage(t *testing.T) {
+	results := []fileResult{
+		{path: "main.go", tokens: 5},
+		{path: filepath.Join("pkg", "a", "x.go"), tokens: 30},
+		{path: filepath.Join("pkg", "a", "deep", "y.go"), tokens: 20},
+		{path: filepath.Join("pkg", "b", "z.go"), tokens: 40},
+		{path: filepath.Join("cmd", "w.go"), tokens: 10},
+	}
+	want := []duEntry{
+		{".", 5, 105},
+		{"pkg", 3, 90},
+		{"pkg/a", 2, 50},
+		{"pkg/b", 1, 40},
+		{"cmd", 1, 10},
+	}
+	got := diskUsage(results, 2)
+	if len(got) != len(want) {
+		t.Fatalf("entries = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("entries[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+
+	if got := diskUsage(results, 0); len(got) != 1 || got[0] != want[0] {
+		t.Errorf("depth 0 entries = %v, want only the total", got)
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/scanner"
+	"go/token"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// langShare is the fraction of a file's bytes in one content language.
+type langShare struct {
+	lang  string
+	share float64
+}
+
+var (
+	htmlPattern = regexp.MustCompile(`(?i)<(!doctype|html|head|body|div|span|table|p|a|script|style|form|ul|li)[\s>/]`)
+	sqlPattern  = regexp.MustCompile(`(?i)\b(select\s.+\sfrom|insert\s+into|update\s.+\sset|delete\s+from|create\s+(table|index|view))\b`)
+)
+
+// classifyContent splits src into content languages: Go code, prose
+// comments, and whatever is embedded in comments and string literals
+// (html, sql, json, shell or plain text). It returns each language's share
+// of the file, largest first. Files that fail to scan are all "go".
+func classifyContent(src []byte) []langShare {
+	bytesBy := make(map[string]int)
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+
+	var s scanner.Scanner
+	s.Init(file, src, nil, scanner.ScanComments)
+	embedded := 0
+	for {
+		_, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		var lang string
+		switch tok {
+		case token.COMMENT:
+			lang = classifyComment(lit)
+		case token.STRING:
+			lang = classifyString(lit)
+		default:
+			continue
+		}
+		if lang == "" {
+			continue
+		}
+		bytesBy[lang] += len(lit)
+		embedded += len(lit)
+	}
+	if s.ErrorCount > 0 {
+		return []langShare{{"go", 1}}
+	}
+	bytesBy["go"] += len(src) - embedded
+
+	var shares []langShare
+	for lang, n := range bytesBy {
+		if n > 0 {
+			shares = append(shares, langShare{lang, float64(n) / float64(len(src))})
+		}
+	}
+	sort.Slice(shares, func(i, j int) bool {
+		if shares[i].share != shares[j].share {
+			return shares[i].share > shares[j].share
+		}
+		return shares[i].lang < shares[j].lang
+	})
+	return shares
+}
+
+func classifyComment(lit string) string {
+	if strings.HasPrefix(lit, "//go:generate") {
+		return "shell"
+	}
+	if strings.HasPrefix(lit, "/*") {
+		if lang := classifyText(strings.TrimSuffix(lit[2:], "*/")); lang != "" {
+			return lang
+		}
+	}
+	return "comments"
+}
+
+// classifyString returns the embedded language of a string literal, or ""
+// for short strings that are ordinary code.
+func classifyString(lit string) string {
+	text, err := strconv.Unquote(lit)
+	if err != nil {
+		text = lit
+	}
+	if len(text) < 80 {
+		return ""
+	}
+	if lang := classifyText(text); lang != "" {
+		return lang
+	}
+	return "text"
+}
+
+func classifyText(text string) string {
+	trimmed := strings.TrimSpace(text)
+	switch {
+	case strings.HasPrefix(trimmed, "#!"):
+		return "shell"
+	case (strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[")) && json.Valid([]byte(trimmed)):
+		return "json"
+	case htmlPattern.MatchString(text):
+		return "html"
+	case sqlPattern.MatchString(text):
+		return "sql"
+	}
+	return ""
+}
+
+// describeShares formats shares as "go 60%, html 35%, comments 5%".
+func describeShares(shares []langShare) string {
+	parts := make([]string, len(shares))
+	for i, s := range shares {
+		parts[i] = fmt.Sprintf("%s %.0f%%", s.lang, s.share*100)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// dominantEmbedded returns the language worth a remediation hint: embedded
+// content making up at least a fifth of the file, or comments at least
+// half of it. It returns "" otherwise. The hint itself is the
+// "remedy.<lang>" message.
+func dominantEmbedded(shares []langShare) string {
+	for _, s := range shares {
+		limit := 0.2
+		if s.lang == "comments" {
+			limit = 0.5
+		}
+		if s.lang != "go" && s.share >= limit {
+			return s.lang
+		}
+	}
+	return ""
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestClassifyContent(t *testing.T) {
+	html := "`<html><body><div>" + strings.Repeat("hello ", 50) + "</div></body></html>`"
+	src := "package p\n\n//go:generate sh -c \"echo hi\"\n\n// Doc.\nconst page = " + html + "\n\nvar q = \"short\"\n"
+
+	shares := classifyContent([]byte(src))
+	got := make(map[string]float64)
+	for _, s := range shares {
+		got[s.lang] = s.share
+	}
+
+	if shares[0].lang != "html" {
+		t.Errorf("dominant language = %q, want html (%s)", shares[0].lang, describeShares(shares))
+	}
+	for _, lang := range []string{"go", "shell", "comments"} {
+		if got[lang] == 0 {
+			t.Errorf("missing %s share in %s", lang, describeShares(shares))
+		}
+	}
+	if lang := dominantEmbedded(shares); lang != "html" {
+		t.Errorf("dominantEmbedded = %q, want html", lang)
+	}
+}
+
+func TestClassifyText(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{`{"a": [1, 2, 3]}`, "json"},
+		{"SELECT id, name FROM users WHERE id = $1", "sql"},
+		{"#!/bin/sh\necho hi", "shell"},
+		{"<div class=\"x\">hi</div>", "html"},
+		{"just some words", ""},
+	}
+	for _, tt := range tests {
+		if got := classifyText(tt.text); got != tt.want {
+			t.Errorf("classifyText(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
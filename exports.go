@@ -0,0 +1,69 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// checkExportedSymbols reports non-test files that declare more than max
+// exported symbols. A wide public surface in one file makes agents read
+// the whole file to find the one function they need, whatever its size.
+func checkExportedSymbols(files []string, max int, read func(string) ([]byte, error)) []finding {
+	var findings []finding
+	for _, path := range files {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		src, err := read(path)
+		if err != nil {
+			continue
+		}
+		f, err := parser.ParseFile(token.NewFileSet(), path, src, parser.SkipObjectResolution)
+		if err != nil {
+			// The token check still reports files that do not parse.
+			continue
+		}
+		if n := exportedSymbols(f); n > max {
+			findings = append(findings, finding{rule: "exported-symbols", path: path, key: "exportsOver", args: []any{n, max}})
+		}
+	}
+	sort.Slice(findings, func(i, j int) bool { return findings[i].path < findings[j].path })
+	return findings
+}
+
+// exportedSymbols counts the exported package-level names f declares,
+// plus exported methods on exported types.
+func exportedSymbols(f *ast.File) int {
+	var n int
+	for _, d := range f.Decls {
+		switch d := d.(type) {
+		case *ast.FuncDecl:
+			if !d.Name.IsExported() {
+				continue
+			}
+			if d.Recv != nil && !ast.IsExported(receiverName(d)) {
+				continue
+			}
+			n++
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					if s.Name.IsExported() {
+						n++
+					}
+				case *ast.ValueSpec:
+					for _, name := range s.Names {
+						if name.IsExported() {
+							n++
+						}
+					}
+				}
+			}
+		}
+	}
+	return n
+}
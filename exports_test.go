@@ -0,0 +1,63 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExportedSymbols(t *testing.T) {
+	src := `package p
+
+const A, b = 1, 2
+
+var (
+	C int
+	d int
+)
+
+type T struct{}
+type u struct{}
+
+func F()      {}
+func g()      {}
+func (T) M()  {}
+func (*T) n() {}
+func (u) M()  {}
+`
+	f, err := parser.ParseFile(token.NewFileSet(), "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A, C, T, F and T.M; not u.M, since u is unexported.
+	if got := exportedSymbols(f); got != 5 {
+		t.Errorf("exportedSymbols = %d, want 5", got)
+	}
+}
+
+func TestCheckExportedSymbols(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"wide.go":      "package p\n\nfunc A() {}\nfunc B() {}\nfunc C() {}\n",
+		"narrow.go":    "package p\n\nfunc D() {}\nfunc e() {}\n",
+		"wide_test.go": "package p\n\nfunc TestA() {}\nfunc TestB() {}\nfunc TestC() {}\n",
+	}
+	var paths []string
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+
+	got := checkExportedSymbols(paths, 2, os.ReadFile)
+	if len(got) != 1 || got[0].path != filepath.Join(dir, "wide.go") {
+		t.Fatalf("findings = %v, want only wide.go", got)
+	}
+	if want := "declares 3 exported symbols, over the limit of 2; split the public API across files"; got[0].message(newMessages("en")) != want {
+		t.Errorf("message = %q, want %q", got[0].message(newMessages("en")), want)
+	}
+}
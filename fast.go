@@ -0,0 +1,32 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+)
+
+// fastIncompatible are flags that need git, the network or a directory
+// walk, none of which fit the -fast latency budget.
+var fastIncompatible = []string{"ref", "sample", "pr-budget", "strict-new", "frozen-after", "otlp-endpoint", "sink", "changed-from", "staged", "watch"}
+
+// checkFast validates a -fast invocation: a single file, the ratio
+// tokenizer and nothing that leaves the process's memory besides reading
+// that file and the config.
+func checkFast(fs *flag.FlagSet, tokenizer string) error {
+	if fs.NArg() != 1 {
+		return errors.New("exactly one file is required")
+	}
+	if tokenizer != "ratio" {
+		return fmt.Errorf("only the ratio tokenizer is fast enough, not %s", tokenizer)
+	}
+	var err error
+	fs.Visit(func(f *flag.Flag) {
+		for _, name := range fastIncompatible {
+			if f.Name == name && err == nil {
+				err = fmt.Errorf("cannot be combined with -%s", name)
+			}
+		}
+	})
+	return err
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFast(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "a.go")
+	if err := os.WriteFile(file, []byte("package a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args    []string
+		code    int
+		wantErr string
+	}{
+		{[]string{"-fast", file}, 0, ""},
+		{[]string{"-fast", "-threshold", "1", file}, 1, ""},
+		{[]string{"-fast", file, file}, 1, "exactly one file"},
+		{[]string{"-fast", "-tokenizer", "bpe", file}, 1, "only the ratio tokenizer"},
+		{[]string{"-fast", "-pr-budget", "10", file}, 1, "cannot be combined with -pr-budget"},
+	}
+	for _, tt := range tests {
+		var stdout, stderr strings.Builder
+		if code := run(append([]string{"-lang", "en"}, tt.args...), &stdout, &stderr); code != tt.code {
+			t.Errorf("run(%v) = %d, want %d\n%s", tt.args, code, tt.code, stderr.String())
+		}
+		if tt.wantErr != "" && !strings.Contains(stderr.String(), tt.wantErr) {
+			t.Errorf("run(%v) stderr = %q, want %q", tt.args, stderr.String(), tt.wantErr)
+		}
+	}
+}
+
+// writeLargeFile writes a Go file of about the default threshold, the
+// size where -fast answers matter most.
+func writeLargeFile(t testing.TB) string {
+	t.Helper()
+	var b strings.Builder
+	b.WriteString("package big\n\nimport \"fmt\"\n")
+	for i := 0; b.Len() < 40000; i++ {
+		fmt.Fprintf(&b, "\n// F%d prints its argument with a greeting.\nfunc F%d(name string) {\n\tfmt.Println(\"hello\", name, %d)\n}\n", i, i, i)
+	}
+	path := filepath.Join(t.TempDir(), "big.go")
+	if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// BenchmarkFast measures -fast on a large file. Editor save hooks expect
+// an answer within about 50ms, a few times what it takes here.
+func BenchmarkFast(b *testing.B) {
+	file := writeLargeFile(b)
+	for b.Loop() {
+		run([]string{"-fast", file}, io.Discard, io.Discard)
+	}
+}
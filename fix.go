@@ -0,0 +1,376 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/token"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// runFix implements `token-lint fix`, which carries out the split that
+// `token-lint suggest` proposes.
+func runFix(args []string) int {
+	fs := flag.NewFlagSet("token-lint fix", flag.ContinueOnError)
+	ratio := fs.Float64("ratio", defaultRatio, "tokens per character ratio")
+	threshold := fs.Int("threshold", defaultThreshold, "token threshold the file should get under")
+	target := fs.Int("target", 0, "aim for new files of at most this many tokens (0 means half of -threshold)")
+	dryRun := fs.Bool("dry-run", false, "print the files that would be written instead of writing them")
+
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 1
+	}
+	if _, err := loadPolicy(fs, "", ""); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	if *ratio <= 0 {
+		fmt.Fprintln(os.Stderr, "error: ratio must be positive")
+		return 1
+	}
+	if *threshold <= 0 || *target < 0 {
+		fmt.Fprintln(os.Stderr, "error: threshold must be positive")
+		return 1
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: token-lint fix [flags] FILE")
+		return 1
+	}
+	if *target == 0 {
+		*target = *threshold / 2
+	}
+
+	name := fs.Arg(0)
+	src, err := os.ReadFile(name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	plan, err := planSplit(name, src, *ratio, *target)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	if len(plan.files) < 2 {
+		fmt.Printf("%s needs no split at a %d token target\n", quotePath(name), *target)
+		return 0
+	}
+	out, err := applySplit(plan)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+
+	if *dryRun {
+		renderPlan(os.Stdout, plan, *threshold)
+		for _, f := range out {
+			fmt.Printf("\n--- %s\n%s", quotePath(f.name), f.content)
+		}
+		return 0
+	}
+	for _, f := range out[1:] {
+		if _, err := os.Stat(f.name); err == nil {
+			fmt.Fprintf(os.Stderr, "error: %s already exists\n", f.name)
+			return 1
+		}
+	}
+	info, err := os.Stat(name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	// The original goes last, so a failure leaves it whole, with at worst
+	// some duplicate declarations in new files to delete.
+	for _, f := range append(out[1:], out[0]) {
+		if err := os.WriteFile(f.name, f.content, info.Mode().Perm()); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 1
+		}
+		fmt.Printf("wrote %s\n", quotePath(f.name))
+	}
+	return 0
+}
+
+// splitOutput is the content of one file produced by a split.
+type splitOutput struct {
+	name    string
+	content []byte
+}
+
+// applySplit renders plan: the original file without the moved
+// declarations, then one new file per other planned file. Each new file
+// gets the original's build constraints, package clause and the imports
+// its declarations use, and import "C" with its cgo preamble if they use
+// C; imports only the moved code used are dropped from the original,
+// except blank and cgo imports, which always stay. All output is
+// gofmt-formatted.
+func applySplit(p *splitPlan) ([]splitOutput, error) {
+	moved := make(map[int]bool)
+	for _, f := range p.files[1:] {
+		for _, i := range f.decls {
+			moved[i] = true
+		}
+	}
+
+	var kept []int
+	for i, d := range p.file.Decls {
+		if gd, ok := d.(*ast.GenDecl); ok && gd.Tok == token.IMPORT {
+			continue
+		}
+		if !moved[i] {
+			kept = append(kept, i)
+		}
+	}
+
+	var outputs []splitOutput
+	original, err := p.rewriteOriginal(moved, p.packagesUsed(kept))
+	if err != nil {
+		return nil, err
+	}
+	outputs = append(outputs, splitOutput{p.path, original})
+
+	for _, f := range p.files[1:] {
+		var b bytes.Buffer
+		b.Write(p.buildConstraints())
+		fmt.Fprintf(&b, "package %s\n\n", p.file.Name.Name)
+		used := p.packagesUsed(f.decls)
+		b.Write(p.cgoImport(used))
+		imports := p.importsFor(used)
+		if len(imports) > 0 {
+			b.WriteString("import (\n")
+			for _, spec := range imports {
+				fmt.Fprintf(&b, "\t%s\n", spec)
+			}
+			b.WriteString(")\n\n")
+		}
+		for _, i := range f.decls {
+			b.Write(p.src[p.decls[i].start:p.decls[i].end])
+			b.WriteString("\n\n")
+		}
+		content, err := format.Source(b.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", f.name, err)
+		}
+		outputs = append(outputs, splitOutput{f.name, content})
+	}
+	return outputs, nil
+}
+
+// buildConstraints returns the //go:build and // +build lines before the
+// package clause, followed by a blank line, or nothing.
+func (p *splitPlan) buildConstraints() []byte {
+	var b bytes.Buffer
+	for _, cg := range p.file.Comments {
+		if cg.Pos() >= p.file.Package {
+			break
+		}
+		for _, c := range cg.List {
+			if strings.HasPrefix(c.Text, "//go:build") || strings.HasPrefix(c.Text, "// +build") {
+				b.WriteString(c.Text + "\n")
+			}
+		}
+	}
+	if b.Len() > 0 {
+		b.WriteString("\n")
+	}
+	return b.Bytes()
+}
+
+// packagesUsed returns the names used as package qualifiers, such as fmt
+// in fmt.Println, by the given declarations, and whether they contain a
+// //go:embed directive.
+func (p *splitPlan) packagesUsed(decls []int) map[string]bool {
+	used := make(map[string]bool)
+	for _, i := range decls {
+		ast.Inspect(p.file.Decls[i], func(n ast.Node) bool {
+			if sel, ok := n.(*ast.SelectorExpr); ok {
+				if id, ok := sel.X.(*ast.Ident); ok && id.Obj == nil {
+					used[id.Name] = true
+				}
+			}
+			return true
+		})
+		if bytes.Contains(p.src[p.decls[i].start:p.decls[i].end], []byte("//go:embed")) {
+			used["_embed"] = true
+		}
+	}
+	return used
+}
+
+// importsFor returns the import specs, as source text, needed by code
+// using the package qualifiers in used. Dot imports are always kept, since
+// their uses cannot be told apart; import "C" is left to cgoImport.
+func (p *splitPlan) importsFor(used map[string]bool) []string {
+	var specs []string
+	for _, imp := range p.file.Imports {
+		if isCgoImport(imp) || !importNeeded(imp, used) {
+			continue
+		}
+		end := imp.End()
+		if imp.Comment != nil {
+			end = imp.Comment.End()
+		}
+		specs = append(specs, string(p.src[p.offset(imp.Pos()):p.offset(end)]))
+	}
+	return specs
+}
+
+// importNeeded reports whether imp is needed by code using the package
+// qualifiers in used.
+func importNeeded(imp *ast.ImportSpec, used map[string]bool) bool {
+	importPath, _ := strconv.Unquote(imp.Path.Value)
+	if imp.Name != nil {
+		switch imp.Name.Name {
+		case ".":
+			return true
+		case "_":
+			return importPath == "embed" && used["_embed"]
+		}
+		return used[imp.Name.Name]
+	}
+	return used[importName(importPath)]
+}
+
+// alwaysKept reports whether imp stays in the original file whether or not
+// the kept code uses it: blank imports are there for their side effects
+// and import "C" carries the cgo preamble.
+func alwaysKept(imp *ast.ImportSpec) bool {
+	return (imp.Name != nil && imp.Name.Name == "_") || isCgoImport(imp)
+}
+
+func isCgoImport(imp *ast.ImportSpec) bool {
+	return imp.Path.Value == `"C"`
+}
+
+// cgoImport returns the import "C" declaration of the original file with
+// its preamble, for a new file whose code uses C, or nothing.
+func (p *splitPlan) cgoImport(used map[string]bool) []byte {
+	if !used["C"] {
+		return nil
+	}
+	for _, d := range p.file.Decls {
+		gd, ok := d.(*ast.GenDecl)
+		if !ok || gd.Tok != token.IMPORT {
+			continue
+		}
+		for _, spec := range gd.Specs {
+			imp := spec.(*ast.ImportSpec)
+			if !isCgoImport(imp) {
+				continue
+			}
+			doc := imp.Doc
+			if doc == nil && !gd.Lparen.IsValid() {
+				doc = gd.Doc
+			}
+			var b bytes.Buffer
+			if doc != nil {
+				b.Write(p.src[p.offset(doc.Pos()):p.offset(doc.End())])
+				b.WriteString("\n")
+			}
+			b.WriteString("import \"C\"\n\n")
+			return b.Bytes()
+		}
+	}
+	return nil
+}
+
+var majorVersion = regexp.MustCompile(`^v[0-9]+$`)
+
+// importName guesses the package name of an import path by convention:
+// gopkg.in/yaml.v3 is yaml, github.com/mattn/go-isatty is isatty and
+// example.com/mod/v2 is mod.
+func importName(importPath string) string {
+	elem := path.Base(importPath)
+	if majorVersion.MatchString(elem) && path.Dir(importPath) != "." {
+		elem = path.Base(path.Dir(importPath))
+	}
+	elem, _, _ = strings.Cut(elem, ".")
+	elem = strings.TrimPrefix(elem, "go-")
+	elem = strings.TrimSuffix(elem, "-go")
+	return strings.ReplaceAll(elem, "-", "_")
+}
+
+func (p *splitPlan) offset(pos token.Pos) int {
+	return p.fset.Position(pos).Offset
+}
+
+// lineStart widens offset back to the start of its line if only
+// indentation precedes it.
+func (p *splitPlan) lineStart(offset int) int {
+	start := bytes.LastIndexByte(p.src[:offset], '\n') + 1
+	if len(bytes.TrimSpace(p.src[start:offset])) > 0 {
+		return offset
+	}
+	return start
+}
+
+// lineEnd widens offset past the end of its line, including a trailing
+// comment, if nothing else follows on the line.
+func (p *splitPlan) lineEnd(offset int) int {
+	end := bytes.IndexByte(p.src[offset:], '\n')
+	if end < 0 {
+		return offset
+	}
+	rest := bytes.TrimSpace(p.src[offset : offset+end])
+	if len(rest) > 0 && !bytes.HasPrefix(rest, []byte("//")) {
+		return offset
+	}
+	return offset + end + 1
+}
+
+// rewriteOriginal returns the original source without the moved
+// declarations and without imports that only they used.
+func (p *splitPlan) rewriteOriginal(moved map[int]bool, keptUses map[string]bool) ([]byte, error) {
+	type span struct{ start, end int }
+	var cut []span
+	for i := range moved {
+		cut = append(cut, span{p.decls[i].start, p.decls[i].end})
+	}
+	for _, d := range p.file.Decls {
+		gd, ok := d.(*ast.GenDecl)
+		if !ok || gd.Tok != token.IMPORT {
+			continue
+		}
+		var unused []*ast.ImportSpec
+		for _, spec := range gd.Specs {
+			if imp := spec.(*ast.ImportSpec); !alwaysKept(imp) && !importNeeded(imp, keptUses) {
+				unused = append(unused, imp)
+			}
+		}
+		if len(unused) == len(gd.Specs) {
+			cut = append(cut, span{p.offset(gd.Pos()), p.offset(gd.End())})
+			continue
+		}
+		for _, imp := range unused {
+			cut = append(cut, span{p.lineStart(p.offset(imp.Pos())), p.lineEnd(p.offset(imp.End()))})
+		}
+	}
+	sort.Slice(cut, func(i, j int) bool { return cut[i].start < cut[j].start })
+
+	var b bytes.Buffer
+	prev := 0
+	for _, s := range cut {
+		if s.start < prev {
+			return nil, errors.New("overlapping declarations")
+		}
+		b.Write(p.src[prev:s.start])
+		prev = s.end
+	}
+	b.Write(p.src[prev:])
+	content, err := format.Source(b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", p.path, err)
+	}
+	return content, nil
+}
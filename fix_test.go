@@ -0,0 +1,153 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+func TestApplySplit(t *testing.T) {
+	body := strings.Repeat("\t_ = 0\n", 40)
+	src := `//go:build linux
+
+package shop
+
+import (
+	"fmt"
+	"strings" // for Render
+)
+
+type Cart struct{ items []string }
+
+func (c *Cart) Add(s string) {
+` + body + `	fmt.Println(s)
+}
+
+func (c *Cart) Total() int {
+` + body + `	return len(c.items)
+}
+
+func (c *Cart) Clear() { c.items = nil }
+
+type Invoice struct{ id int }
+
+func (i Invoice) String() string {
+` + body + `	return fmt.Sprint(i.id)
+}
+
+func (i Invoice) Render() string {
+` + body + `	return strings.ToUpper(i.String())
+}
+`
+	plan, err := planSplit("shop/cart.go", []byte(src), 1, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := applySplit(plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 2 {
+		t.Fatalf("wrote %d files, want 2", len(out))
+	}
+	for _, f := range out {
+		if _, err := parser.ParseFile(token.NewFileSet(), f.name, f.content, 0); err != nil {
+			t.Errorf("%s does not parse: %v", f.name, err)
+		}
+		if !strings.HasPrefix(string(f.content), "//go:build linux\n\npackage shop\n") {
+			t.Errorf("%s lost its build constraint or package clause:\n%s", f.name, f.content)
+		}
+	}
+
+	original, moved := string(out[0].content), string(out[1].content)
+	if out[1].name != "shop/cart_invoice.go" {
+		t.Errorf("new file = %q, want shop/cart_invoice.go", out[1].name)
+	}
+	if strings.Contains(original, "Invoice") || !strings.Contains(original, "func (c *Cart) Total") {
+		t.Errorf("original holds the wrong declarations:\n%s", original)
+	}
+	if strings.Contains(original, `"strings"`) || !strings.Contains(original, `"fmt"`) {
+		t.Errorf("original imports are wrong:\n%s", original)
+	}
+	if !strings.Contains(moved, `"fmt"`) || !strings.Contains(moved, `"strings" // for Render`) {
+		t.Errorf("new file imports are wrong:\n%s", moved)
+	}
+}
+
+func TestApplySplitBlankAndCgoImports(t *testing.T) {
+	body := strings.Repeat("\t_ = 0\n", 40)
+	src := `package native
+
+// #include <stdlib.h>
+import "C"
+
+import (
+	"fmt"
+	_ "net/http/pprof"
+)
+
+type Buffer struct{ n int }
+
+func (b *Buffer) Grow() {
+` + body + body + `	b.n++
+}
+
+func (b *Buffer) Len() int {
+` + body + `	return b.n
+}
+
+type Alloc struct{ size int }
+
+func (a Alloc) Free() {
+` + body + `	C.free(nil)
+}
+
+func (a Alloc) String() string {
+` + body + `	return fmt.Sprint(a.size)
+}
+`
+	plan, err := planSplit("native/buffer.go", []byte(src), 1, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := applySplit(plan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 2 {
+		t.Fatalf("wrote %d files, want 2", len(out))
+	}
+
+	original, moved := string(out[0].content), string(out[1].content)
+	if strings.Contains(original, "Alloc") {
+		t.Fatalf("original holds the wrong declarations:\n%s", original)
+	}
+	if !strings.Contains(original, `_ "net/http/pprof"`) || !strings.Contains(original, "// #include <stdlib.h>\nimport \"C\"") {
+		t.Errorf("original lost its blank or cgo import:\n%s", original)
+	}
+	if !strings.Contains(moved, "// #include <stdlib.h>\nimport \"C\"") {
+		t.Errorf("new file lacks import \"C\" with its preamble:\n%s", moved)
+	}
+	if strings.Contains(moved, "pprof") {
+		t.Errorf("new file repeats the blank import:\n%s", moved)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), out[1].name, out[1].content, 0); err != nil {
+		t.Errorf("%s does not parse: %v", out[1].name, err)
+	}
+}
+
+func TestImportName(t *testing.T) {
+	tests := map[string]string{
+		"fmt":                        "fmt",
+		"net/http":                   "http",
+		"gopkg.in/yaml.v3":           "yaml",
+		"github.com/mattn/go-isatty": "isatty",
+		"example.com/mod/v2":         "mod",
+	}
+	for in, want := range tests {
+		if got := importName(in); got != want {
+			t.Errorf("importName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// fleetRepo is the scan outcome for one repository in fleet mode.
+type fleetRepo struct {
+	name       string
+	files      int
+	violations []fileResult
+	max        int
+	err        error
+}
+
+// runFleet implements `token-lint fleet`, which clones or updates a list
+// of repositories and reports on all of them together.
+func runFleet(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("token-lint fleet", flag.ContinueOnError)
+	reposFile := fs.String("repos", "", "file listing repository URLs, one per line")
+	dir := fs.String("dir", "", "directory to clone repositories into (default: user cache dir)")
+	threshold := fs.Int("threshold", defaultThreshold, "maximum tokens before warning")
+	ratio := fs.Float64("ratio", defaultRatio, "tokens per character ratio")
+	top := fs.Int("top", 10, "number of worst files to list")
+
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 1
+	}
+
+	if *reposFile == "" {
+		fmt.Fprintln(os.Stderr, "error: -repos is required")
+		return 1
+	}
+	if *ratio <= 0 {
+		fmt.Fprintln(os.Stderr, "error: ratio must be positive")
+		return 1
+	}
+	if *threshold <= 0 {
+		fmt.Fprintln(os.Stderr, "error: threshold must be positive")
+		return 1
+	}
+
+	urls, err := readRepoList(*reposFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+
+	if *dir == "" {
+		cache, err := os.UserCacheDir()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 1
+		}
+		*dir = filepath.Join(cache, "token-lint", "fleet")
+	}
+	if err := os.MkdirAll(*dir, 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+
+	settings := fleetSettings{threshold: *threshold, ratio: *ratio, explicit: make(map[string]string)}
+	fs.Visit(func(f *flag.Flag) { settings.explicit[f.Name] = f.Value.String() })
+	var repos []fleetRepo
+	for _, url := range urls {
+		if ctx.Err() != nil {
+			break
+		}
+		repos = append(repos, scanFleetRepo(ctx, url, filepath.Join(*dir, repoDirName(url)), settings))
+	}
+
+	failed := printFleetReport(repos, *top)
+	if ctx.Err() != nil {
+		fmt.Fprintf(os.Stderr, "%s after %d of %d repositories\n", canceledReason(ctx, newMessages("en")), len(repos), len(urls))
+		return exitCanceled
+	}
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+// readRepoList reads repository URLs, ignoring blank lines and # comments.
+func readRepoList(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var urls []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		urls = append(urls, line)
+	}
+	return urls, sc.Err()
+}
+
+// repoName returns the host and path of a repository URL, e.g.
+// "github.com/org/repo" for "https://github.com/org/repo.git" or
+// "git@github.com:org/repo.git".
+func repoName(url string) string {
+	name := strings.TrimSuffix(strings.TrimRight(url, "/"), ".git")
+	if _, rest, ok := strings.Cut(name, "://"); ok {
+		name = rest
+	} else if host, path, ok := strings.Cut(name, ":"); ok && !strings.Contains(host, "/") {
+		name = host + "/" + path // scp-like syntax
+	}
+	if user, rest, ok := strings.Cut(name, "@"); ok && !strings.Contains(user, "/") {
+		name = rest
+	}
+	return strings.Trim(filepath.ToSlash(name), "/")
+}
+
+// repoDirName derives a stable checkout directory name from a repository
+// URL: its name, with a hash of the whole URL so that no two URLs share a
+// checkout, e.g. "github.com_org_repo-1a2b3c4d".
+func repoDirName(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return strings.ReplaceAll(repoName(url), "/", "_") + "-" + hex.EncodeToString(sum[:4])
+}
+
+// fleetSettings are the settings a fleet scan starts from. Each
+// repository's own config file fills in those not given explicitly.
+type fleetSettings struct {
+	threshold int
+	ratio     float64
+	explicit  map[string]string // flags given on the command line
+}
+
+// load returns the policy the clone at dest commits and the options for
+// analyzing its files.
+func (s fleetSettings) load(dest string) (*policy, analyzeOptions, error) {
+	fs := flag.NewFlagSet("fleet", flag.ContinueOnError)
+	threshold := fs.Int("threshold", s.threshold, "")
+	ratio := fs.Float64("ratio", s.ratio, "")
+	for name, value := range s.explicit {
+		if fs.Lookup(name) != nil {
+			if err := fs.Set(name, value); err != nil {
+				return nil, analyzeOptions{}, err
+			}
+		}
+	}
+	// Only the clone's own files count, not any further up.
+	var files [2]string
+	for i, name := range []string{configFileName, ignoreFileName} {
+		if _, err := os.Stat(filepath.Join(dest, name)); err == nil {
+			files[i] = filepath.Join(dest, name)
+		}
+	}
+	pol, err := readPolicy(fs, files[0], files[1])
+	if err != nil {
+		return nil, analyzeOptions{}, err
+	}
+	opts, err := pol.options(*threshold, *ratio)
+	return pol, opts, err
+}
+
+// scanFleetRepo clones url into dest (or fast-forwards an existing clone)
+// and analyzes its Go files under the policy the repository commits.
+func scanFleetRepo(ctx context.Context, url, dest string, settings fleetSettings) fleetRepo {
+	repo := fleetRepo{name: repoName(url)}
+
+	if _, err := os.Stat(filepath.Join(dest, ".git")); err == nil {
+		_, err = gitOutput("-C", dest, "pull", "--ff-only", "--quiet")
+		repo.err = err
+	} else {
+		// After --, a URL starting with - cannot pass for an option.
+		_, err = gitOutput("clone", "--depth", "1", "--quiet", "--", url, dest)
+		repo.err = err
+	}
+	if repo.err != nil {
+		return repo
+	}
+
+	pol, opts, err := settings.load(dest)
+	if err != nil {
+		repo.err = err
+		return repo
+	}
+	files, err := pol.expand(ctx, []string{dest + "/..."})
+	if err != nil {
+		repo.err = err
+		return repo
+	}
+
+	results, violations := analyzeFiles(ctx, files, opts)
+	repo.files = len(results)
+	for _, r := range results {
+		repo.max = max(repo.max, r.tokens)
+	}
+	for _, v := range violations {
+		if rel, err := filepath.Rel(dest, v.path); err == nil {
+			v.path = rel
+		}
+		repo.violations = append(repo.violations, v)
+	}
+	return repo
+}
+
+// printFleetReport prints the per-repository table and the worst files
+// across all repositories. It reports whether any repository failed to
+// scan or has violations.
+func printFleetReport(repos []fleetRepo, top int) bool {
+	failed := false
+	type worst struct {
+		repo string
+		fileResult
+	}
+	var all []worst
+
+	fmt.Printf("%-40s %8s %10s %10s\n", "REPO", "FILES", "VIOLATIONS", "MAX TOKENS")
+	fmt.Println(strings.Repeat("-", 71))
+	for _, r := range repos {
+		if r.err != nil {
+			failed = true
+			fmt.Printf("%-40s error: %v\n", r.name, r.err)
+			continue
+		}
+		fmt.Printf("%-40s %8d %10d %10d\n", r.name, r.files, len(r.violations), r.max)
+		for _, v := range r.violations {
+			all = append(all, worst{r.name, v})
+		}
+	}
+
+	if len(all) == 0 {
+		fmt.Printf("\nNo files exceed their token threshold\n")
+		return failed
+	}
+	failed = true
+
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].tokens > all[j].tokens
+	})
+	if len(all) > top {
+		all = all[:top]
+	}
+
+	fmt.Printf("\nWorst files:\n\n")
+	for _, w := range all {
+		fmt.Printf("  %s: %s  ~%d tokens (threshold %d)\n", w.repo, quotePath(w.path), w.tokens, w.threshold)
+	}
+	return failed
+}
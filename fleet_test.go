@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRepoDirName(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://github.com/org/repo.git", "github.com/org/repo"},
+		{"https://github.com/org/repo/", "github.com/org/repo"},
+		{"git@github.com:org/repo.git", "github.com/org/repo"},
+		{"ssh://git@gitlab.com/org/repo.git", "gitlab.com/org/repo"},
+		{"/srv/git/repo", "srv/git/repo"},
+	}
+
+	dirs := make(map[string]string)
+	for _, tt := range tests {
+		if got := repoName(tt.url); got != tt.want {
+			t.Errorf("repoName(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+		dir := repoDirName(tt.url)
+		if !strings.HasPrefix(dir, strings.ReplaceAll(tt.want, "/", "_")+"-") {
+			t.Errorf("repoDirName(%q) = %q, want it to start with the name", tt.url, dir)
+		}
+		if other, ok := dirs[dir]; ok {
+			t.Errorf("%q and %q share the checkout %s", other, tt.url, dir)
+		}
+		dirs[dir] = tt.url
+	}
+	if repoDirName("https://github.com/org/repo.git") != repoDirName("https://github.com/org/repo.git") {
+		t.Error("repoDirName is not stable")
+	}
+}
+
+func TestScanFleetRepo(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	src := filepath.Join(t.TempDir(), "src", "repo")
+	if err := os.MkdirAll(src, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"big.go":       strings.Repeat("a", 200),
+		"legacy.go":    strings.Repeat("a", 200),
+		configFileName: "threshold: 150\nexclude: [legacy.go]\n",
+	} {
+		if err := os.WriteFile(filepath.Join(src, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", "init"},
+	} {
+		if _, err := gitOutput(append([]string{"-C", src}, args...)...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dest := filepath.Join(t.TempDir(), "clone")
+	settings := fleetSettings{threshold: 100, ratio: 1}
+
+	for _, step := range []string{"clone", "update"} {
+		repo := scanFleetRepo(context.Background(), src, dest, settings)
+		if repo.err != nil {
+			t.Fatalf("%s: %v", step, repo.err)
+		}
+		if repo.files != 1 || len(repo.violations) != 1 || repo.violations[0].path != "big.go" {
+			t.Errorf("%s: got %d files, violations %v; want 1 file and big.go violating", step, repo.files, repo.violations)
+		} else if got := repo.violations[0].threshold; got != 150 {
+			t.Errorf("%s: threshold = %d, want 150 from the repository's config", step, got)
+		}
+	}
+}
+
+func TestScanFleetRepoOptionURL(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	// Read as an option, the URL would make git clone dest, a bare
+	// repository, with a command of our choosing.
+	marker := filepath.Join(t.TempDir(), "ran")
+	url := "--upload-pack=touch " + marker + ";git-upload-pack"
+	dest := filepath.Join(t.TempDir(), "bare.git")
+	if _, err := gitOutput("init", "--quiet", "--bare", dest); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(t.TempDir())
+	repo := scanFleetRepo(context.Background(), url, dest, fleetSettings{threshold: 100, ratio: 1})
+	if repo.err == nil {
+		t.Error("cloning an option-like URL succeeded")
+	}
+	if _, err := os.Stat(marker); err == nil {
+		t.Error("the URL was run as a git option")
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// jsonFile is the machine-readable form of a fileResult.
+type jsonFile struct {
+	Path      string `json:"path"`
+	Chars     int    `json:"chars"`
+	Tokens    int    `json:"tokens"`
+	Threshold int    `json:"threshold"`
+	// Violation marks files over their threshold that fail the check;
+	// suppressed, baselined and frozen ones are tolerated instead, unless
+	// -fail-frozen lets frozen ones fail.
+	Violation bool   `json:"violation"`
+	SHA256    string `json:"sha256,omitempty"`
+	// Suppressed is the reason given by a //tokenlint:ignore directive.
+	Suppressed string `json:"suppressed,omitempty"`
+	// Baselined and Frozen mark violations tolerated by -baseline and
+	// -frozen-after.
+	Baselined bool `json:"baselined,omitempty"`
+	Frozen    bool `json:"frozen,omitempty"`
+	// Classes splits the tokens of violations by content class.
+	Classes *tokenClasses `json:"classes,omitempty"`
+}
+
+func toJSONFile(r fileResult, failFrozen bool) jsonFile {
+	return jsonFile{
+		Path:       r.path,
+		Chars:      r.chars,
+		Tokens:     r.tokens,
+		Threshold:  r.threshold,
+		Violation:  r.tokens > r.threshold && r.suppressed == "" && !r.tolerated(failFrozen),
+		SHA256:     r.sha256,
+		Suppressed: r.suppressed,
+		Baselined:  r.baselined,
+		Frozen:     r.frozen,
+		Classes:    r.classes,
+	}
+}
+
+// jsonSchemaVersion is bumped whenever -format json changes incompatibly.
+const jsonSchemaVersion = 1
+
+// jsonReport is the -format json document.
+type jsonReport struct {
+	Version  int           `json:"version"`
+	Files    []jsonFile    `json:"files"`
+	Findings []jsonFinding `json:"findings,omitempty"`
+	Summary  jsonSummary   `json:"summary"`
+}
+
+type jsonSummary struct {
+	Files      int `json:"files"`
+	Violations int `json:"violations"`
+	Tolerated  int `json:"tolerated,omitempty"` // baselined and frozen violations
+	Findings   int `json:"findings,omitempty"`
+	Tokens     int `json:"tokens"`
+	Threshold  int `json:"threshold"`
+}
+
+// jsonFinding is the machine-readable form of a finding. Messages are
+// always in English.
+type jsonFinding struct {
+	Rule    string `json:"rule"`
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func toJSONFinding(f finding) jsonFinding {
+	return jsonFinding{Rule: f.rule, Path: f.path, Message: f.message(newMessages("en"))}
+}
+
+// writeJSONReport writes results as a single -format json document.
+// failFrozen counts frozen violations as failing, as -fail-frozen does.
+func writeJSONReport(w io.Writer, results []fileResult, findings []finding, threshold int, failFrozen bool) error {
+	report := jsonReport{
+		Version: jsonSchemaVersion,
+		Files:   make([]jsonFile, 0, len(results)),
+		Summary: jsonSummary{Files: len(results), Findings: len(findings), Threshold: threshold},
+	}
+	for _, r := range results {
+		f := toJSONFile(r, failFrozen)
+		report.Files = append(report.Files, f)
+		report.Summary.Tokens += r.tokens
+		switch {
+		case f.Violation:
+			report.Summary.Violations++
+		case f.Baselined || f.Frozen:
+			report.Summary.Tolerated++
+		}
+	}
+	for _, f := range findings {
+		report.Findings = append(report.Findings, toJSONFinding(f))
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+// jsonlRecord is one line of -format jsonl output: a "file" record per
+// analyzed file, as it completes, then a final "summary" record.
+type jsonlRecord struct {
+	Type string `json:"type"`
+	*jsonFile
+	Files      *int `json:"files,omitempty"`
+	Violations *int `json:"violations,omitempty"`
+	Tolerated  int  `json:"tolerated,omitempty"`
+}
+
+// jsonlWriter streams -format jsonl records.
+type jsonlWriter struct {
+	enc        *json.Encoder
+	failFrozen bool
+}
+
+func newJSONLWriter(w io.Writer, failFrozen bool) *jsonlWriter {
+	return &jsonlWriter{enc: json.NewEncoder(w), failFrozen: failFrozen}
+}
+
+func (w *jsonlWriter) file(r fileResult) {
+	f := toJSONFile(r, w.failFrozen)
+	w.enc.Encode(jsonlRecord{Type: "file", jsonFile: &f})
+}
+
+func (w *jsonlWriter) finding(f finding) {
+	w.enc.Encode(struct {
+		Type string `json:"type"`
+		jsonFinding
+	}{"finding", toJSONFinding(f)})
+}
+
+// summary writes the summary record; violations excludes the tolerated
+// ones.
+func (w *jsonlWriter) summary(files, violations, tolerated int) {
+	w.enc.Encode(jsonlRecord{Type: "summary", Files: &files, Violations: &violations, Tolerated: tolerated})
+}
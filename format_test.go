@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestJSONLWriter(t *testing.T) {
+	var out strings.Builder
+	w := newJSONLWriter(&out, false)
+	w.file(fileResult{path: "a.go", chars: 100, tokens: 65, threshold: 50, sha256: "ab12"})
+	w.file(fileResult{path: "b.go", chars: 10, tokens: 6, threshold: 50})
+	w.file(fileResult{path: "c.go", chars: 100, tokens: 65, threshold: 50, frozen: true})
+	w.finding(finding{rule: "package-doc", path: "pkg", key: "pkgDocMissing", args: []any{"pkg"}})
+	w.summary(3, 1, 1)
+
+	want := `{"type":"file","path":"a.go","chars":100,"tokens":65,"threshold":50,"violation":true,"sha256":"ab12"}
+{"type":"file","path":"b.go","chars":10,"tokens":6,"threshold":50,"violation":false}
+{"type":"file","path":"c.go","chars":100,"tokens":65,"threshold":50,"violation":false,"frozen":true}
+{"type":"finding","rule":"package-doc","path":"pkg","message":"package pkg has no package comment; add one in doc.go"}
+{"type":"summary","files":3,"violations":1,"tolerated":1}
+`
+	if out.String() != want {
+		t.Errorf("jsonl output:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
+
+func TestWriteJSONReport(t *testing.T) {
+	var out strings.Builder
+	results := []fileResult{
+		{path: "a.go", chars: 100, tokens: 65, threshold: 50},
+		{path: "b.go", chars: 10, tokens: 6, threshold: 50},
+		{path: "c.go", chars: 100, tokens: 65, threshold: 50, baselined: true},
+	}
+	if err := writeJSONReport(&out, results, nil, 50, false); err != nil {
+		t.Fatal(err)
+	}
+
+	var got jsonReport
+	if err := json.Unmarshal([]byte(out.String()), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Version != jsonSchemaVersion || len(got.Files) != 3 || !got.Files[0].Violation {
+		t.Errorf("report = %+v", got)
+	}
+	if c := got.Files[2]; c.Violation || !c.Baselined {
+		t.Errorf("baselined file = %+v, want a tolerated violation", c)
+	}
+	if want := (jsonSummary{Files: 3, Violations: 1, Tolerated: 1, Tokens: 136, Threshold: 50}); got.Summary != want {
+		t.Errorf("summary = %+v, want %+v", got.Summary, want)
+	}
+}
+
+// TestFailFrozenReports checks that every report fails a frozen file
+// when -fail-frozen makes it fail the run.
+func TestFailFrozenReports(t *testing.T) {
+	frozen := []fileResult{{path: "c.go", chars: 100, tokens: 65, threshold: 50, frozen: true}}
+
+	var js strings.Builder
+	if err := writeJSONReport(&js, frozen, nil, 50, true); err != nil {
+		t.Fatal(err)
+	}
+	var report jsonReport
+	if err := json.Unmarshal([]byte(js.String()), &report); err != nil {
+		t.Fatal(err)
+	}
+	if !report.Files[0].Violation || !report.Files[0].Frozen || report.Summary.Violations != 1 || report.Summary.Tolerated != 0 {
+		t.Errorf("json report = %+v, want a frozen violation", report)
+	}
+
+	var jl strings.Builder
+	newJSONLWriter(&jl, true).file(frozen[0])
+	if !strings.Contains(jl.String(), `"violation":true`) {
+		t.Errorf("jsonl = %s, want a violation", jl.String())
+	}
+
+	var sarif strings.Builder
+	if err := writeSARIF(&sarif, frozen, nil, true); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(sarif.String(), `"level": "error"`) {
+		t.Errorf("sarif = %s, want level error", sarif.String())
+	}
+
+	var gh strings.Builder
+	writeGitHubAnnotations(&gh, frozen, nil, true)
+	if !strings.HasPrefix(gh.String(), "::error ") {
+		t.Errorf("github = %q, want an error", gh.String())
+	}
+
+	var ju strings.Builder
+	if err := writeJUnit(&ju, frozen, frozen, nil, true); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(ju.String(), "<failure ") {
+		t.Errorf("junit = %s, want a failure", ju.String())
+	}
+}
+
+func TestOutputChannels(t *testing.T) {
+	dir := t.TempDir()
+	small := filepath.Join(dir, "small.go")
+	if err := os.WriteFile(small, []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	huge := filepath.Join(dir, "huge.go")
+	if err := os.WriteFile(huge, make([]byte, 4096), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		format     string
+		wantStdout string
+	}{
+		{"text", "1 file(s) exceed 1 token threshold"},
+		{"json", `"summary": {`},
+		{"jsonl", `"type":"summary"`},
+		{"sarif", `"ruleId": "token-limit"`},
+		{"github", "::error file="},
+		{"junit", `<failure message="~8 tokens`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var stdout, stderr strings.Builder
+			args := []string{"-lang", "en", "-format", tt.format, "-threshold", "1", "-max-file-bytes", "1024", dir}
+			if code := run(args, &stdout, &stderr); code != 1 {
+				t.Errorf("exit code = %d, want 1", code)
+			}
+
+			if !strings.Contains(stderr.String(), "warning: skipping") {
+				t.Errorf("stderr missing skip warning:\n%s", stderr.String())
+			}
+			if strings.Contains(stdout.String(), "warning") {
+				t.Errorf("stdout contains diagnostics:\n%s", stdout.String())
+			}
+			if !strings.Contains(stdout.String(), tt.wantStdout) {
+				t.Errorf("stdout missing %q:\n%s", tt.wantStdout, stdout.String())
+			}
+
+			lines := strings.Split(strings.TrimSpace(stderr.String()), "\n")
+			if want := "token-lint: files=1 violations=1 max=8 threshold=1"; lines[len(lines)-1] != want {
+				t.Errorf("last stderr line = %q, want %q", lines[len(lines)-1], want)
+			}
+
+			if tt.format == "json" && !json.Valid([]byte(stdout.String())) {
+				t.Errorf("stdout is not JSON:\n%s", stdout.String())
+			}
+			if tt.format == "jsonl" {
+				for _, line := range strings.Split(strings.TrimSpace(stdout.String()), "\n") {
+					if !json.Valid([]byte(line)) {
+						t.Errorf("stdout line is not JSON: %q", line)
+					}
+				}
+			}
+		})
+	}
+}
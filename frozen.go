@@ -0,0 +1,24 @@
+package main
+
+import "time"
+
+// markFrozenFile flags a violation whose file has not been committed to
+// since cutoff. Untracked files are never frozen.
+func markFrozenFile(v *fileResult, cutoff time.Time) {
+	if t, ok := lastCommitTime(v.path); ok {
+		v.lastChange = t
+		v.frozen = t.Before(cutoff)
+	}
+}
+
+// countFailing returns how many violations should fail the build. Frozen
+// violations only count when failFrozen is set; baselined ones never do.
+func countFailing(violations []fileResult, failFrozen bool) int {
+	n := 0
+	for _, v := range violations {
+		if !v.tolerated(failFrozen) {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMarkFrozenFile(t *testing.T) {
+	dir := newTestRepo(t)
+	testCommit(t, dir, "2020-01-01T00:00:00Z", map[string]string{"old.go": "package a\n"})
+	testCommit(t, dir, time.Now().Format(time.RFC3339), map[string]string{"recent.go": "package a\n"})
+	untracked := filepath.Join(dir, "untracked.go")
+	if err := os.WriteFile(untracked, []byte("package a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	violations := []fileResult{
+		{path: filepath.Join(dir, "old.go")},
+		{path: filepath.Join(dir, "recent.go")},
+		{path: untracked},
+	}
+	cutoff := time.Now().AddDate(0, -6, 0)
+	for i := range violations {
+		markFrozenFile(&violations[i], cutoff)
+	}
+
+	if !violations[0].frozen {
+		t.Error("old file not marked frozen")
+	}
+	if violations[1].frozen || violations[2].frozen {
+		t.Error("recent or untracked file marked frozen")
+	}
+
+	if n := countFailing(violations, false); n != 2 {
+		t.Errorf("countFailing without -fail-frozen = %d, want 2", n)
+	}
+	if n := countFailing(violations, true); n != 3 {
+		t.Errorf("countFailing with -fail-frozen = %d, want 3", n)
+	}
+}
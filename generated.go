@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/befabri/token-lint/pkg/tokenlint"
+)
+
+// newGeneratedRules combines the config's generated patterns with
+// -generated flags, which are relative to the working directory. It
+// returns nil when neither changes the built-in heuristics.
+func newGeneratedRules(c *config, flags []string, noBuiltin bool) (*tokenlint.GeneratedRules, error) {
+	g := &tokenlint.GeneratedRules{NoBuiltin: noBuiltin}
+	patterns := len(flags)
+	if c != nil {
+		patterns += len(c.Generated)
+		if c.GeneratedBuiltin != nil && !*c.GeneratedBuiltin {
+			g.NoBuiltin = true
+		}
+		if err := g.AddPatterns(c.dir, c.Generated); err != nil {
+			return nil, fmt.Errorf("generated: %v", err)
+		}
+	}
+	if err := g.AddPatterns(".", flags); err != nil {
+		return nil, fmt.Errorf("-generated: %v", err)
+	}
+	if !g.NoBuiltin && patterns == 0 {
+		return nil, nil
+	}
+	return g, nil
+}
+
+// patternList is a repeatable string flag.
+type patternList []string
+
+func (p *patternList) Set(s string) error {
+	*p = append(*p, s)
+	return nil
+}
+
+func (p *patternList) String() string { return strings.Join(*p, ",") }
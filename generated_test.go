@@ -0,0 +1,54 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/befabri/token-lint/pkg/tokenlint"
+)
+
+func TestGeneratedRules(t *testing.T) {
+	var nilRules *tokenlint.GeneratedRules
+	if !nilRules.Match("api.pb.go") || nilRules.Match("main.go") {
+		t.Error("nil rules do not apply the built-in heuristics")
+	}
+
+	dir := t.TempDir()
+	f := false
+	c := &config{dir: dir, Generated: []string{"zz_generated*.go"}}
+	g, err := newGeneratedRules(c, []string{"*_templ.go", "**/mocks/**"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"main.go", false},
+		{"api.pb.go", true}, // built-in
+		{"view_templ.go", true},
+		{"internal/store/mocks/store.go", true},
+		{filepath.Join(dir, "pkg", "zz_generated.deepcopy.go"), true},
+		{filepath.Join(filepath.Dir(dir), "zz_generated.go"), false}, // outside the config directory
+	}
+	for _, tt := range tests {
+		if got := g.Match(tt.path); got != tt.want {
+			t.Errorf("match(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+
+	c.GeneratedBuiltin = &f
+	if g, err = newGeneratedRules(c, nil, false); err != nil {
+		t.Fatal(err)
+	}
+	if g.Match("api.pb.go") {
+		t.Error("generated_builtin: false kept the built-in heuristics")
+	}
+
+	if g, err = newGeneratedRules(nil, nil, false); err != nil || g != nil {
+		t.Errorf("newGeneratedRules without patterns = %v, %v; want nil", g, err)
+	}
+	if _, err = newGeneratedRules(nil, []string{"[z-a]"}, false); err == nil {
+		t.Error("invalid pattern accepted")
+	}
+}
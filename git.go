@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// gitOutput runs git with args and returns its trimmed stdout.
+func gitOutput(args ...string) (string, error) {
+	out, err := gitRawOutput(args...)
+	return strings.TrimSpace(out), err
+}
+
+// gitRawOutput is gitOutput without the trimming, for -z listings whose
+// paths may begin or end with whitespace; splitNul drops the final NUL.
+func gitRawOutput(args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("git %s: %s", strings.Join(args, " "), msg)
+		}
+		return "", fmt.Errorf("git %s: %w", strings.Join(args, " "), err)
+	}
+	return string(out), nil
+}
+
+// repoRoot returns the top-level directory of the enclosing git
+// repository, or the working directory outside of one.
+func repoRoot() (string, error) {
+	if root, err := gitOutput("rev-parse", "--show-toplevel"); err == nil {
+		return root, nil
+	}
+	return os.Getwd()
+}
+
+// relToRoot returns path relative to root as a slash-separated path.
+func relToRoot(root, path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	// Resolve symlinks on both sides, since git reports the real path of
+	// the top-level directory.
+	if real, err := filepath.EvalSymlinks(abs); err == nil {
+		abs = real
+	}
+	if real, err := filepath.EvalSymlinks(root); err == nil {
+		root = real
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// gitBlob returns the contents of rel (relative to the repository root)
+// at ref without touching the working tree.
+func gitBlob(ref, rel string) ([]byte, error) {
+	out, err := exec.Command("git", "show", ref+":"+rel).Output()
+	if err != nil {
+		return nil, fmt.Errorf("git show %s:%s: %w", ref, rel, err)
+	}
+	return out, nil
+}
+
+// lastCommitTime returns when path was last changed in a commit. It
+// reports false for files git does not track.
+func lastCommitTime(path string) (time.Time, bool) {
+	out, err := gitOutput("-C", filepath.Dir(path), "log", "-1", "--format=%ct", "--", filepath.Base(path))
+	if err != nil || out == "" {
+		return time.Time{}, false
+	}
+	sec, err := strconv.ParseInt(out, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(sec, 0), true
+}
+
+// commitCounts returns how many commits since the given date (any format
+// git accepts, e.g. "90 days ago") touched each file, keyed by path
+// relative to the repository root.
+func commitCounts(since string) (map[string]int, error) {
+	root, err := gitOutput("rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	out, err := gitOutput("-C", root, "log", "--since="+since, "--format=", "--name-only")
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int)
+	for _, line := range strings.Split(out, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			counts[line]++
+		}
+	}
+	return counts, nil
+}
+
+// changedFiles lists files added or modified between the merge base of
+// base and HEAD, relative to the repository root containing dir. Deleted
+// files are omitted.
+func changedFiles(dir, base string) ([]string, error) {
+	out, err := gitRawOutput("-C", dir, "diff", "-z", "--name-only", "--diff-filter=d", base+"...HEAD")
+	if err != nil {
+		return nil, err
+	}
+	return splitNul(out), nil
+}
+
+// modifiedFiles lists files added or modified in the working tree since
+// the merge base of ref and HEAD, relative to the repository root
+// containing dir. Uncommitted changes to tracked files are included;
+// deleted files are omitted.
+func modifiedFiles(dir, ref string) ([]string, error) {
+	out, err := gitRawOutput("-C", dir, "diff", "-z", "--name-only", "--diff-filter=d", "--merge-base", ref)
+	if err != nil {
+		return nil, err
+	}
+	return splitNul(out), nil
+}
+
+// stagedFiles lists files with staged changes, including deletions,
+// relative to the repository root.
+func stagedFiles() ([]string, error) {
+	out, err := gitRawOutput("diff", "--cached", "-z", "--name-only", "--no-renames")
+	if err != nil {
+		return nil, err
+	}
+	return splitNul(out), nil
+}
+
+// addedFiles lists files added between the merge base of base and HEAD,
+// relative to the repository root containing dir.
+func addedFiles(dir, base string) ([]string, error) {
+	out, err := gitRawOutput("-C", dir, "diff", "-z", "--name-only", "--diff-filter=A", base+"...HEAD")
+	if err != nil {
+		return nil, err
+	}
+	return splitNul(out), nil
+}
+
+// renamedFiles maps files renamed since ref to their path at ref, both
+// relative to the repository root containing dir. The working tree is
+// compared, so uncommitted renames are included.
+func renamedFiles(dir, ref string) (map[string]string, error) {
+	out, err := gitRawOutput("-C", dir, "diff", "-z", "--name-status", "-M", "--diff-filter=R", ref)
+	if err != nil {
+		return nil, err
+	}
+	// Records are "R<score>\x00<old>\x00<new>\x00".
+	fields := splitNul(out)
+	renames := make(map[string]string)
+	for i := 0; i+2 < len(fields); i += 3 {
+		renames[fields[i+2]] = fields[i+1]
+	}
+	return renames, nil
+}
+
+// refChange is a file changed between two refs. For an added file
+// oldPath is empty, for a deleted one path is.
+type refChange struct {
+	oldPath, path string
+}
+
+// refChanges lists the files changed between from and to, relative to the
+// repository root, following renames. Paths, if any, limit the listing
+// like a git pathspec relative to the working directory.
+func refChanges(from, to string, paths []string) ([]refChange, error) {
+	args := append([]string{"diff", "-z", "--name-status", "-M", "--no-ext-diff", from, to, "--"}, paths...)
+	out, err := gitRawOutput(args...)
+	if err != nil {
+		return nil, err
+	}
+	// Records are "<status>\x00<path>\x00", or for renames and copies
+	// "R<score>\x00<old>\x00<new>\x00".
+	fields := splitNul(out)
+	var changes []refChange
+	for i := 0; i+1 < len(fields); i += 2 {
+		switch status := fields[i]; status[0] {
+		case 'A':
+			changes = append(changes, refChange{path: fields[i+1]})
+		case 'D':
+			changes = append(changes, refChange{oldPath: fields[i+1]})
+		case 'R', 'C':
+			if i+2 >= len(fields) {
+				return nil, fmt.Errorf("git diff: truncated %s record", status)
+			}
+			changes = append(changes, refChange{oldPath: fields[i+1], path: fields[i+2]})
+			i++
+		default:
+			changes = append(changes, refChange{oldPath: fields[i+1], path: fields[i+1]})
+		}
+	}
+	return changes, nil
+}
+
+// commitBefore returns the last commit on HEAD made before date, which
+// may be any date git understands, such as "90 days ago".
+func commitBefore(date string) (string, error) {
+	sha, err := gitOutput("rev-list", "-1", "--before="+date, "HEAD")
+	if err != nil {
+		return "", err
+	}
+	if sha == "" {
+		return "", fmt.Errorf("no commit before %s", date)
+	}
+	return sha, nil
+}
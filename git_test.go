@@ -0,0 +1,180 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// newTestRepo creates an empty git repository, skipping the test if git
+// is not installed.
+func newTestRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	testGit(t, dir, "", "init", "--quiet", "--initial-branch=main")
+	return dir
+}
+
+// testCommit writes files (path to content) into the repository and
+// commits them. A non-empty date sets the author and committer dates.
+func testCommit(t *testing.T, dir, date string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	testGit(t, dir, date, "add", "-A")
+	testGit(t, dir, date, "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", "test")
+}
+
+func testGit(t *testing.T, dir, date string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	if date != "" {
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_DATE="+date, "GIT_COMMITTER_DATE="+date)
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+func TestAddedFiles(t *testing.T) {
+	dir := newTestRepo(t)
+	testCommit(t, dir, "", map[string]string{"old.go": "package a\n"})
+	testGit(t, dir, "", "checkout", "--quiet", "-b", "feature")
+	testCommit(t, dir, "", map[string]string{"old.go": "package a // changed\n", "new.go": "package a\n"})
+
+	got, err := addedFiles(dir, "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got[0] != "new.go" {
+		t.Errorf("addedFiles = %v, want [new.go]", got)
+	}
+}
+
+func TestBranchAddedFilesSymlinked(t *testing.T) {
+	dir := newTestRepo(t)
+	testCommit(t, dir, "", map[string]string{"old.go": "package a\n"})
+	testGit(t, dir, "", "checkout", "--quiet", "-b", "feature")
+	testCommit(t, dir, "", map[string]string{"é.go": "package a\n"})
+
+	// Work from a symlink to the checkout, as git reports the real root.
+	link := filepath.Join(t.TempDir(), "link")
+	if err := os.Symlink(dir, link); err != nil {
+		t.Skip(err)
+	}
+	t.Chdir(link)
+
+	added, err := branchAddedFiles("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !added.has("é.go") || !added.has(filepath.Join(link, "é.go")) || added.has("old.go") {
+		t.Errorf("branchAddedFiles = %+v, want é.go only", added)
+	}
+}
+
+func TestChangedFiles(t *testing.T) {
+	dir := newTestRepo(t)
+	testCommit(t, dir, "", map[string]string{"a.go": "package a\n", "b.go": "package a\n"})
+	testGit(t, dir, "", "checkout", "--quiet", "-b", "feature")
+	testCommit(t, dir, "", map[string]string{"b.go": "package a // changed\n", "pkg/c.go": "package c\n", "notes.txt": "x", "é.go": "package a\n"})
+	testGit(t, dir, "", "rm", "--quiet", "a.go")
+	testCommit(t, dir, "", nil)
+
+	got, err := changedFiles(dir, "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Git would quote é.go without -z.
+	want := []string{"b.go", "notes.txt", "pkg/c.go", "é.go"}
+	if len(got) != len(want) {
+		t.Fatalf("changedFiles = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("changedFiles[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestStagedFiles(t *testing.T) {
+	dir := newTestRepo(t)
+	testCommit(t, dir, "", map[string]string{"a.go": "package a\n"})
+	if err := os.WriteFile(filepath.Join(dir, "é.go"), []byte("package a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	testGit(t, dir, "", "add", "é.go")
+	testGit(t, dir, "", "rm", "--quiet", "a.go")
+	t.Chdir(dir)
+
+	got, err := stagedFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0] != "a.go" || got[1] != "é.go" {
+		t.Errorf("stagedFiles = %q, want [a.go é.go]", got)
+	}
+}
+
+func TestModifiedFiles(t *testing.T) {
+	dir := newTestRepo(t)
+	testCommit(t, dir, "", map[string]string{"a.go": "package a\n", "b.go": "package a\n", "c.go": "package a\n"})
+	testGit(t, dir, "", "checkout", "--quiet", "-b", "feature")
+	testCommit(t, dir, "", map[string]string{"b.go": "package a // committed\n", "é.go": "package a\n"})
+	if err := os.WriteFile(filepath.Join(dir, "c.go"), []byte("package a // uncommitted\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := modifiedFiles(dir, "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 3 || got[0] != "b.go" || got[1] != "c.go" || got[2] != "é.go" {
+		t.Errorf("modifiedFiles = %q, want [b.go c.go é.go]", got)
+	}
+
+	// From a symlink to the checkout, paths still match the real root git
+	// reports.
+	link := filepath.Join(t.TempDir(), "link")
+	if err := os.Symlink(dir, link); err != nil {
+		t.Skip(err)
+	}
+	t.Chdir(link)
+	changed, err := branchModifiedFiles("main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changed.has("é.go") || !changed.has("c.go") || changed.has("a.go") {
+		t.Errorf("branchModifiedFiles = %+v, want b.go, c.go and é.go", changed)
+	}
+}
+
+func TestRenamedFiles(t *testing.T) {
+	dir := newTestRepo(t)
+	body := "package a\n\n// A long enough body for git to detect the rename.\nfunc F() int { return 1 }\n"
+	testCommit(t, dir, "", map[string]string{"old.go": body, "keep.go": "package a\n"})
+	if err := os.Mkdir(filepath.Join(dir, "pkg"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	testGit(t, dir, "", "mv", "old.go", "pkg/new.go")
+	testCommit(t, dir, "", nil)
+
+	got, err := renamedFiles(dir, "HEAD~1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || got["pkg/new.go"] != "old.go" {
+		t.Errorf("renamedFiles = %v, want pkg/new.go from old.go", got)
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// writeGitHubAnnotations writes a GitHub Actions workflow command for each
+// violation and finding, so they are annotated on the pull request diff.
+// Tolerated violations become warnings.
+func writeGitHubAnnotations(w io.Writer, violations []fileResult, findings []finding, failFrozen bool) {
+	en := newMessages("en")
+	for _, v := range violations {
+		level := "error"
+		if v.tolerated(failFrozen) {
+			level = "warning"
+		}
+		fmt.Fprintf(w, "::%s file=%s,line=1,title=%s::%s\n", level,
+			escapeProperty(filepath.ToSlash(v.path)), escapeProperty("token-lint: token-limit"),
+			escapeData(en.f("sarifViolation", v.tokens, v.threshold, v.chars)))
+	}
+	for _, f := range findings {
+		fmt.Fprintf(w, "::error file=%s,title=%s::%s\n",
+			escapeProperty(filepath.ToSlash(f.path)), escapeProperty("token-lint: "+f.rule),
+			escapeData(f.message(en)))
+	}
+}
+
+// escapeData escapes a workflow command message.
+func escapeData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+// escapeProperty escapes a workflow command property value.
+func escapeProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteGitHubAnnotations(t *testing.T) {
+	var out strings.Builder
+	violations := []fileResult{
+		{path: "pkg/big.go", tokens: 30000, threshold: 25000, chars: 46000},
+		{path: "a,b.go", tokens: 26000, threshold: 25000, chars: 40000, frozen: true},
+	}
+	findings := []finding{{rule: "package-doc", path: "pkg", key: "pkgDocMissing", args: []any{"pkg"}}}
+	writeGitHubAnnotations(&out, violations, findings, false)
+
+	want := "::error file=pkg/big.go,line=1,title=token-lint%3A token-limit::~30000 tokens, over the 25000 token threshold (46000 chars)\n" +
+		"::warning file=a%2Cb.go,line=1,title=token-lint%3A token-limit::~26000 tokens, over the 25000 token threshold (40000 chars)\n" +
+		"::error file=pkg,title=token-lint%3A package-doc::package pkg has no package comment; add one in doc.go\n"
+	if out.String() != want {
+		t.Errorf("annotations:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
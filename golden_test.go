@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update-golden", false, "rewrite the golden files under testdata/golden")
+
+// goldenFormats are the -format values every golden case is run with.
+var goldenFormats = []string{"text", "tree", "json", "jsonl", "sarif", "github", "junit"}
+
+// TestGolden runs token-lint over each repository under testdata/golden
+// in every output format and compares stdout and the exit code with
+// <case>/<format>.golden. A case is a directory holding the repository in
+// repo/ and the command line arguments, one per line, in args. Run
+//
+//	go test -run TestGolden -update-golden
+//
+// to accept changed output after reviewing the diff.
+func TestGolden(t *testing.T) {
+	cases, err := filepath.Glob(filepath.Join("testdata", "golden", "*", "args"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cases) == 0 {
+		t.Fatal("no golden cases found")
+	}
+	// Nothing from the environment may leak into the output.
+	t.Setenv("LANG", "C")
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "")
+
+	for _, argsFile := range cases {
+		dir, err := filepath.Abs(filepath.Dir(argsFile))
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := os.ReadFile(argsFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		args := strings.Fields(string(content))
+
+		for _, format := range goldenFormats {
+			t.Run(filepath.Base(dir)+"/"+format, func(t *testing.T) {
+				t.Chdir(filepath.Join(dir, "repo"))
+				var stdout bytes.Buffer
+				code := run(append([]string{"-format", format}, args...), &stdout, new(bytes.Buffer))
+				got := fmt.Sprintf("%s-- exit %d --\n", stdout.String(), code)
+
+				golden := filepath.Join(dir, format+".golden")
+				if *updateGolden {
+					if err := os.WriteFile(golden, []byte(got), 0644); err != nil {
+						t.Fatal(err)
+					}
+					return
+				}
+				want, err := os.ReadFile(golden)
+				if err != nil {
+					t.Fatalf("%v (run with -update-golden to create it)", err)
+				}
+				if got != string(want) {
+					t.Errorf("output differs from %s (run with -update-golden to accept):\n--- got ---\n%s\n--- want ---\n%s", golden, got, want)
+				}
+			})
+		}
+	}
+}
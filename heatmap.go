@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+)
+
+// heatmapCell aggregates the files one team owns in one package.
+type heatmapCell struct {
+	Team       string
+	Package    string
+	Files      int
+	Tokens     int
+	BaseTokens int
+}
+
+// Growth is the token change since the base ref.
+func (c heatmapCell) Growth() int {
+	return c.Tokens - c.BaseTokens
+}
+
+// runHeatmap implements `token-lint heatmap`, which reports tokens and
+// growth per owning team and package.
+func runHeatmap(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("token-lint heatmap", flag.ContinueOnError)
+	ratio := fs.Float64("ratio", defaultRatio, "tokens per character ratio")
+	since := fs.String("since", "", "git ref to measure growth from, e.g. HEAD~100 or v1.2.0")
+	ownersFile := fs.String("codeowners", "", "CODEOWNERS file (default: .github/CODEOWNERS, CODEOWNERS or docs/CODEOWNERS)")
+	format := fs.String("format", "csv", "output format: csv or html")
+
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 1
+	}
+	pol, err := loadPolicy(fs, "", "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+
+	if *ratio <= 0 {
+		fmt.Fprintln(os.Stderr, "error: ratio must be positive")
+		return 1
+	}
+	if *format != "csv" && *format != "html" {
+		fmt.Fprintf(os.Stderr, "error: unknown heatmap format %q\n", *format)
+		return 1
+	}
+
+	root, err := repoRoot()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+
+	owners, err := openCodeowners(root, *ownersFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{"./..."}
+	}
+
+	files, err := pol.expand(ctx, paths)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+
+	// Compare renamed files with their old path so a move is reported as
+	// growth of the same file rather than a brand new one.
+	var renames map[string]string
+	if *since != "" {
+		if renames, err = renamedFiles(root, *since); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 1
+		}
+	}
+
+	opts, err := pol.options(defaultThreshold, *ratio)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	results, _ := analyzeFiles(ctx, files, opts)
+	cells, err := buildHeatmap(results, root, owners, func(rel string) int {
+		if *since == "" {
+			return 0
+		}
+		if old, ok := renames[rel]; ok {
+			rel = old
+		}
+		content, err := gitBlob(*since, rel)
+		if err != nil {
+			return 0 // file did not exist at the base ref
+		}
+		return int(float64(len(content)) * *ratio)
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+
+	if *format == "html" {
+		err = writeHeatmapHTML(os.Stdout, cells, *since)
+	} else {
+		err = writeHeatmapCSV(os.Stdout, cells)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// buildHeatmap groups results by owning team and package. baseTokens
+// returns a file's token count at the base ref, given its root-relative path.
+func buildHeatmap(results []fileResult, root string, owners codeowners, baseTokens func(rel string) int) ([]heatmapCell, error) {
+	type key struct{ team, pkg string }
+	cells := make(map[key]*heatmapCell)
+
+	for _, r := range results {
+		rel, err := relToRoot(root, r.path)
+		if err != nil {
+			return nil, err
+		}
+		k := key{owners.team(rel), path.Dir(rel)}
+		c, ok := cells[k]
+		if !ok {
+			c = &heatmapCell{Team: k.team, Package: k.pkg}
+			cells[k] = c
+		}
+		c.Files++
+		c.Tokens += r.tokens
+		c.BaseTokens += baseTokens(rel)
+	}
+
+	out := make([]heatmapCell, 0, len(cells))
+	for _, c := range cells {
+		out = append(out, *c)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Team != out[j].Team {
+			return out[i].Team < out[j].Team
+		}
+		return out[i].Package < out[j].Package
+	})
+	return out, nil
+}
+
+func writeHeatmapCSV(w io.Writer, cells []heatmapCell) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"team", "package", "files", "tokens", "base_tokens", "growth"})
+	for _, c := range cells {
+		cw.Write([]string{
+			c.Team,
+			c.Package,
+			strconv.Itoa(c.Files),
+			strconv.Itoa(c.Tokens),
+			strconv.Itoa(c.BaseTokens),
+			strconv.Itoa(c.Growth()),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+var heatmapHTML = template.Must(template.New("heatmap").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>token-lint heatmap</title></head>
+<body>
+<h1>token-lint heatmap</h1>
+{{if .Since}}<p>Growth measured since <code>{{.Since}}</code>.</p>{{end}}
+<table>
+<tr><th>Team</th><th>Package</th><th>Files</th><th>Tokens</th><th>Growth</th></tr>
+{{range .Rows}}<tr><td>{{.Team}}</td><td><code>{{.Package}}</code></td><td>{{.Files}}</td><td style="background: rgba(220, 40, 40, {{.Heat}})">{{.Tokens}}</td><td>{{.Growth}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+func writeHeatmapHTML(w io.Writer, cells []heatmapCell, since string) error {
+	type row struct {
+		heatmapCell
+		Heat string
+	}
+	maxTokens := 1
+	for _, c := range cells {
+		maxTokens = max(maxTokens, c.Tokens)
+	}
+	rows := make([]row, len(cells))
+	for i, c := range cells {
+		rows[i] = row{c, strconv.FormatFloat(float64(c.Tokens)/float64(maxTokens), 'f', 2, 64)}
+	}
+	return heatmapHTML.Execute(w, struct {
+		Since string
+		Rows  []row
+	}{since, rows})
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCodeowners(t *testing.T) {
+	rules, err := parseCodeowners(strings.NewReader(`# comment
+*           @org/everyone
+/pkg/api/   @org/api
+*_test.go   @org/qa   # tests
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"main.go", "@org/everyone"},
+		{"pkg/api/client.go", "@org/api"},
+		{"pkg/api/client_test.go", "@org/qa"},
+		{"other/pkg/api/x.go", "@org/everyone"},
+	}
+	for _, tt := range tests {
+		if got := rules.team(tt.path); got != tt.want {
+			t.Errorf("team(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+
+	if got := codeowners(nil).team("main.go"); got != "(unowned)" {
+		t.Errorf("team with no rules = %q, want (unowned)", got)
+	}
+}
+
+func TestBuildHeatmap(t *testing.T) {
+	root := t.TempDir()
+	rules, err := parseCodeowners(strings.NewReader("/api/ @api\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := []fileResult{
+		{path: filepath.Join(root, "api", "a.go"), tokens: 100},
+		{path: filepath.Join(root, "api", "b.go"), tokens: 50},
+		{path: filepath.Join(root, "main.go"), tokens: 10},
+	}
+	cells, err := buildHeatmap(results, root, rules, func(rel string) int {
+		if rel == "api/a.go" {
+			return 40
+		}
+		return 0
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []heatmapCell{
+		{Team: "(unowned)", Package: ".", Files: 1, Tokens: 10},
+		{Team: "@api", Package: "api", Files: 2, Tokens: 150, BaseTokens: 40},
+	}
+	if len(cells) != len(want) {
+		t.Fatalf("got %d cells %+v, want %d", len(cells), cells, len(want))
+	}
+	for i := range want {
+		if cells[i] != want[i] {
+			t.Errorf("cell %d = %+v, want %+v", i, cells[i], want[i])
+		}
+	}
+	if g := cells[1].Growth(); g != 110 {
+		t.Errorf("Growth() = %d, want 110", g)
+	}
+
+	var csvOut strings.Builder
+	if err := writeHeatmapCSV(&csvOut, cells); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(csvOut.String(), "@api,api,2,150,40,110\n") {
+		t.Errorf("CSV missing @api row:\n%s", csvOut.String())
+	}
+}
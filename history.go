@@ -0,0 +1,313 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// historyBucket holds one historyRecord per commit, keyed by SHA.
+var historyBucket = []byte("commits")
+
+// historyRecord is the per-file token counts of one commit.
+type historyRecord struct {
+	Commit  string         `json:"commit"`
+	Time    int64          `json:"time"` // committer date, Unix seconds
+	Subject string         `json:"subject"`
+	Files   map[string]int `json:"files"` // path relative to the repository root to tokens
+}
+
+// runRecord implements `token-lint record`, which stores the token count
+// of every Go file at a commit in the history database.
+func runRecord(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("token-lint record", flag.ContinueOnError)
+	dbPath := fs.String("db", "", "history database (default token-lint/history.db in the git directory)")
+	commit := fs.String("commit", "HEAD", "commit to record")
+	ratio := fs.Float64("ratio", defaultRatio, "tokens per character ratio")
+
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 1
+	}
+	pol, err := loadPolicy(fs, "", "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	if *ratio <= 0 {
+		fmt.Fprintln(os.Stderr, "error: ratio must be positive")
+		return 1
+	}
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{"./..."}
+	}
+
+	opts, err := pol.options(defaultThreshold, *ratio)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	rec, err := recordCommit(ctx, *commit, paths, pol, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	db, err := openHistory(*dbPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	defer db.Close()
+	if err := saveRecord(db, rec); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	fmt.Printf("recorded %d file(s) at %.12s\n", len(rec.Files), rec.Commit)
+	return 0
+}
+
+// recordCommit counts the Go files matching paths at commit that are in
+// scope of pol.
+func recordCommit(ctx context.Context, commit string, paths []string, pol *policy, opts analyzeOptions) (historyRecord, error) {
+	var rec historyRecord
+	out, err := gitOutput("show", "-s", "--format=%H%x00%ct%x00%s", commit+"^{commit}", "--")
+	if err != nil {
+		return rec, err
+	}
+	fields := strings.SplitN(out, "\x00", 3)
+	if len(fields) != 3 {
+		return rec, fmt.Errorf("unexpected git show output %q", out)
+	}
+	rec.Commit, rec.Subject = fields[0], fields[2]
+	if rec.Time, err = strconv.ParseInt(fields[1], 10, 64); err != nil {
+		return rec, err
+	}
+
+	files, src, err := refFiles(ctx, rec.Commit, paths, pol.expandOptions())
+	if err != nil {
+		return rec, err
+	}
+	opts.read = src.read
+	results, _ := analyzeFiles(ctx, pol.filter(files), opts)
+	rec.Files = make(map[string]int, len(results))
+	for _, r := range results {
+		rel, err := relToRoot(src.root, r.path)
+		if err != nil {
+			return rec, err
+		}
+		rec.Files[rel] = r.tokens
+	}
+	return rec, ctx.Err()
+}
+
+// openHistory opens the history database at path, creating it if needed.
+// An empty path means token-lint/history.db in the git directory, shared
+// by all worktrees.
+func openHistory(path string) (*bolt.DB, error) {
+	if path == "" {
+		dir, err := gitOutput("rev-parse", "--path-format=absolute", "--git-common-dir")
+		if err != nil {
+			return nil, err
+		}
+		path = filepath.Join(dir, "token-lint", "history.db")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	db, err := bolt.Open(path, 0644, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return db, nil
+}
+
+// saveRecord stores rec, replacing any earlier record of the same commit.
+func saveRecord(db *bolt.DB, rec historyRecord) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	return db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists(historyBucket)
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(rec.Commit), data)
+	})
+}
+
+// loadRecords returns all records, oldest commit first.
+func loadRecords(db *bolt.DB) ([]historyRecord, error) {
+	var records []historyRecord
+	err := db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(historyBucket)
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, v []byte) error {
+			var rec historyRecord
+			if err := json.Unmarshal(v, &rec); err != nil {
+				return fmt.Errorf("record %s: %v", k, err)
+			}
+			records = append(records, rec)
+			return nil
+		})
+	})
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Time < records[j].Time })
+	return records, err
+}
+
+// fileTrend is the token history of one file across recorded commits.
+type fileTrend struct {
+	path   string
+	points []int // tokens per record, -1 where the file did not exist
+	first  int   // tokens when first recorded
+	latest int
+	jump   int           // largest increase between consecutive records
+	jumpAt historyRecord // record the largest increase arrived in
+}
+
+// runTrend implements `token-lint trend`, which shows how the largest
+// files grew across the recorded commits.
+func runTrend(args []string) int {
+	fs := flag.NewFlagSet("token-lint trend", flag.ContinueOnError)
+	dbPath := fs.String("db", "", "history database (default token-lint/history.db in the git directory)")
+	top := fs.Int("top", 10, "number of files to show, largest in the latest record first")
+	last := fs.Int("last", 30, "only consider the latest N recorded commits (0 for all)")
+
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 1
+	}
+	if *top <= 0 || *last < 0 {
+		fmt.Fprintln(os.Stderr, "error: top must be positive and last not negative")
+		return 1
+	}
+
+	db, err := openHistory(*dbPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	records, err := loadRecords(db)
+	db.Close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	if len(records) == 0 {
+		fmt.Fprintln(os.Stderr, "no history recorded; run token-lint record first")
+		return 0
+	}
+	if *last > 0 && len(records) > *last {
+		records = records[len(records)-*last:]
+	}
+	printTrends(os.Stdout, computeTrends(records, *top), len(records))
+	return 0
+}
+
+// computeTrends returns the history of the top largest files in the
+// latest record, largest first.
+func computeTrends(records []historyRecord, top int) []fileTrend {
+	latest := records[len(records)-1]
+	var paths []string
+	for p := range latest.Files {
+		paths = append(paths, p)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		a, b := latest.Files[paths[i]], latest.Files[paths[j]]
+		if a != b {
+			return a > b
+		}
+		return paths[i] < paths[j]
+	})
+	if len(paths) > top {
+		paths = paths[:top]
+	}
+
+	trends := make([]fileTrend, len(paths))
+	for i, p := range paths {
+		t := fileTrend{path: p, latest: latest.Files[p], first: -1}
+		prev := -1
+		for _, rec := range records {
+			n, ok := rec.Files[p]
+			if !ok {
+				t.points = append(t.points, -1)
+				prev = -1
+				continue
+			}
+			t.points = append(t.points, n)
+			if t.first < 0 {
+				t.first = n
+			}
+			if prev >= 0 && n-prev > t.jump {
+				t.jump, t.jumpAt = n-prev, rec
+			}
+			prev = n
+		}
+		trends[i] = t
+	}
+	return trends
+}
+
+func printTrends(w io.Writer, trends []fileTrend, commits int) {
+	fmt.Fprintf(w, "Largest files across %d recorded commit(s)\n\n", commits)
+	fmt.Fprintf(w, "%-50s %8s %8s %8s  %-*s  %s\n", "FILE", "FIRST", "LATEST", "CHANGE", commits, "HISTORY", "BIGGEST JUMP")
+	for _, t := range trends {
+		change := "-"
+		if t.first > 0 {
+			change = fmt.Sprintf("%+.1f%%", float64(t.latest-t.first)/float64(t.first)*100)
+		}
+		jump := "-"
+		if t.jump > 0 {
+			jump = fmt.Sprintf("%+d at %.7s %s %s", t.jump, t.jumpAt.Commit,
+				time.Unix(t.jumpAt.Time, 0).UTC().Format("2006-01-02"), t.jumpAt.Subject)
+		}
+		fmt.Fprintf(w, "%-50s %8d %8d %8s  %-*s  %s\n", quotePath(t.path), t.first, t.latest, change,
+			max(commits, len("HISTORY")), sparkline(t.points), jump)
+	}
+}
+
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline draws points scaled between their minimum and maximum, with a
+// space for each -1.
+func sparkline(points []int) string {
+	lo, hi := -1, 0
+	for _, p := range points {
+		if p < 0 {
+			continue
+		}
+		if lo < 0 || p < lo {
+			lo = p
+		}
+		hi = max(hi, p)
+	}
+	var b strings.Builder
+	for _, p := range points {
+		switch {
+		case p < 0:
+			b.WriteByte(' ')
+		case hi == lo:
+			b.WriteRune(sparks[len(sparks)-1])
+		default:
+			b.WriteRune(sparks[(p-lo)*(len(sparks)-1)/(hi-lo)])
+		}
+	}
+	return b.String()
+}
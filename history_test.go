@@ -0,0 +1,72 @@
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestHistoryRoundTrip(t *testing.T) {
+	db, err := openHistory(filepath.Join(t.TempDir(), "sub", "history.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	records := []historyRecord{
+		{Commit: "bbb", Time: 200, Files: map[string]int{"a.go": 20}},
+		{Commit: "aaa", Time: 100, Files: map[string]int{"a.go": 10}},
+		{Commit: "bbb", Time: 200, Files: map[string]int{"a.go": 25}}, // re-recorded
+	}
+	for _, rec := range records {
+		if err := saveRecord(db, rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := loadRecords(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0].Commit != "aaa" || got[1].Files["a.go"] != 25 {
+		t.Errorf("loadRecords = %+v, want aaa then bbb with 25 tokens", got)
+	}
+}
+
+func TestComputeTrends(t *testing.T) {
+	records := []historyRecord{
+		{Commit: "c1", Files: map[string]int{"big.go": 100, "small.go": 10}},
+		{Commit: "c2", Files: map[string]int{"big.go": 400, "small.go": 12}},
+		{Commit: "c3", Files: map[string]int{"big.go": 450, "new.go": 300, "small.go": 5}},
+	}
+	trends := computeTrends(records, 2)
+	if len(trends) != 2 || trends[0].path != "big.go" || trends[1].path != "new.go" {
+		t.Fatalf("trends = %+v, want big.go and new.go", trends)
+	}
+
+	big := trends[0]
+	if big.first != 100 || big.latest != 450 || big.jump != 300 || big.jumpAt.Commit != "c2" {
+		t.Errorf("big.go = %+v, want 100 to 450 with a 300 jump at c2", big)
+	}
+	if want := []int{-1, -1, 300}; !reflect.DeepEqual(trends[1].points, want) {
+		t.Errorf("new.go points = %v, want %v", trends[1].points, want)
+	}
+	if trends[1].jump != 0 {
+		t.Errorf("new.go jump = %d, want 0 for a file recorded once", trends[1].jump)
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	tests := []struct {
+		points []int
+		want   string
+	}{
+		{[]int{0, 7, 14}, "▁▄█"},
+		{[]int{-1, 5, 5}, " ██"},
+		{nil, ""},
+	}
+	for _, tt := range tests {
+		if got := sparkline(tt.points); got != tt.want {
+			t.Errorf("sparkline(%v) = %q, want %q", tt.points, got, tt.want)
+		}
+	}
+}
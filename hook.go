@@ -0,0 +1,131 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// hookMarker identifies hooks written by install-hook, so reinstalling
+// updates them instead of treating them as someone else's.
+const hookMarker = "# Installed by token-lint install-hook; rerun it to update."
+
+// runInstallHook implements `token-lint install-hook`, which writes a git
+// hook running token-lint on what is about to be committed or pushed.
+func runInstallHook(args []string) int {
+	fs := flag.NewFlagSet("token-lint install-hook", flag.ContinueOnError)
+	hook := fs.String("hook", "pre-commit", "hook to install: pre-commit (checks staged files) or pre-push (checks files changed since -base)")
+	base := fs.String("base", "origin/main", "git ref pre-push hooks compare against")
+	command := fs.String("command", "token-lint", "command the hook runs, e.g. 'go tool token-lint'")
+	chain := fs.Bool("chain", false, "keep an existing hook and run it before token-lint")
+	force := fs.Bool("force", false, "replace an existing hook")
+
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 1
+	}
+	if *hook != "pre-commit" && *hook != "pre-push" {
+		fmt.Fprintf(os.Stderr, "error: unknown hook %q\n", *hook)
+		return 1
+	}
+	if *chain && *force {
+		fmt.Fprintln(os.Stderr, "error: -chain and -force are mutually exclusive")
+		return 1
+	}
+
+	// --git-path honors core.hooksPath and linked worktrees.
+	dir, err := gitOutput("rev-parse", "--path-format=absolute", "--git-path", "hooks")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	path, chained, err := installHook(dir, *hook, hookScript(*hook, *command, *base), *chain, *force)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	fmt.Printf("installed %s\n", quotePath(path))
+	if chained != "" {
+		fmt.Printf("the previous hook, now %s, runs first\n", quotePath(chained))
+	}
+	return 0
+}
+
+// hookScript returns the hook body, without the lines that run a chained
+// hook. Both hooks find the repository's .token-lint.yaml on their own,
+// since git runs them from the top of the working tree.
+func hookScript(hook, command, base string) string {
+	check := command + " -staged"
+	if hook == "pre-push" {
+		check = command + " -changed-from " + shellQuote(base)
+	}
+	return "exec " + check + "\n"
+}
+
+// installHook writes hook into dir with body as its final command. An
+// existing hook written by install-hook is updated in place. Any other
+// existing hook is an error unless chain is set, which renames it to
+// <hook>.chained and runs it first, or force is set, which replaces it.
+// It returns the hook's path and the chained hook's path, if any.
+func installHook(dir, hook, body string, chain, force bool) (string, string, error) {
+	path := filepath.Join(dir, hook)
+	chainedPath := path + ".chained"
+
+	existing, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return "", "", err
+	case strings.Contains(string(existing), hookMarker):
+		// Ours: keep chaining whatever it chained before.
+		chain = chain || fileExists(chainedPath)
+	case chain:
+		if fileExists(chainedPath) {
+			return "", "", fmt.Errorf("%s already exists; remove it or install with -force", chainedPath)
+		}
+		if err := os.Rename(path, chainedPath); err != nil {
+			return "", "", err
+		}
+	case !force:
+		return "", "", fmt.Errorf("%s already exists; use -chain to run it before token-lint or -force to replace it", path)
+	}
+
+	var b strings.Builder
+	b.WriteString("#!/bin/sh\n" + hookMarker + "\n")
+	if chain {
+		// Like git, skip the old hook if it is not executable.
+		q := shellQuote(chainedPath)
+		fmt.Fprintf(&b, "if [ -x %s ]; then\n\t%s \"$@\" || exit $?\nfi\n", q, q)
+	}
+	b.WriteString(body)
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", "", err
+	}
+	if err := os.WriteFile(path, []byte(b.String()), 0755); err != nil {
+		return "", "", err
+	}
+	// WriteFile keeps the mode of an existing file.
+	if err := os.Chmod(path, 0755); err != nil {
+		return "", "", err
+	}
+	if !chain {
+		chainedPath = ""
+	}
+	return path, chainedPath, nil
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
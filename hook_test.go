@@ -0,0 +1,61 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInstallHook(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "hooks")
+	body := hookScript("pre-commit", "token-lint", "origin/main")
+
+	path, chained, err := installHook(dir, "pre-commit", body, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, _ := os.ReadFile(path)
+	if chained != "" || !strings.HasSuffix(string(content), "exec token-lint -staged\n") {
+		t.Errorf("hook = %q, chained = %q", content, chained)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm()&0111 == 0 {
+		t.Errorf("hook is not executable: %v", err)
+	}
+	// Reinstalling our own hook updates it.
+	if _, _, err := installHook(dir, "pre-commit", body, false, false); err != nil {
+		t.Errorf("reinstall: %v", err)
+	}
+
+	prePush := filepath.Join(dir, "pre-push")
+	if err := os.WriteFile(prePush, []byte("#!/bin/sh\necho mine\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	pushBody := hookScript("pre-push", "go tool token-lint", "origin/main")
+	if _, _, err := installHook(dir, "pre-push", pushBody, false, false); err == nil {
+		t.Fatal("installHook replaced a foreign hook without -chain or -force")
+	}
+	_, chained, err = installHook(dir, "pre-push", pushBody, true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if old, _ := os.ReadFile(chained); string(old) != "#!/bin/sh\necho mine\n" {
+		t.Errorf("chained hook = %q, want the previous hook", old)
+	}
+	content, _ = os.ReadFile(prePush)
+	if !strings.Contains(string(content), "'"+chained+"' \"$@\" || exit $?") ||
+		!strings.HasSuffix(string(content), "exec go tool token-lint -changed-from 'origin/main'\n") {
+		t.Errorf("chaining hook = %q", content)
+	}
+	// Reinstalling keeps the chain.
+	if _, chained, err := installHook(dir, "pre-push", pushBody, false, false); err != nil || chained == "" {
+		t.Errorf("reinstall chained = %q, %v; want the chain kept", chained, err)
+	}
+
+	if err := os.WriteFile(prePush, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := installHook(dir, "pre-push", pushBody, false, true); err != nil {
+		t.Errorf("-force: %v", err)
+	}
+}
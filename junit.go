@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+)
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Skipped  int             `xml:"skipped,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+// writeJUnit writes a JUnit XML report with one test case per analyzed
+// file, failing those over their threshold, plus one failing test case
+// per rule finding. Tolerated violations are reported as skipped.
+func writeJUnit(w io.Writer, results []fileResult, violations []fileResult, findings []finding, failFrozen bool) error {
+	en := newMessages("en")
+	tolerated := make(map[string]bool)
+	for _, v := range violations {
+		if v.tolerated(failFrozen) {
+			tolerated[v.path] = true
+		}
+	}
+
+	files := junitTestSuite{Name: "token-lint"}
+	for _, r := range results {
+		c := junitTestCase{Name: filepath.ToSlash(r.path), ClassName: "token-limit"}
+		if r.tokens > r.threshold {
+			msg := en.f("sarifViolation", r.tokens, r.threshold, r.chars)
+			if r.suppressed != "" {
+				c.Skipped = &junitSkipped{Message: "suppressed: " + r.suppressed}
+				files.Skipped++
+			} else if tolerated[r.path] {
+				c.Skipped = &junitSkipped{Message: "tolerated: " + msg}
+				files.Skipped++
+			} else {
+				c.Failure = &junitFailure{Message: msg, Type: "token-limit", Text: msg}
+				files.Failures++
+			}
+		}
+		files.Cases = append(files.Cases, c)
+	}
+	files.Tests = len(files.Cases)
+
+	suites := junitTestSuites{Suites: []junitTestSuite{files}}
+	if len(findings) > 0 {
+		rules := junitTestSuite{Name: "token-lint rules", Tests: len(findings), Failures: len(findings)}
+		for _, f := range findings {
+			msg := f.message(en)
+			rules.Cases = append(rules.Cases, junitTestCase{
+				Name:      filepath.ToSlash(f.path),
+				ClassName: f.rule,
+				Failure:   &junitFailure{Message: msg, Type: f.rule, Text: msg},
+			})
+		}
+		suites.Suites = append(suites.Suites, rules)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suites); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestWriteJUnit(t *testing.T) {
+	results := []fileResult{
+		{path: "small.go", tokens: 10, threshold: 100},
+		{path: "big.go", tokens: 150, threshold: 100, chars: 230},
+		{path: "old.go", tokens: 120, threshold: 100, chars: 180},
+	}
+	violations := []fileResult{results[1], results[2]}
+	violations[1].frozen = true
+	findings := []finding{{rule: "package-doc", path: "pkg", key: "pkgDocMissing", args: []any{"pkg"}}}
+
+	var out strings.Builder
+	if err := writeJUnit(&out, results, violations, findings, false); err != nil {
+		t.Fatal(err)
+	}
+
+	var got junitTestSuites
+	if err := xml.Unmarshal([]byte(out.String()), &got); err != nil {
+		t.Fatalf("%v\n%s", err, out.String())
+	}
+	if len(got.Suites) != 2 {
+		t.Fatalf("suites = %d, want 2", len(got.Suites))
+	}
+	files := got.Suites[0]
+	if files.Tests != 3 || files.Failures != 1 || files.Skipped != 1 {
+		t.Errorf("files suite = %d tests, %d failures, %d skipped; want 3, 1, 1", files.Tests, files.Failures, files.Skipped)
+	}
+	if c := files.Cases[1]; c.Name != "big.go" || c.Failure == nil || c.Failure.Message != "~150 tokens, over the 100 token threshold (230 chars)" {
+		t.Errorf("big.go case = %+v", c)
+	}
+	if c := got.Suites[1].Cases[0]; c.ClassName != "package-doc" || c.Failure == nil {
+		t.Errorf("rule case = %+v", c)
+	}
+}
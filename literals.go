@@ -0,0 +1,104 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"sort"
+)
+
+// minDataLiteral is the size in bytes from which a string literal or a
+// composite literal of constants counts as embedded data rather than code.
+const minDataLiteral = 1024
+
+// dataLiterals summarizes the data literals of one file.
+type dataLiterals struct {
+	bytes       int // total size of all data literals
+	largest     int // size of the largest one
+	largestLine int
+}
+
+// checkDataLiterals reports files where giant string literals and data
+// blobs such as []byte{0x1f, 0x8b, ...} make up more than share of the
+// tokens. Such data is rarely useful context and belongs in files loaded
+// with go:embed.
+func checkDataLiterals(results []fileResult, share float64, read func(string) ([]byte, error)) []finding {
+	var findings []finding
+	for _, r := range results {
+		src, err := read(r.path)
+		if err != nil || len(src) == 0 {
+			continue
+		}
+		d, ok := findDataLiterals(r.path, src)
+		if !ok || float64(d.bytes) <= share*float64(len(src)) {
+			continue
+		}
+		scale := float64(r.tokens) / float64(len(src))
+		findings = append(findings, finding{rule: "data-literals", path: r.path, key: "dataLiterals",
+			args: []any{float64(d.bytes) / float64(len(src)) * 100, r.tokens, int(float64(d.largest)*scale + 0.5), d.largestLine}})
+	}
+	sort.Slice(findings, func(i, j int) bool { return findings[i].path < findings[j].path })
+	return findings
+}
+
+// findDataLiterals measures the data literals in src. It returns false if
+// src does not parse.
+func findDataLiterals(filename string, src []byte) (dataLiterals, bool) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filename, src, parser.SkipObjectResolution)
+	if err != nil {
+		return dataLiterals{}, false
+	}
+	var d dataLiterals
+	ast.Inspect(f, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.BasicLit:
+			if n.Kind != token.STRING {
+				return false
+			}
+		case *ast.CompositeLit:
+			if !constantElements(n) {
+				return true
+			}
+		default:
+			return true
+		}
+		size := fset.Position(n.End()).Offset - fset.Position(n.Pos()).Offset
+		if size >= minDataLiteral {
+			d.bytes += size
+			if size > d.largest {
+				d.largest = size
+				d.largestLine = fset.Position(n.Pos()).Line
+			}
+		}
+		return false
+	})
+	return d, true
+}
+
+// constantElements reports whether every element of lit is a basic
+// literal, possibly negated or keyed by one, as in a table of bytes.
+func constantElements(lit *ast.CompositeLit) bool {
+	if len(lit.Elts) == 0 {
+		return false
+	}
+	isConst := func(e ast.Expr) bool {
+		if u, ok := e.(*ast.UnaryExpr); ok {
+			e = u.X
+		}
+		_, ok := e.(*ast.BasicLit)
+		return ok
+	}
+	for _, e := range lit.Elts {
+		if kv, ok := e.(*ast.KeyValueExpr); ok {
+			if !isConst(kv.Key) || !isConst(kv.Value) {
+				return false
+			}
+			continue
+		}
+		if !isConst(e) {
+			return false
+		}
+	}
+	return true
+}
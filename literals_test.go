@@ -0,0 +1,48 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFindDataLiterals(t *testing.T) {
+	blob := strings.Repeat("0x1f, ", 200)
+	text := strings.Repeat("x", 1500)
+	src := "package p\n\nvar short = \"hello\"\n\nvar gz = []byte{" + blob + "}\n\nvar doc = `" + text + "`\n\nvar calls = []int{f(1), 2}\n\nfunc f(int) int { return 0 }\n"
+	d, ok := findDataLiterals("p.go", []byte(src))
+	if !ok {
+		t.Fatal("source did not parse")
+	}
+	blobSize := len("[]byte{" + blob + "}")
+	textSize := len(text) + 2
+	if d.bytes != blobSize+textSize || d.largest != textSize || d.largestLine != 7 {
+		t.Errorf("data literals = %+v, want %d bytes, largest %d at line 7", d, blobSize+textSize, textSize)
+	}
+}
+
+func TestCheckDataLiterals(t *testing.T) {
+	dir := t.TempDir()
+	data := filepath.Join(dir, "data.go")
+	code := filepath.Join(dir, "code.go")
+	files := map[string]string{
+		data: "package p\n\nvar s = `" + strings.Repeat("x", 2000) + "`\n",
+		code: "package p\n\nvar s = `" + strings.Repeat("x", 2000) + "`\n\n" + strings.Repeat("func f() {}\n", 200),
+	}
+	var results []fileResult
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		results = append(results, fileResult{path: path, tokens: len(content)})
+	}
+
+	got := checkDataLiterals(results, 0.5, os.ReadFile)
+	if len(got) != 1 || got[0].path != data {
+		t.Fatalf("findings = %v, want only data.go", got)
+	}
+	if msg := got[0].message(newMessages("en")); !strings.Contains(msg, "largest ~2002 tokens at line 3") {
+		t.Errorf("message = %q", msg)
+	}
+}
@@ -0,0 +1,344 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/befabri/token-lint/pkg/tokenlint"
+)
+
+// JSON-RPC error codes used by the language server.
+const (
+	lspParseError     = -32700
+	lspMethodNotFound = -32601
+)
+
+// lspMaxMessage caps the Content-Length the server accepts, so a broken
+// or hostile client cannot make it allocate without bound. Whole documents
+// travel in messages, so the cap is generous.
+const lspMaxMessage = 64 << 20
+
+// lspMessage is an incoming JSON-RPC request or notification.
+type lspMessage struct {
+	ID     json.RawMessage `json:"id,omitempty"` // absent for notifications
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// lspDocument is the text document part of textDocument/* notifications.
+type lspDocument struct {
+	TextDocument struct {
+		URI  string `json:"uri"`
+		Text string `json:"text"`
+	} `json:"textDocument"`
+	ContentChanges []struct {
+		Text string `json:"text"`
+	} `json:"contentChanges"`
+	Text *string `json:"text"` // didSave with includeText
+}
+
+type lspPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type lspRange struct {
+	Start lspPosition `json:"start"`
+	End   lspPosition `json:"end"`
+}
+
+type lspDiagnostic struct {
+	Range    lspRange `json:"range"`
+	Severity int      `json:"severity"`
+	Code     string   `json:"code"`
+	Source   string   `json:"source"`
+	Message  string   `json:"message"`
+}
+
+// lspServer serves token-limit diagnostics over the Language Server
+// Protocol. Documents are analyzed in memory, as the editor holds them.
+type lspServer struct {
+	in        *bufio.Reader
+	out       io.Writer
+	opts      analyzeOptions
+	inScope   func(path string) bool
+	generated *tokenlint.GeneratedRules // nil for the built-in rules
+	msg       messages
+	docs      map[string]string // open document URI to text
+	shutdown  bool
+}
+
+// runLSP implements `token-lint lsp`, which serves diagnostics on stdin
+// and stdout for editors.
+func runLSP(args []string) int {
+	fs := flag.NewFlagSet("token-lint lsp", flag.ContinueOnError)
+	flags := addServerFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 1
+	}
+	// Documents are recounted on every change, too often for a remote API.
+	pol, opts, err := flags.load(fs, "lsp")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+
+	s := &lspServer{
+		in:        bufio.NewReader(os.Stdin),
+		out:       os.Stdout,
+		opts:      opts,
+		inScope:   pol.inScope,
+		generated: pol.generated,
+		msg:       newMessages("en"),
+		docs:      make(map[string]string),
+	}
+	return s.serve()
+}
+
+// serve handles messages until the client sends exit or closes stdin,
+// and returns the exit code the protocol asks for: 0 after a shutdown
+// request, 1 otherwise.
+func (s *lspServer) serve() int {
+	for {
+		body, err := readLSPMessage(s.in)
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			}
+			return 1
+		}
+		var m lspMessage
+		if err := json.Unmarshal(body, &m); err != nil {
+			s.replyError(nil, lspParseError, err.Error())
+			continue
+		}
+		if m.Method == "exit" {
+			if s.shutdown {
+				return 0
+			}
+			return 1
+		}
+		s.handle(m)
+	}
+}
+
+func (s *lspServer) handle(m lspMessage) {
+	switch m.Method {
+	case "initialize":
+		s.reply(m.ID, map[string]any{
+			"capabilities": map[string]any{
+				"textDocumentSync": map[string]any{
+					"openClose": true,
+					"change":    1, // full text
+					"save":      map[string]any{"includeText": true},
+				},
+			},
+			"serverInfo": map[string]any{"name": "token-lint", "version": currentBuildInfo().version},
+		})
+	case "shutdown":
+		s.shutdown = true
+		s.reply(m.ID, nil)
+	case "textDocument/didOpen", "textDocument/didChange", "textDocument/didSave", "textDocument/didClose":
+		var p lspDocument
+		if err := json.Unmarshal(m.Params, &p); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %s: %v\n", m.Method, err)
+			return
+		}
+		uri := p.TextDocument.URI
+		switch m.Method {
+		case "textDocument/didOpen":
+			s.docs[uri] = p.TextDocument.Text
+		case "textDocument/didChange":
+			if n := len(p.ContentChanges); n > 0 {
+				s.docs[uri] = p.ContentChanges[n-1].Text
+			}
+		case "textDocument/didSave":
+			if p.Text != nil {
+				s.docs[uri] = *p.Text
+			}
+		case "textDocument/didClose":
+			delete(s.docs, uri)
+			s.publish(uri, nil)
+			return
+		}
+		s.publish(uri, s.diagnose(uri, s.docs[uri]))
+	default:
+		// Requests need an answer; notifications such as initialized
+		// and $/cancelRequest may be ignored.
+		if len(m.ID) > 0 {
+			s.replyError(m.ID, lspMethodNotFound, "method not supported: "+m.Method)
+		}
+	}
+}
+
+// diagnose returns the diagnostics for the Go document at uri with the
+// given text: one on the package clause line if it is over its threshold.
+// Generated documents are skipped, as the CLI and the analyzer skip them.
+func (s *lspServer) diagnose(uri, text string) []lspDiagnostic {
+	path, ok := uriPath(uri)
+	if !ok || !strings.HasSuffix(path, ".go") || !s.inScope(path) {
+		return nil
+	}
+	if s.generated.Match(path) || tokenlint.GeneratedHeader(strings.NewReader(text)) {
+		return nil
+	}
+	opts := s.opts
+	opts.read = func(string) ([]byte, error) { return []byte(text), nil }
+	report := tokenlint.Analyze(context.Background(), []string{path}, opts.library())
+	if len(report.Violations) == 0 {
+		return nil
+	}
+	v := report.Violations[0]
+	line := packageLine(path, text)
+	return []lspDiagnostic{{
+		Range:    lspRange{lspPosition{line, 0}, lspPosition{line, utf16Len(lineText(text, line))}},
+		Severity: 2, // warning
+		Code:     "token-limit",
+		Source:   "token-lint",
+		Message:  s.msg.f("sarifViolation", v.Tokens, v.Threshold, v.Chars),
+	}}
+}
+
+// packageLine returns the zero-based line of the package clause, or 0 if
+// the source does not parse that far.
+func packageLine(path, text string) int {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, path, text, parser.PackageClauseOnly)
+	if err != nil || !f.Package.IsValid() {
+		return 0
+	}
+	return fset.Position(f.Package).Line - 1
+}
+
+func lineText(text string, line int) string {
+	for range line {
+		_, rest, ok := strings.Cut(text, "\n")
+		if !ok {
+			return ""
+		}
+		text = rest
+	}
+	text, _, _ = strings.Cut(text, "\n")
+	return strings.TrimSuffix(text, "\r")
+}
+
+// utf16Len returns the length of s in UTF-16 code units, the LSP default
+// position encoding.
+func utf16Len(s string) int {
+	return len(utf16.Encode([]rune(s)))
+}
+
+// uriPath returns the local path of a file URI.
+func uriPath(uri string) (string, bool) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return "", false
+	}
+	path := u.Path
+	// file:///C:/dir/x.go has the path /C:/dir/x.go.
+	if len(path) >= 3 && path[0] == '/' && path[2] == ':' {
+		path = path[1:]
+	}
+	return filepath.FromSlash(path), true
+}
+
+func (s *lspServer) publish(uri string, diags []lspDiagnostic) {
+	if diags == nil {
+		diags = []lspDiagnostic{}
+	}
+	s.send(map[string]any{
+		"jsonrpc": "2.0",
+		"method":  "textDocument/publishDiagnostics",
+		"params":  map[string]any{"uri": uri, "diagnostics": diags},
+	})
+}
+
+func (s *lspServer) reply(id json.RawMessage, result any) {
+	s.send(map[string]any{"jsonrpc": "2.0", "id": id, "result": result})
+}
+
+func (s *lspServer) replyError(id json.RawMessage, code int, message string) {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	s.send(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      id,
+		"error":   map[string]any{"code": code, "message": message},
+	})
+}
+
+func (s *lspServer) send(v any) {
+	if err := writeLSPMessage(s.out, v); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+	}
+}
+
+// readLSPMessage reads one message framed by a Content-Length header of
+// at most lspMaxMessage bytes.
+func readLSPMessage(r *bufio.Reader) ([]byte, error) {
+	length := -1
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			if err == io.EOF && line == "" && length < 0 {
+				return nil, io.EOF
+			}
+			return nil, fmt.Errorf("reading header: %w", err)
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("malformed header %q", line)
+		}
+		if strings.EqualFold(strings.TrimSpace(name), "Content-Length") {
+			if length, err = strconv.Atoi(strings.TrimSpace(value)); err != nil || length < 0 {
+				return nil, fmt.Errorf("bad Content-Length %q", value)
+			}
+		}
+	}
+	if length < 0 {
+		return nil, errors.New("message without Content-Length")
+	}
+	if length > lspMaxMessage {
+		return nil, fmt.Errorf("Content-Length %d exceeds the %d byte limit", length, lspMaxMessage)
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, fmt.Errorf("reading body: %w", err)
+	}
+	return body, nil
+}
+
+// writeLSPMessage writes v as JSON framed by a Content-Length header.
+func writeLSPMessage(w io.Writer, v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "Content-Length: %d\r\n\r\n", len(body))
+	b.Write(body)
+	_, err = w.Write(b.Bytes())
+	return err
+}
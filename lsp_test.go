@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLSPSession(t *testing.T) {
+	dir := t.TempDir()
+	uri := "file://" + filepath.ToSlash(filepath.Join(dir, "big.go"))
+	big := "// Package a is documented.\npackage a\n\n// " + strings.Repeat("x", 200) + "\n"
+
+	var in bytes.Buffer
+	for _, m := range []string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`,
+		`{"jsonrpc":"2.0","method":"initialized","params":{}}`,
+		`{"jsonrpc":"2.0","method":"textDocument/didOpen","params":{"textDocument":{"uri":"` + uri + `","text":` + jsonQuote(big) + `}}}`,
+		`{"jsonrpc":"2.0","method":"textDocument/didChange","params":{"textDocument":{"uri":"` + uri + `"},"contentChanges":[{"text":"package a\n"}]}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"textDocument/hover","params":{}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"shutdown"}`,
+		`{"jsonrpc":"2.0","method":"exit"}`,
+	} {
+		if err := writeLSPMessage(&in, json.RawMessage(m)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var out bytes.Buffer
+	s := &lspServer{
+		in:      bufio.NewReader(&in),
+		out:     &out,
+		opts:    analyzeOptions{threshold: 100, ratio: 1},
+		inScope: func(string) bool { return true },
+		msg:     newMessages("en"),
+		docs:    make(map[string]string),
+	}
+	if code := s.serve(); code != 0 {
+		t.Errorf("serve = %d, want 0 after shutdown", code)
+	}
+
+	type reply struct {
+		ID     *int            `json:"id"`
+		Method string          `json:"method"`
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Code int `json:"code"`
+		} `json:"error"`
+		Params struct {
+			Diagnostics []lspDiagnostic `json:"diagnostics"`
+		} `json:"params"`
+	}
+	r := bufio.NewReader(&out)
+	var replies []reply
+	for {
+		body, err := readLSPMessage(r)
+		if err != nil {
+			break
+		}
+		var rep reply
+		if err := json.Unmarshal(body, &rep); err != nil {
+			t.Fatal(err)
+		}
+		replies = append(replies, rep)
+	}
+	if len(replies) != 5 {
+		t.Fatalf("got %d replies, want 5: %+v", len(replies), replies)
+	}
+	if replies[0].ID == nil || *replies[0].ID != 1 || !strings.Contains(string(replies[0].Result), `"textDocumentSync"`) {
+		t.Errorf("initialize reply = %+v", replies[0])
+	}
+	diags := replies[1].Params.Diagnostics
+	if replies[1].Method != "textDocument/publishDiagnostics" || len(diags) != 1 {
+		t.Fatalf("didOpen reply = %+v, want one diagnostic", replies[1])
+	}
+	want := lspRange{lspPosition{1, 0}, lspPosition{1, 9}}
+	if diags[0].Range != want || diags[0].Code != "token-limit" || !strings.Contains(diags[0].Message, "over the 100 token threshold") {
+		t.Errorf("diagnostic = %+v, want token-limit on the package clause", diags[0])
+	}
+	if len(replies[2].Params.Diagnostics) != 0 {
+		t.Errorf("didChange under the threshold published %+v, want none", replies[2].Params.Diagnostics)
+	}
+	if replies[3].Error == nil || replies[3].Error.Code != lspMethodNotFound {
+		t.Errorf("hover reply = %+v, want method not found", replies[3])
+	}
+	if string(replies[4].Result) != "null" {
+		t.Errorf("shutdown result = %s, want null", replies[4].Result)
+	}
+}
+
+func TestLSPDiagnoseGenerated(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir) // -generated patterns are relative to the working directory
+	generated, err := newGeneratedRules(nil, []string{"*_templ.go"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &lspServer{
+		opts:      analyzeOptions{threshold: 100, ratio: 1},
+		inScope:   func(string) bool { return true },
+		generated: generated,
+		msg:       newMessages("en"),
+	}
+	uri := func(name string) string { return "file://" + filepath.ToSlash(filepath.Join(dir, name)) }
+	big := "package a\n\n// " + strings.Repeat("x", 200) + "\n"
+
+	if len(s.diagnose(uri("big.go"), big)) != 1 {
+		t.Fatal("big.go has no diagnostic, want one")
+	}
+	for name, text := range map[string]string{
+		"api.pb.go":     big,
+		"view_templ.go": big,
+		"header.go":     "// Code generated by gen. DO NOT EDIT.\n\n" + big,
+	} {
+		if d := s.diagnose(uri(name), text); len(d) != 0 {
+			t.Errorf("%s: got %+v, want no diagnostics on generated files", name, d)
+		}
+	}
+}
+
+func jsonQuote(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+func TestReadLSPMessage(t *testing.T) {
+	r := bufio.NewReader(strings.NewReader("Content-Length: 2\r\nContent-Type: application/vscode-jsonrpc; charset=utf-8\r\n\r\n{}Content-Length: 5\r\n\r\n{"))
+	body, err := readLSPMessage(r)
+	if err != nil || string(body) != "{}" {
+		t.Errorf("first message = %q, %v; want {}", body, err)
+	}
+	if _, err := readLSPMessage(r); err == nil {
+		t.Error("truncated body read without error")
+	}
+
+	r = bufio.NewReader(strings.NewReader("Content-Length: 99999999999\r\n\r\n{}"))
+	if _, err := readLSPMessage(r); err == nil || !strings.Contains(err.Error(), "exceeds") {
+		t.Errorf("oversized message error = %v, want the size limit", err)
+	}
+}
+
+func TestURIPath(t *testing.T) {
+	tests := []struct {
+		uri  string
+		want string
+		ok   bool
+	}{
+		{"file:///home/me/a%20b.go", filepath.FromSlash("/home/me/a b.go"), true},
+		{"file:///C:/src/x.go", filepath.FromSlash("C:/src/x.go"), true},
+		{"untitled:Untitled-1", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := uriPath(tt.uri)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("uriPath(%q) = %q, %v; want %q, %v", tt.uri, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -10,40 +10,258 @@
 //
 //	0 - All files under threshold
 //	1 - One or more files exceed threshold
+//	2 - Interrupted (SIGINT/SIGTERM) or timed out; partial results are printed
 //
 // Token estimation uses a character-based ratio calibrated for Claude's tokenizer
 // on Go code (~0.65 tokens per character). Actual token counts may vary slightly.
 package main
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
-	"path/filepath"
+	"os/signal"
+	"runtime"
 	"sort"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/befabri/token-lint/pkg/tokenlint"
 )
 
 const (
-	defaultThreshold = 25000
-	defaultRatio     = 0.65
+	defaultThreshold = tokenlint.DefaultThreshold
+	defaultRatio     = tokenlint.DefaultRatio
 )
 
+// exitCanceled is returned when a scan is interrupted or times out.
+const exitCanceled = 2
+
+// analyzeOptions controls how files are counted and judged.
+type analyzeOptions struct {
+	threshold     int
+	ratio         float64
+	maxFileBytes  int64 // files larger than this are skipped; 0 means no limit
+	ignoreImports bool  // exclude the package clause and imports from counts
+
+	tokenizer      tokenlint.Tokenizer // counting backend; nil estimates from ratio
+	categoryRatios map[string]float64  // ratio per tokenlint.Category, for ratio estimates
+
+	read func(path string) ([]byte, error) // file source; nil reads the working tree
+
+	testThreshold int                           // threshold for _test.go files; 0 uses threshold
+	pathThreshold func(path string) (int, bool) // per-path override of threshold, if set
+
+	newFiles     gitFileSet // files added on this branch
+	newThreshold int        // stricter threshold for newFiles; 0 disables
+
+	jobs int // files analyzed concurrently; 0 uses GOMAXPROCS
+
+	tolerate func(*fileResult) // marks baselined and frozen violations, if set
+	onResult func(fileResult)  // called as each file is analyzed, in order, if set
+	stderr   io.Writer         // destination for warnings; os.Stderr if nil
+}
+
+// library returns the tokenlint options o amounts to. New files are held
+// to newThreshold through the final threshold adjustment.
+func (o analyzeOptions) library() tokenlint.Options {
+	lo := tokenlint.Options{
+		Threshold:      o.threshold,
+		TestThreshold:  o.testThreshold,
+		PathThreshold:  o.pathThreshold,
+		Ratio:          o.ratio,
+		CategoryRatios: o.categoryRatios,
+		Tokenizer:      o.tokenizer,
+		IgnoreImports:  o.ignoreImports,
+		MaxFileBytes:   o.maxFileBytes,
+		Read:           o.read,
+		Jobs:           o.jobs,
+		Warnf:          o.warnf,
+	}
+	if o.newThreshold > 0 && len(o.newFiles.files) > 0 {
+		lo.Limit = func(path string, t int) int {
+			if o.newFiles.has(path) {
+				return min(t, o.newThreshold)
+			}
+			return t
+		}
+	}
+	return lo
+}
+
+// count returns the token count of content.
+func (o analyzeOptions) count(content []byte) int {
+	return o.library().Count(content)
+}
+
+// readFile returns the content of path from the configured source.
+func (o analyzeOptions) readFile(path string) ([]byte, error) {
+	if o.read != nil {
+		return o.read(path)
+	}
+	return os.ReadFile(path)
+}
+
+// warnf reports a non-fatal problem with one file.
+func (o analyzeOptions) warnf(format string, args ...any) {
+	w := o.stderr
+	if w == nil {
+		w = os.Stderr
+	}
+	fmt.Fprintf(w, "warning: "+format+"\n", args...)
+}
+
 type fileResult struct {
-	path   string
-	tokens int
-	chars  int
+	path      string
+	tokens    int
+	chars     int
+	threshold int    // effective threshold for this file
+	sha256    string // hex SHA-256 of the file content as read
+	category  string // tokenlint.Category, "" for ordinary code
+
+	frozen     bool      // violation on a file untouched for -frozen-after months
+	lastChange time.Time // last commit touching the file, if known
+
+	suppressed string // reason given by a //tokenlint:ignore directive
+
+	baselined      bool // violation recorded in the -baseline and not grown since
+	baselineTokens int  // tokens recorded in the -baseline, if any
+
+	languages []langShare   // content breakdown, computed for violations only
+	decls     []declSize    // largest declarations, computed for violations only
+	classes   *tokenClasses // tokens by content class, computed for violations only
+}
+
+// tolerated reports whether a violation is shown without failing the
+// build: grandfathered by the baseline, or frozen unless -fail-frozen is
+// set.
+func (r fileResult) tolerated(failFrozen bool) bool {
+	return r.baselined || r.frozen && !failFrozen
 }
 
 func main() {
-	os.Exit(run(os.Args[1:]))
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
 }
 
-func run(args []string) int {
+// run executes token-lint with args. Machine-readable output and the text
+// report go to stdout; warnings, errors and progress notes go to stderr.
+func run(args []string, stdout, stderr io.Writer) int {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if len(args) > 0 {
+		switch args[0] {
+		case "report":
+			return runReport(ctx, args[1:])
+		case "recommend":
+			return runRecommend(ctx, args[1:])
+		case "fleet":
+			return runFleet(ctx, args[1:])
+		case "heatmap":
+			return runHeatmap(ctx, args[1:])
+		case "prioritize":
+			return runPrioritize(ctx, args[1:])
+		case "commit-msg":
+			return runCommitMsg(args[1:])
+		case "view":
+			return runView(args[1:])
+		case "suggest":
+			return runSuggest(args[1:])
+		case "fix":
+			return runFix(args[1:])
+		case "calibrate":
+			return runCalibrate(ctx, args[1:])
+		case "outline":
+			return runOutline(ctx, args[1:])
+		case "score":
+			return runScore(ctx, args[1:])
+		case "du":
+			return runDu(ctx, args[1:])
+		case "campaign":
+			return runCampaign(ctx, args[1:])
+		case "install-hook":
+			return runInstallHook(args[1:])
+		case "diff":
+			return runDiff(args[1:])
+		case "agent-prep":
+			return runAgentPrep(ctx, args[1:])
+		case "record":
+			return runRecord(ctx, args[1:])
+		case "trend":
+			return runTrend(args[1:])
+		case "lsp":
+			return runLSP(args[1:])
+		case "mcp":
+			return runMCP(args[1:])
+		case "version":
+			currentBuildInfo().write(stdout)
+			return 0
+		}
+	}
+
 	fs := flag.NewFlagSet("token-lint", flag.ContinueOnError)
+	fs.SetOutput(stderr)
 	threshold := fs.Int("threshold", defaultThreshold, "maximum tokens before warning")
+	var rules pathThresholds
+	fs.Var(&rules, "rule", "per-path threshold PATTERN=THRESHOLD, e.g. 'cmd/**=15000' (repeatable; the last match wins)")
+	fast := fs.Bool("fast", false, "check a single file with the ratio estimate only, skipping discovery, git and rules (for editor save hooks)")
+	configPath := fs.String("config", "", "config file (default: nearest "+configFileName+" from the working directory up)")
+	ignoreFile := fs.String("ignore-file", "", "gitignore-style file of paths to skip (default: nearest "+ignoreFileName+" from the working directory up)")
+	testThreshold := fs.Int("test-threshold", 0, "threshold for _test.go files (0 uses -threshold)")
+	ramp := fs.String("ramp", "", "tighten the threshold linearly over time, e.g. 40000@2026-01-01,25000@2026-06-01 (overrides -threshold)")
 	showAll := fs.Bool("all", false, "show token counts for all files, not just violations")
+	minTokens := fs.Int("min-tokens", 0, "leave files under this many tokens out of the -all listing and -format tree")
+	staged := fs.Bool("staged", false, "only analyze files with staged changes, as they are in the git index (for pre-commit hooks)")
+	changedFrom := fs.String("changed-from", "", "only analyze files added or modified since the merge base with this git ref, e.g. origin/main (uncommitted changes included)")
+	ref := fs.String("ref", "", "analyze files as they are at this git commit, branch, tag or stash instead of the working tree")
+	format := fs.String("format", "text", "output format: text, tree, json, jsonl, sarif, github or junit")
+	lang := fs.String("lang", "", "language for report messages: en, ja or de (default from $LANG)")
 	ratio := fs.Float64("ratio", defaultRatio, "tokens per character ratio")
+	tokenizer := fs.String("tokenizer", "ratio", "token counting backend: "+strings.Join(tokenizerNames(), ", "))
+	encoding := fs.String("encoding", "cl100k_base", "vocabulary for -tokenizer bpe: cl100k_base or o200k_base")
+	apiModel := fs.String("api-model", defaultClaudeModel, "model whose tokenizer -tokenizer claude-api counts with")
+	apiQPS := fs.Float64("api-qps", defaultAPIQPS, "maximum requests per second for API tokenizers (0 means no limit)")
+	apiConcurrency := fs.Int("api-concurrency", defaultAPIConcurrency, "maximum concurrent requests for API tokenizers (0 means no limit)")
+	apiBudget := fs.Int("api-budget", 0, "maximum requests per run for API tokenizers, retries included; later files fall back to the -ratio estimate (0 means no limit)")
+	apiBatch := fs.Int("api-batch", defaultAPIBatch, "maximum small files (under 4 KiB) counted per request by API tokenizers (1 disables batching)")
+	model := fs.String("model", "", "preset tokenizer, ratio and threshold for a model: "+strings.Join(modelNames(), ", "))
+	ignoreImports := fs.Bool("ignore-imports", false, "exclude the package clause and import block from counts")
+	includeHidden := fs.Bool("include-hidden", false, "scan files and directories starting with . or _ (ignored by the go tool)")
+	includeGenerated := fs.Bool("include-generated", false, "scan generated files (by path, or a \"// Code generated ... DO NOT EDIT.\" header) when expanding ./...")
+	var generatedFlags patternList
+	fs.Var(&generatedFlags, "generated", "treat files matching this gitignore-style pattern as generated, e.g. '*_templ.go' or '**/mocks/**' (repeatable)")
+	noBuiltinGenerated := fs.Bool("no-builtin-generated", false, "replace the built-in generated path patterns (/gen/, *_gen.go, *.pb.go, *.sql.go) with -generated ones")
+	noGitignore := fs.Bool("no-gitignore", false, "scan files matched by .gitignore files when expanding ./...")
+	maxFileBytes := fs.Int64("max-file-bytes", 0, "skip files larger than this many bytes (0 means no limit)")
+	jobs := fs.Int("j", runtime.GOMAXPROCS(0), "number of files to analyze concurrently")
+	watch := fs.Bool("watch", false, "after the report, keep re-analyzing files as they change until interrupted")
+	frozenAfter := fs.Int("frozen-after", 0, "treat violations in files not committed to for this many months as frozen and non-failing (0 disables)")
+	baselinePath := fs.String("baseline", "", "JSON file of grandfathered violations; they are reported but only fail if they grow")
+	writeBaselineFlag := fs.Bool("write-baseline", false, "record the current violations in the -baseline file and exit")
+	failFrozen := fs.Bool("fail-frozen", false, "let frozen violations fail the build too")
+	prBudget := fs.Int("pr-budget", 0, "fail if the Go files changed since -base total more than this many tokens (0 disables)")
+	totalBudget := fs.Int("total-budget", 0, "fail if all analyzed files together total more than this many tokens (0 disables)")
+	base := fs.String("base", "origin/main", "git ref the current branch is compared against")
+	strictNew := fs.Int("strict-new", 0, "stricter threshold for files added since -base (0 disables)")
+	sample := fs.String("sample", "", "analyze a deterministic sample of files, e.g. 10%")
+	sampleSeedFlag := fs.String("sample-seed", "", "seed for -sample (default: current commit SHA)")
+	timeout := fs.Duration("timeout", 0, "abort the scan after this duration, e.g. 5m (0 means no limit)")
+	pkgDocBudget := fs.Int("package-doc-budget", 0, "require a package comment in every package and cap it at this many tokens (0 disables)")
+	byPackage := fs.Bool("by-package", false, "also print token totals per package directory")
+	packageBudget := fs.Int("package-budget", 0, "report packages whose files total more than this many tokens (0 disables)")
+	maxExports := fs.Int("max-exports", 0, "report non-test files declaring more than this many exported symbols (0 disables)")
+	maxReceivers := fs.Int("max-receivers", 0, "report non-test files declaring methods on more than this many receiver types (0 disables)")
+	maxDataShare := fs.Float64("max-data-share", 0, "report files where string literals and constant tables of 1 KiB or more make up more than this fraction of the tokens, e.g. 0.5 (0 disables)")
+	agentBudget := fs.Int("agent-budget", 5000, "token budget for agent instruction files (CLAUDE.md, AGENTS.md, .cursorrules) found next to the scanned files (0 disables)")
+	var sinks sinkList
+	fs.Var(&sinks, "sink", "also deliver the JSON report to a plugin, e.g. exec:./upload-report to run a command with it on stdin (repeatable)")
+	otlp := fs.String("otlp-endpoint", "", "OTLP/HTTP collector URL for scan telemetry (default $OTEL_EXPORTER_OTLP_ENDPOINT)")
 
 	if err := fs.Parse(args); err != nil {
 		if err == flag.ErrHelp {
@@ -52,154 +270,613 @@ func run(args []string) int {
 		return 1
 	}
 
+	if *configPath == "" {
+		var err error
+		if *configPath, err = findConfig("."); err != nil {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+			return 1
+		}
+	}
+	var cfg *config
+	if *configPath != "" {
+		var err error
+		if cfg, err = loadConfig(*configPath); err != nil {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+			return 1
+		}
+		if err := cfg.apply(fs); err != nil {
+			fmt.Fprintf(stderr, "error: %s: %v\n", *configPath, err)
+			return 1
+		}
+	}
+
+	if *model != "" {
+		if err := applyModelPreset(fs, *model); err != nil {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+			return 1
+		}
+	}
+
+	if *ramp != "" {
+		r, err := parseRamp(*ramp)
+		if err != nil {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+			return 1
+		}
+		*threshold = r.at(time.Now())
+		fmt.Fprintf(stderr, "ramp: threshold %d today, %d from %s\n", *threshold, r.target, r.by.Format(time.DateOnly))
+	}
+
 	if *ratio <= 0 {
-		fmt.Fprintln(os.Stderr, "error: ratio must be positive")
+		fmt.Fprintln(stderr, "error: ratio must be positive")
 		return 1
 	}
 	if *threshold <= 0 {
-		fmt.Fprintln(os.Stderr, "error: threshold must be positive")
+		fmt.Fprintln(stderr, "error: threshold must be positive")
+		return 1
+	}
+	if *jobs <= 0 {
+		fmt.Fprintln(stderr, "error: -j must be positive")
+		return 1
+	}
+	if *watch && (*format != "text" || *ref != "" || *staged || *changedFrom != "" || *sample != "") {
+		fmt.Fprintln(stderr, "error: -watch needs -format text and cannot be combined with -ref, -staged, -changed-from or -sample")
+		return 1
+	}
+	if *changedFrom != "" && *ref != "" {
+		fmt.Fprintln(stderr, "error: -changed-from cannot be combined with -ref")
+		return 1
+	}
+	if *staged && *ref != "" {
+		fmt.Fprintln(stderr, "error: -staged cannot be combined with -ref")
+		return 1
+	}
+	if *totalBudget > 0 && *sample != "" {
+		fmt.Fprintln(stderr, "error: -total-budget cannot be combined with -sample")
+		return 1
+	}
+	if *writeBaselineFlag && *baselinePath == "" {
+		fmt.Fprintln(stderr, "error: -write-baseline requires -baseline")
+		return 1
+	}
+	if *fast {
+		if err := checkFast(fs, *tokenizer); err != nil {
+			fmt.Fprintf(stderr, "error: -fast: %v\n", err)
+			return 1
+		}
+		*agentBudget, *pkgDocBudget = 0, 0
+	}
+	tok, err := newTokenizer(*tokenizer, tokenizerConfig{
+		ratio:       *ratio,
+		encoding:    *encoding,
+		model:       *apiModel,
+		qps:         *apiQPS,
+		concurrency: *apiConcurrency,
+		budget:      *apiBudget,
+		batch:       *apiBatch,
+	})
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
 		return 1
 	}
+	switch *format {
+	case "text", "tree", "json", "jsonl", "sarif", "github", "junit":
+	default:
+		fmt.Fprintf(stderr, "error: unknown format %q\n", *format)
+		return 1
+	}
+
+	msg := newMessages(*lang)
+
+	var sampleFraction float64
+	if *sample != "" {
+		f, err := parseSample(*sample)
+		if err != nil {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+			return 1
+		}
+		sampleFraction = f
+	}
 
 	paths := fs.Args()
 	if len(paths) == 0 {
 		paths = []string{"./..."}
 	}
 
-	files, err := expandArgs(paths)
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	generated, err := newGeneratedRules(cfg, generatedFlags, *noBuiltinGenerated)
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %v\n", err)
+		return 1
+	}
+	expand := tokenlint.ExpandOptions{
+		IncludeHidden:    *includeHidden,
+		NoGitignore:      *noGitignore,
+		IncludeGenerated: *includeGenerated,
+		Generated:        generated,
+	}
+
+	start := time.Now()
+	var files []string
+	var read func(string) ([]byte, error)
+	if *fast {
+		files = paths
+	} else if *ref != "" {
+		var src refSource
+		files, src, err = refFiles(ctx, *ref, paths, expand)
+		read = src.read
+	} else if *staged {
+		var src refSource
+		files, src, err = indexFiles(ctx, paths, expand)
+		read = src.read
+	} else {
+		files, err = tokenlint.Expand(ctx, paths, expand)
+	}
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		if ctx.Err() != nil {
+			fmt.Fprintln(stderr, msg.f("canceledDiscovery", canceledReason(ctx, msg)))
+			return exitCanceled
+		}
+		fmt.Fprintf(stderr, "error: %v\n", err)
 		return 1
 	}
 
+	if *ignoreFile == "" {
+		if *ignoreFile, err = findUp(".", ignoreFileName); err != nil {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+			return 1
+		}
+	}
+	var ig *tokenlint.IgnoreRules
+	if *ignoreFile != "" {
+		if ig, err = tokenlint.LoadIgnoreFile(*ignoreFile); err != nil {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+			return 1
+		}
+	}
+	// pol drops the files excluded by the config or the ignore file.
+	pol := &policy{cfg: cfg, ignore: ig, generated: generated}
+	files = pol.filter(files)
+
+	if *changedFrom != "" {
+		changed, err := branchModifiedFiles(*changedFrom)
+		if err != nil {
+			fmt.Fprintf(stderr, "error: -changed-from: %v\n", err)
+			return 1
+		}
+		total := len(files)
+		kept := files[:0]
+		for _, path := range files {
+			if changed.has(path) {
+				kept = append(kept, path)
+			}
+		}
+		files = kept
+		fmt.Fprintln(stderr, msg.f("changedOnly", len(files), total, *changedFrom))
+	}
+
+	if sampleFraction > 0 {
+		seed := *sampleSeedFlag
+		if seed == "" {
+			seed = sampleSeed()
+		}
+		total := len(files)
+		files = sampleFiles(files, sampleFraction, seed)
+		fmt.Fprintln(stderr, msg.f("sampling", len(files), total, *sample, seed))
+	}
+
+	// Whatever happens from here on, finish with one stable line scripts
+	// can grep for.
+	var results, violations []fileResult
+	defer func() {
+		fmt.Fprintln(stderr, summaryTrailer(results, violations, *threshold))
+	}()
+
 	if len(files) == 0 {
-		fmt.Fprintln(os.Stderr, "no Go files found")
+		fmt.Fprintln(stderr, msg.f("noFiles"))
+		return 0
+	}
+
+	opts := analyzeOptions{
+		threshold:     *threshold,
+		ratio:         *ratio,
+		maxFileBytes:  *maxFileBytes,
+		ignoreImports: *ignoreImports,
+		jobs:          *jobs,
+		testThreshold: *testThreshold,
+		tokenizer:     tok,
+		read:          read,
+		stderr:        stderr,
+	}
+	opts.pathThreshold = pathThresholdFunc(cfg, rules)
+	if cfg != nil {
+		opts.categoryRatios = cfg.Ratios
+	}
+	if *strictNew > 0 {
+		newFiles, err := branchAddedFiles(*base)
+		if err != nil {
+			fmt.Fprintf(stderr, "error: -strict-new: %v\n", err)
+			return 1
+		}
+		opts.newFiles = newFiles
+		opts.newThreshold = *strictNew
+	}
+
+	// Violations are marked as they are found, so that streamed output
+	// can tell tolerated ones apart too.
+	var recorded *baseline
+	if *baselinePath != "" && !*writeBaselineFlag {
+		if recorded, err = loadBaseline(*baselinePath); err != nil {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+			return 1
+		}
+	}
+	if *frozenAfter > 0 || recorded != nil {
+		cutoff := time.Now().AddDate(0, -*frozenAfter, 0)
+		opts.tolerate = func(r *fileResult) {
+			if *frozenAfter > 0 {
+				markFrozenFile(r, cutoff)
+			}
+			if recorded != nil {
+				recorded.markFile(r)
+			}
+		}
+	}
+
+	var jsonl *jsonlWriter
+	if *format == "jsonl" {
+		jsonl = newJSONLWriter(stdout, *failFrozen)
+		opts.onResult = jsonl.file
+	}
+
+	results, violations = analyzeFiles(ctx, files, opts)
+	if err := tokenizerErr(tok); err != nil {
+		fmt.Fprintf(stderr, "error: -tokenizer %s: %v\n", *tokenizer, err)
+		return 1
+	}
+	if estimated, budget := tokenizerFallbacks(tok); estimated > 0 {
+		fmt.Fprintf(stderr, "warning: %s\n", msg.f("apiBudgetExhausted", budget, estimated))
+	}
+
+	var findings []finding
+	if *pkgDocBudget > 0 {
+		findings = append(findings, checkPackageDocs(files, *pkgDocBudget, opts.count, opts.readFile)...)
+	}
+	var packages []packageTotal
+	if *byPackage || *packageBudget > 0 {
+		packages = packageTotals(results)
+	}
+	if *packageBudget > 0 {
+		findings = append(findings, checkPackageBudget(packages, *packageBudget)...)
+	}
+	if *maxExports > 0 {
+		findings = append(findings, checkExportedSymbols(files, *maxExports, opts.readFile)...)
+	}
+	if *maxReceivers > 0 {
+		findings = append(findings, checkReceivers(files, *maxReceivers, opts.readFile)...)
+	}
+	if *maxDataShare > 0 {
+		findings = append(findings, checkDataLiterals(results, *maxDataShare, opts.readFile)...)
+	}
+	if *agentBudget > 0 {
+		findings = append(findings, checkAgentFiles(files, *agentBudget, opts.count, opts.readFile)...)
+	}
+
+	if jsonl != nil {
+		for _, f := range findings {
+			jsonl.finding(f)
+		}
+		failing := countFailing(violations, *failFrozen)
+		jsonl.summary(len(results), failing, len(violations)-failing)
+	}
+
+	if *writeBaselineFlag {
+		if err := writeBaseline(*baselinePath, violations); err != nil {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+			return 1
+		}
+		fmt.Fprintln(stderr, msg.f("baselineWritten", len(violations), *baselinePath))
 		return 0
 	}
 
-	results, violations := analyzeFiles(files, *threshold, *ratio)
+	if endpoint := otlpEndpoint(*otlp); endpoint != "" {
+		stats := scanStats{
+			start:      start,
+			end:        time.Now(),
+			files:      len(results),
+			violations: len(violations),
+			threshold:  *threshold,
+		}
+		if err := exportTelemetry(endpoint, stats); err != nil {
+			fmt.Fprintf(stderr, "warning: telemetry export failed: %v\n", err)
+		}
+	}
 
 	sort.Slice(results, func(i, j int) bool {
 		return results[i].tokens > results[j].tokens
 	})
 
-	if *showAll {
-		printAllResults(results, *threshold)
+	// Human-readable messages share stdout with the text report but must
+	// not corrupt machine-readable formats.
+	text := *format == "text"
+	human := stdout
+	if !text {
+		human = stderr
 	}
 
-	if len(violations) > 0 {
-		printViolations(violations, *threshold)
-		return 1
+	if *format == "json" {
+		if err := writeJSONReport(stdout, results, findings, *threshold, *failFrozen); err != nil {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+			return 1
+		}
+	}
+
+	if *format == "tree" {
+		writeTree(stdout, msg, buildTree(atLeast(results, *minTokens)))
+	}
+
+	if *format == "github" {
+		writeGitHubAnnotations(stdout, violations, findings, *failFrozen)
+	}
+
+	if *format == "junit" {
+		if err := writeJUnit(stdout, results, violations, findings, *failFrozen); err != nil {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+			return 1
+		}
+	}
+
+	if *format == "sarif" {
+		if err := writeSARIF(stdout, violations, findings, *failFrozen); err != nil {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+			return 1
+		}
+	}
+
+	if text && *showAll {
+		printAllResults(stdout, msg, atLeast(results, *minTokens))
+	}
+
+	if text && *byPackage {
+		printPackages(stdout, msg, packages, *packageBudget)
+	}
+
+	if text && len(violations) > 0 {
+		printViolations(stdout, msg, violations, *threshold)
+	}
+
+	if text {
+		printSuppressed(stdout, msg, results)
+	}
+
+	if ctx.Err() != nil {
+		fmt.Fprintln(stderr, msg.f("canceledAnalysis", canceledReason(ctx, msg), len(results), len(files)))
+		return exitCanceled
+	}
+
+	if text && len(findings) > 0 {
+		printFindings(stdout, msg, findings)
+	}
+
+	failed := countFailing(violations, *failFrozen) > 0 || len(findings) > 0
+
+	if len(sinks) > 0 {
+		var report bytes.Buffer
+		if err := writeJSONReport(&report, results, findings, *threshold, *failFrozen); err != nil {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+			return 1
+		}
+		if err := sinks.deliver(ctx, report.Bytes(), stderr); err != nil {
+			fmt.Fprintf(stderr, "error: %v\n", err)
+			failed = true
+		}
 	}
 
-	if !*showAll {
-		fmt.Printf("All %d files under %d token threshold\n", len(results), *threshold)
+	if *totalBudget > 0 {
+		total := 0
+		for _, r := range results {
+			total += r.tokens
+		}
+		if total > *totalBudget {
+			fmt.Fprint(human, msg.f("totalBudget", len(results), total, *totalBudget))
+			failed = true
+		}
+	}
+
+	if *prBudget > 0 {
+		// The PR total is reported on its own, not as files of the report.
+		prOpts := opts
+		prOpts.tolerate, prOpts.onResult = nil, nil
+		total, n, err := prTokens(ctx, *base, pol, *includeGenerated, prOpts)
+		if err != nil {
+			fmt.Fprintf(stderr, "error: -pr-budget: %v\n", err)
+			return 1
+		}
+		if total > *prBudget {
+			fmt.Fprint(human, msg.f("prBudget", *base, n, total, *prBudget))
+			fmt.Fprint(human, msg.f("prSplit"))
+			failed = true
+		}
+	}
+
+	if !failed && text && !*showAll && len(violations) == 0 {
+		fmt.Fprint(stdout, msg.f("allUnder", len(results), *threshold))
+	}
+
+	if *watch {
+		// Directories given as arguments are watched too, so files created
+		// where there were none at startup are seen.
+		var roots []string
+		for _, p := range paths {
+			if root, ok := tokenlint.SplitRecursive(p); ok {
+				roots = append(roots, root)
+			} else if info, err := os.Stat(p); err == nil && info.IsDir() {
+				roots = append(roots, p)
+			}
+		}
+		fmt.Fprintln(stderr, msg.f("watching", len(results)))
+		return watchFiles(ctx, stdout, msg, opts, results, roots, *includeHidden, func() ([]string, error) {
+			files, err := tokenlint.Expand(ctx, paths, expand)
+			return pol.filter(files), err
+		})
+	}
+	if failed {
+		return 1
 	}
 	return 0
 }
 
-func analyzeFiles(files []string, threshold int, ratio float64) ([]fileResult, []fileResult) {
+// summaryTrailer formats the machine-parsable line printed to stderr at the
+// end of every check run.
+func summaryTrailer(results, violations []fileResult, threshold int) string {
+	maxTokens := 0
+	for _, r := range results {
+		maxTokens = max(maxTokens, r.tokens)
+	}
+	return fmt.Sprintf("token-lint: files=%d violations=%d max=%d threshold=%d",
+		len(results), len(violations), maxTokens, threshold)
+}
+
+// canceledReason describes why ctx ended, for partial-result messages.
+func canceledReason(ctx context.Context, msg messages) string {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return msg.f("timedOut")
+	}
+	return msg.f("interrupted")
+}
+
+// analyzeFiles counts tokens for each file with tokenlint.Analyze and
+// adds the details shown for violations, which tolerate marks before
+// onResult sees them. Results and onResult calls follow the order of
+// files. If ctx is canceled it stops early and
+// returns the results gathered so far.
+func analyzeFiles(ctx context.Context, files []string, opts analyzeOptions) ([]fileResult, []fileResult) {
 	var results, violations []fileResult
 
-	for _, path := range files {
-		content, err := os.ReadFile(path)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
-			continue
+	// Details are worked out by the workers measuring each file, which
+	// have its content at hand, and picked up in order below.
+	var mu sync.Mutex
+	details := make(map[string]fileResult)
+	lo := opts.library()
+	lo.Inspect = func(f tokenlint.File, content []byte) {
+		if !f.Over() {
+			return
+		}
+		d := fileResult{
+			languages: classifyContent(content),
+			decls:     largestDecls(f.Path, content, f.Tokens, 5),
+			classes:   classifyTokens(content, f.Tokens),
+		}
+		mu.Lock()
+		details[f.Path] = d
+		mu.Unlock()
+	}
+	lo.OnFile = func(f tokenlint.File) {
+		mu.Lock()
+		r := details[f.Path]
+		mu.Unlock()
+		r.path, r.tokens, r.chars, r.threshold = f.Path, f.Tokens, f.Chars, f.Threshold
+		r.sha256, r.category, r.suppressed = f.SHA256, f.Category, f.Suppressed
+		if f.Over() && opts.tolerate != nil {
+			opts.tolerate(&r)
 		}
-
-		chars := len(content)
-		tokens := int(float64(chars) * ratio)
-		r := fileResult{path: path, tokens: tokens, chars: chars}
 		results = append(results, r)
-
-		if tokens > threshold {
+		if opts.onResult != nil {
+			opts.onResult(r)
+		}
+		if f.Over() {
 			violations = append(violations, r)
 		}
 	}
-
+	tokenlint.Analyze(ctx, files, lo)
 	return results, violations
 }
 
-func printAllResults(results []fileResult, threshold int) {
-	fmt.Printf("%-60s %8s %8s\n", "FILE", "TOKENS", "CHARS")
-	fmt.Println(strings.Repeat("-", 78))
+// atLeast returns the results with at least min tokens, for listings
+// that should skip tiny files. Totals and checks always use every result.
+func atLeast(results []fileResult, min int) []fileResult {
+	if min <= 0 {
+		return results
+	}
+	var kept []fileResult
 	for _, r := range results {
-		marker := ""
-		if r.tokens > threshold {
-			marker = " <- EXCEEDS LIMIT"
+		if r.tokens >= min {
+			kept = append(kept, r)
 		}
-		fmt.Printf("%-60s %8d %8d%s\n", r.path, r.tokens, r.chars, marker)
 	}
-	fmt.Println()
+	return kept
 }
 
-func printViolations(violations []fileResult, threshold int) {
-	fmt.Printf("%d file(s) exceed %d token threshold:\n\n", len(violations), threshold)
-	for _, v := range violations {
-		pct := float64(v.tokens) / float64(threshold) * 100
-		fmt.Printf("  %s\n", v.path)
-		fmt.Printf("    ~%d tokens (%.0f%% of limit, %d chars)\n", v.tokens, pct, v.chars)
-		fmt.Printf("    Consider splitting into smaller files for better LLM readability\n\n")
+func printAllResults(w io.Writer, msg messages, results []fileResult) {
+	fmt.Fprintf(w, "%-60s %8s %8s\n", "FILE", "TOKENS", "CHARS")
+	fmt.Fprintln(w, strings.Repeat("-", 78))
+	for _, r := range results {
+		marker := ""
+		if r.tokens > r.threshold && r.suppressed == "" {
+			marker = msg.f("exceeds")
+		}
+		fmt.Fprintf(w, "%-60s %8d %8d%s\n", quotePath(r.path), r.tokens, r.chars, marker)
 	}
+	fmt.Fprintln(w)
 }
 
-func expandArgs(args []string) ([]string, error) {
-	var files []string
-
-	for _, arg := range args {
-		if arg == "./..." {
-			// Recursively find all .go files
-			err := filepath.Walk(".", func(path string, info os.FileInfo, err error) error {
-				if err != nil {
-					return err
-				}
-				if !info.IsDir() && strings.HasSuffix(path, ".go") && !isGenerated(path) {
-					files = append(files, path)
-				}
-				return nil
-			})
-			if err != nil {
-				return nil, err
-			}
-		} else if dir, ok := strings.CutSuffix(arg, "/..."); ok {
-			// Recursively find .go files in directory
-			err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
-				if err != nil {
-					return err
-				}
-				if !info.IsDir() && strings.HasSuffix(path, ".go") && !isGenerated(path) {
-					files = append(files, path)
-				}
-				return nil
-			})
-			if err != nil {
-				return nil, err
-			}
-		} else if info, err := os.Stat(arg); err == nil && info.IsDir() {
-			// Find .go files in directory (non-recursive)
-			entries, err := os.ReadDir(arg)
-			if err != nil {
-				return nil, err
-			}
-			for _, e := range entries {
-				if !e.IsDir() && strings.HasSuffix(e.Name(), ".go") {
-					files = append(files, filepath.Join(arg, e.Name()))
-				}
+func printViolations(w io.Writer, msg messages, violations []fileResult, threshold int) {
+	fmt.Fprint(w, msg.f("violations", len(violations), threshold))
+	for _, v := range violations {
+		pct := float64(v.tokens) / float64(v.threshold) * 100
+		fmt.Fprintf(w, "  %s\n", quotePath(v.path))
+		if v.threshold != threshold {
+			fmt.Fprint(w, msg.f("fileThreshold", v.threshold))
+		}
+		fmt.Fprint(w, msg.f("detail", v.tokens, pct, v.chars))
+		if c := v.classes; c != nil {
+			fmt.Fprint(w, msg.f("classes", c.Code, c.Comments, c.Strings))
+		}
+		if v.frozen {
+			fmt.Fprint(w, msg.f("frozen", v.lastChange.Format("2006-01-02")))
+		}
+		switch {
+		case v.baselined:
+			fmt.Fprint(w, msg.f("baselined", v.baselineTokens))
+		case v.baselineTokens > 0:
+			fmt.Fprint(w, msg.f("baselineGrew", v.baselineTokens))
+		}
+		if lang := dominantEmbedded(v.languages); lang != "" {
+			fmt.Fprint(w, msg.f("content", describeShares(v.languages)))
+			fmt.Fprint(w, msg.f("embedded", lang, msg.f("remedy."+lang)))
+			continue
+		}
+		if len(v.decls) > 0 {
+			fmt.Fprint(w, msg.f("largestDecls"))
+			for _, d := range v.decls {
+				fmt.Fprint(w, msg.f("declEntry", d.name, d.line, d.tokens))
 			}
-		} else {
-			// Single file
-			files = append(files, arg)
 		}
+		fmt.Fprint(w, msg.f("split"))
 	}
-
-	return files, nil
 }
 
-// isGenerated returns true for paths that contain generated code
-func isGenerated(path string) bool {
-	return strings.Contains(path, "/gen/") ||
-		strings.Contains(path, "_gen.go") ||
-		strings.HasSuffix(path, ".pb.go") ||
-		strings.HasSuffix(path, ".sql.go")
+// printSuppressed lists files exempted by a //tokenlint:ignore directive,
+// so exceptions stay visible.
+func printSuppressed(w io.Writer, msg messages, results []fileResult) {
+	var suppressed []fileResult
+	for _, r := range results {
+		if r.suppressed != "" {
+			suppressed = append(suppressed, r)
+		}
+	}
+	if len(suppressed) == 0 {
+		return
+	}
+	fmt.Fprint(w, msg.f("suppressed", len(suppressed)))
+	for _, r := range suppressed {
+		fmt.Fprintf(w, "  %s\n", quotePath(r.path))
+		fmt.Fprint(w, msg.f("suppressedFile", r.tokens, r.suppressed))
+	}
+	fmt.Fprintln(w)
 }
@@ -1,35 +1,18 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
-)
 
-func TestIsGenerated(t *testing.T) {
-	tests := []struct {
-		path string
-		want bool
-	}{
-		{"foo.go", false},
-		{"pkg/handler.go", false},
-		{"internal/gen/types.go", true},
-		{"foo_gen.go", true},
-		{"api.pb.go", true},
-		{"queries.sql.go", true},
-		{"gen/foo.go", false}, // must have /gen/ not just gen/
-		{"/gen/foo.go", true},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.path, func(t *testing.T) {
-			got := isGenerated(tt.path)
-			if got != tt.want {
-				t.Errorf("isGenerated(%q) = %v, want %v", tt.path, got, tt.want)
-			}
-		})
-	}
-}
+	"github.com/befabri/token-lint/pkg/tokenlint"
+)
 
 func TestAnalyzeFiles(t *testing.T) {
 	dir := t.TempDir()
@@ -49,7 +32,7 @@ func TestAnalyzeFiles(t *testing.T) {
 	}
 
 	files := []string{smallFile, largeFile}
-	results, violations := analyzeFiles(files, 25000, 0.65)
+	results, violations := analyzeFiles(context.Background(), files, analyzeOptions{threshold: 25000, ratio: 0.65})
 
 	if len(results) != 2 {
 		t.Errorf("got %d results, want 2", len(results))
@@ -64,73 +47,196 @@ func TestAnalyzeFiles(t *testing.T) {
 	}
 }
 
-func TestExpandArgs(t *testing.T) {
+func TestAnalyzeFilesMaxBytes(t *testing.T) {
+	dir := t.TempDir()
+
+	smallFile := filepath.Join(dir, "small.go")
+	if err := os.WriteFile(smallFile, []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	hugeFile := filepath.Join(dir, "huge.go")
+	if err := os.WriteFile(hugeFile, make([]byte, 4096), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := analyzeOptions{threshold: 1, ratio: 0.65, maxFileBytes: 1024}
+	results, violations := analyzeFiles(context.Background(), []string{smallFile, hugeFile}, opts)
+
+	if len(results) != 1 || results[0].path != smallFile {
+		t.Errorf("got results %v, want only %s", results, smallFile)
+	}
+	if len(violations) != 1 || violations[0].path != smallFile {
+		t.Errorf("got violations %v, want only %s", violations, smallFile)
+	}
+}
+
+func TestAnalyzeFilesIgnoreImports(t *testing.T) {
 	dir := t.TempDir()
+	file := filepath.Join(dir, "a.go")
+	src := "package a\n\nimport (\n\t\"fmt\"\n\t\"os\"\n)\n\nvar _ = fmt.Sprint(os.Args)\n"
+	if err := os.WriteFile(file, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	results, _ := analyzeFiles(context.Background(), []string{file}, analyzeOptions{threshold: 25000, ratio: 1, ignoreImports: true})
+	if len(results) != 1 {
+		t.Fatalf("got %d results, want 1", len(results))
+	}
+	// Only the blank lines and the var declaration remain.
+	if want := len("\n\n\n\nvar _ = fmt.Sprint(os.Args)\n"); results[0].chars != want {
+		t.Errorf("chars = %d, want %d", results[0].chars, want)
+	}
+}
 
-	files := []string{"a.go", "b.go", "c.txt"}
-	for _, f := range files {
-		path := filepath.Join(dir, f)
-		if err := os.WriteFile(path, []byte("test"), 0644); err != nil {
+func TestAnalyzeFilesStrictNew(t *testing.T) {
+	dir := t.TempDir()
+	oldFile := filepath.Join(dir, "old.go")
+	newFile := filepath.Join(dir, "new.go")
+	for _, f := range []string{oldFile, newFile} {
+		if err := os.WriteFile(f, make([]byte, 100), 0644); err != nil {
 			t.Fatal(err)
 		}
 	}
 
-	subdir := filepath.Join(dir, "sub")
-	if err := os.Mkdir(subdir, 0755); err != nil {
-		t.Fatal(err)
+	opts := analyzeOptions{
+		threshold:    150,
+		ratio:        1,
+		newFiles:     gitFileSet{root: dir, files: map[string]bool{"new.go": true}},
+		newThreshold: 50,
 	}
-	if err := os.WriteFile(filepath.Join(subdir, "d.go"), []byte("test"), 0644); err != nil {
-		t.Fatal(err)
+	results, violations := analyzeFiles(context.Background(), []string{oldFile, newFile}, opts)
+
+	if results[0].threshold != 150 || results[1].threshold != 50 {
+		t.Errorf("thresholds = %d, %d, want 150, 50", results[0].threshold, results[1].threshold)
+	}
+	if len(violations) != 1 || violations[0].path != newFile {
+		t.Errorf("violations = %v, want only %s", violations, newFile)
 	}
+}
 
-	t.Run("single file", func(t *testing.T) {
-		got, err := expandArgs([]string{filepath.Join(dir, "a.go")})
-		if err != nil {
+func TestAnalyzeFilesSuppressed(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"ignored.go":   "//tokenlint:ignore vendored spec tables\npackage a\n",
+		"noreason.go":  "//tokenlint:ignore\npackage a\n",
+		"violating.go": "package a\n",
+	}
+	var paths []string
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
 			t.Fatal(err)
 		}
-		if len(got) != 1 {
-			t.Errorf("got %d files, want 1", len(got))
+		paths = append(paths, path)
+	}
+
+	var stderr strings.Builder
+	results, violations := analyzeFiles(context.Background(), paths, analyzeOptions{threshold: 1, ratio: 1, stderr: &stderr})
+	if len(results) != 3 || len(violations) != 2 {
+		t.Fatalf("got %d results, %d violations; want 3, 2", len(results), len(violations))
+	}
+	for _, v := range violations {
+		if filepath.Base(v.path) == "ignored.go" {
+			t.Error("suppressed file reported as a violation")
 		}
-	})
+	}
+	if !strings.Contains(stderr.String(), "needs a reason") {
+		t.Errorf("stderr = %q, want a warning about the missing reason", stderr.String())
+	}
+
+	var out strings.Builder
+	printSuppressed(&out, newMessages("en"), results)
+	if !strings.Contains(out.String(), "ignored.go\n    ~50 tokens, reason: vendored spec tables") {
+		t.Errorf("suppressed section:\n%s", out.String())
+	}
+}
 
-	t.Run("directory non-recursive", func(t *testing.T) {
-		got, err := expandArgs([]string{dir})
-		if err != nil {
+func TestAnalyzeFilesOrder(t *testing.T) {
+	dir := t.TempDir()
+	var paths []string
+	for i := range 50 {
+		path := filepath.Join(dir, fmt.Sprintf("f%02d.go", i))
+		content := "package a\n" + strings.Repeat("// padding\n", 50-i)
+		if i%10 == 0 {
+			content = "//tokenlint:ignore\n" + content // warns
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
 			t.Fatal(err)
 		}
-		if len(got) != 2 {
-			t.Errorf("got %d files, want 2 (.go files only)", len(got))
+		paths = append(paths, path)
+		if i == 25 {
+			paths = append(paths, filepath.Join(dir, "missing.go"))
 		}
-	})
+	}
 
-	t.Run("directory recursive", func(t *testing.T) {
-		got, err := expandArgs([]string{dir + "/..."})
-		if err != nil {
-			t.Fatal(err)
+	var want []string
+	var wantStderr string
+	for _, jobs := range []int{1, 8} {
+		var stderr strings.Builder
+		var streamed []string
+		opts := analyzeOptions{threshold: 100, ratio: 1, jobs: jobs, stderr: &stderr,
+			onResult: func(r fileResult) { streamed = append(streamed, r.path) }}
+		results, _ := analyzeFiles(context.Background(), paths, opts)
+		var got []string
+		for _, r := range results {
+			got = append(got, r.path)
 		}
-		if len(got) != 3 {
-			t.Errorf("got %d files, want 3", len(got))
+		if !slices.Equal(got, streamed) {
+			t.Errorf("jobs %d: onResult order %v differs from results %v", jobs, streamed, got)
 		}
-	})
+		if jobs == 1 {
+			want, wantStderr = got, stderr.String()
+			if len(want) != 50 || strings.Count(wantStderr, "warning:") != 6 {
+				t.Fatalf("jobs 1: got %d results and stderr %q, want 50 results and 6 warnings", len(want), wantStderr)
+			}
+			continue
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("jobs %d: results %v, want %v", jobs, got, want)
+		}
+		if stderr.String() != wantStderr {
+			t.Errorf("jobs %d: stderr %q, want %q", jobs, stderr.String(), wantStderr)
+		}
+	}
+}
+
+func TestAnalyzeFilesBPE(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "a.go")
+	if err := os.WriteFile(file, []byte("hello world"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tok, err := newTokenizer("bpe", tokenizerConfig{encoding: "cl100k_base"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results, _ := analyzeFiles(context.Background(), []string{file}, analyzeOptions{threshold: 25000, ratio: 0.65, tokenizer: tok})
+	if len(results) != 1 || results[0].tokens != 2 {
+		t.Errorf("results = %v, want one file with 2 tokens", results)
+	}
+	// sha256("hello world")
+	if want := "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9"; results[0].sha256 != want {
+		t.Errorf("sha256 = %s, want %s", results[0].sha256, want)
+	}
 }
 
 func TestRunValidation(t *testing.T) {
 	t.Run("negative ratio", func(t *testing.T) {
-		code := run([]string{"-ratio", "-1", "."})
+		code := run([]string{"-ratio", "-1", "."}, io.Discard, io.Discard)
 		if code != 1 {
 			t.Errorf("expected exit code 1 for negative ratio, got %d", code)
 		}
 	})
 
 	t.Run("zero threshold", func(t *testing.T) {
-		code := run([]string{"-threshold", "0", "."})
+		code := run([]string{"-threshold", "0", "."}, io.Discard, io.Discard)
 		if code != 1 {
 			t.Errorf("expected exit code 1 for zero threshold, got %d", code)
 		}
 	})
 
 	t.Run("help flag", func(t *testing.T) {
-		code := run([]string{"-h"})
+		code := run([]string{"-h"}, io.Discard, io.Discard)
 		if code != 0 {
 			t.Errorf("expected exit code 0 for help, got %d", code)
 		}
@@ -146,16 +252,95 @@ func TestRunWithFiles(t *testing.T) {
 	}
 
 	t.Run("files under threshold", func(t *testing.T) {
-		code := run([]string{smallFile})
+		code := run([]string{smallFile}, io.Discard, io.Discard)
 		if code != 0 {
 			t.Errorf("expected exit code 0, got %d", code)
 		}
 	})
 
 	t.Run("files over threshold", func(t *testing.T) {
-		code := run([]string{"-threshold", "1", smallFile})
+		code := run([]string{"-threshold", "1", smallFile}, io.Discard, io.Discard)
 		if code != 1 {
 			t.Errorf("expected exit code 1 for violation, got %d", code)
 		}
 	})
+
+	t.Run("total budget", func(t *testing.T) {
+		if code := run([]string{"-total-budget", "1000", smallFile}, io.Discard, io.Discard); code != 0 {
+			t.Errorf("expected exit code 0 under the total budget, got %d", code)
+		}
+		var out strings.Builder
+		if code := run([]string{"-total-budget", "2", smallFile}, &out, io.Discard); code != 1 {
+			t.Errorf("expected exit code 1 over the total budget, got %d", code)
+		}
+		if !strings.Contains(out.String(), "over the 2 token total budget") {
+			t.Errorf("output does not explain the failure:\n%s", out.String())
+		}
+	})
+}
+
+func TestCancellation(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "a.go")
+	if err := os.WriteFile(file, []byte("package a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	t.Run("discovery", func(t *testing.T) {
+		if _, err := tokenlint.Expand(ctx, []string{dir + "/..."}, tokenlint.ExpandOptions{}); !errors.Is(err, context.Canceled) {
+			t.Errorf("Expand error = %v, want context.Canceled", err)
+		}
+	})
+
+	t.Run("analysis", func(t *testing.T) {
+		results, _ := analyzeFiles(ctx, []string{file}, analyzeOptions{threshold: 25000, ratio: 0.65})
+		if len(results) != 0 {
+			t.Errorf("got %d results after cancellation, want 0", len(results))
+		}
+	})
+
+	t.Run("timeout exit code", func(t *testing.T) {
+		code := run([]string{"-timeout", "1ns", file}, io.Discard, io.Discard)
+		if code != exitCanceled {
+			t.Errorf("expected exit code %d on timeout, got %d", exitCanceled, code)
+		}
+	})
+}
+
+func TestAnalyzeFilesCategoryRatios(t *testing.T) {
+	dir := t.TempDir()
+	code := filepath.Join(dir, "a.go")
+	test := filepath.Join(dir, "a_test.go")
+	for _, p := range []string{code, test} {
+		if err := os.WriteFile(p, []byte(strings.Repeat("x", 100)), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	opts := analyzeOptions{
+		threshold:      1000,
+		ratio:          0.5,
+		tokenizer:      tokenlint.RatioTokenizer(0.5),
+		categoryRatios: map[string]float64{"test": 0.2},
+	}
+	results, _ := analyzeFiles(context.Background(), []string{code, test}, opts)
+	if len(results) != 2 || results[0].tokens != 50 || results[1].tokens != 20 {
+		t.Errorf("results = %+v, want 50 tokens for a.go and 20 for a_test.go", results)
+	}
+	if results[1].category != "test" {
+		t.Errorf("category = %q, want test", results[1].category)
+	}
+}
+
+func TestAtLeast(t *testing.T) {
+	results := []fileResult{{path: "big.go", tokens: 900}, {path: "tiny.go", tokens: 12}, {path: "edge.go", tokens: 100}}
+	got := atLeast(results, 100)
+	if len(got) != 2 || got[0].path != "big.go" || got[1].path != "edge.go" {
+		t.Errorf("atLeast(100) = %v, want big.go and edge.go", got)
+	}
+	if got := atLeast(results, 0); len(got) != 3 {
+		t.Errorf("atLeast(0) kept %d results, want all 3", len(got))
+	}
 }
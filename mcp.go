@@ -0,0 +1,349 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/befabri/token-lint/pkg/tokenlint"
+)
+
+// mcpProtocolVersion is the Model Context Protocol revision served.
+const mcpProtocolVersion = "2025-06-18"
+
+// mcpInvalidParams is the JSON-RPC error code for bad tool calls.
+const mcpInvalidParams = -32602
+
+// mcpTool describes one tool in a tools/list reply.
+type mcpTool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	InputSchema map[string]any `json:"inputSchema"`
+}
+
+// mcpPathSchema is the input schema of tools that take one file.
+var mcpPathSchema = map[string]any{
+	"type": "object",
+	"properties": map[string]any{
+		"path": map[string]any{"type": "string", "description": "Go file, absolute or relative to the server's working directory"},
+	},
+	"required": []string{"path"},
+}
+
+var mcpTools = []mcpTool{
+	{
+		Name:        "count_tokens",
+		Description: "Count the tokens of a Go file as it is on disk and report the threshold that applies to it.",
+		InputSchema: mcpPathSchema,
+	},
+	{
+		Name:        "list_violations",
+		Description: "Scan the repository and list the Go files over their token threshold, largest first.",
+		InputSchema: map[string]any{"type": "object", "properties": map[string]any{}},
+	},
+	{
+		Name:        "suggest_split",
+		Description: "Propose how to split a Go file into smaller files whose declarations belong together.",
+		InputSchema: mcpPathSchema,
+	},
+}
+
+// mcpServer answers Model Context Protocol tool calls about token budgets
+// on stdin and stdout, for coding agents.
+type mcpServer struct {
+	in      *bufio.Reader
+	out     io.Writer
+	opts    analyzeOptions
+	root    string   // the working tree; tools read no files outside it
+	paths   []string // scanned by list_violations
+	expand  tokenlint.ExpandOptions
+	inScope func(path string) bool
+}
+
+// runMCP implements `token-lint mcp`, which serves token-lint's checks as
+// MCP tools.
+func runMCP(args []string) int {
+	fs := flag.NewFlagSet("token-lint mcp", flag.ContinueOnError)
+	flags := addServerFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 1
+	}
+	// list_violations recounts the whole repository on every call, too
+	// often for a remote API.
+	pol, opts, err := flags.load(fs, "mcp")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	root, err := repoRoot()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{"./..."}
+	}
+	s := &mcpServer{
+		in:      bufio.NewReader(os.Stdin),
+		out:     os.Stdout,
+		opts:    opts,
+		root:    root,
+		paths:   paths,
+		expand:  pol.expandOptions(),
+		inScope: pol.inScope,
+	}
+	return s.serve(context.Background())
+}
+
+// serve handles newline-delimited JSON-RPC messages until the client
+// closes stdin.
+func (s *mcpServer) serve(ctx context.Context) int {
+	for {
+		line, err := s.in.ReadBytes('\n')
+		if len(strings.TrimSpace(string(line))) > 0 {
+			var m lspMessage
+			if err := json.Unmarshal(line, &m); err != nil {
+				s.replyError(nil, lspParseError, err.Error())
+			} else {
+				s.handle(ctx, m)
+			}
+		}
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				fmt.Fprintf(os.Stderr, "error: %v\n", err)
+				return 1
+			}
+			return 0
+		}
+	}
+}
+
+func (s *mcpServer) handle(ctx context.Context, m lspMessage) {
+	switch m.Method {
+	case "initialize":
+		s.reply(m.ID, map[string]any{
+			"protocolVersion": mcpProtocolVersion,
+			"capabilities":    map[string]any{"tools": map[string]any{}},
+			"serverInfo":      map[string]any{"name": "token-lint", "version": currentBuildInfo().version},
+			"instructions": fmt.Sprintf("Go files should stay under %d tokens so they fit in an LLM context window. "+
+				"Check files you grow with count_tokens, and use suggest_split before splitting one that is over.", s.opts.threshold),
+		})
+	case "ping":
+		s.reply(m.ID, map[string]any{})
+	case "tools/list":
+		s.reply(m.ID, map[string]any{"tools": mcpTools})
+	case "tools/call":
+		var p struct {
+			Name      string `json:"name"`
+			Arguments struct {
+				Path string `json:"path"`
+			} `json:"arguments"`
+		}
+		if err := json.Unmarshal(m.Params, &p); err != nil {
+			s.replyError(m.ID, mcpInvalidParams, err.Error())
+			return
+		}
+		var result any
+		var err error
+		switch p.Name {
+		case "count_tokens":
+			result, err = s.countTokens(ctx, p.Arguments.Path)
+		case "list_violations":
+			result, err = s.listViolations(ctx)
+		case "suggest_split":
+			result, err = s.suggestSplit(p.Arguments.Path)
+		default:
+			s.replyError(m.ID, mcpInvalidParams, "unknown tool: "+p.Name)
+			return
+		}
+		s.reply(m.ID, mcpToolResult(result, err))
+	default:
+		// Requests need an answer; notifications such as
+		// notifications/initialized may be ignored.
+		if len(m.ID) > 0 {
+			s.replyError(m.ID, lspMethodNotFound, "method not supported: "+m.Method)
+		}
+	}
+}
+
+// mcpToolResult wraps the outcome of a tool call. Failures are reported
+// to the agent as tool errors rather than protocol errors, so it can
+// correct the call.
+func mcpToolResult(result any, err error) map[string]any {
+	if err != nil {
+		return map[string]any{
+			"content": []map[string]any{{"type": "text", "text": err.Error()}},
+			"isError": true,
+		}
+	}
+	text, err := json.Marshal(result)
+	if err != nil {
+		return mcpToolResult(nil, err)
+	}
+	return map[string]any{
+		"content":           []map[string]any{{"type": "text", "text": string(text)}},
+		"structuredContent": result,
+	}
+}
+
+// mcpFile is a file in tool results.
+type mcpFile struct {
+	Path       string `json:"path"`
+	Tokens     int    `json:"tokens"`
+	Chars      int    `json:"chars"`
+	Threshold  int    `json:"threshold"`
+	Over       bool   `json:"over"`
+	Suppressed string `json:"suppressed,omitempty"`
+}
+
+func newMCPFile(f tokenlint.File) mcpFile {
+	return mcpFile{
+		Path:       f.Path,
+		Tokens:     f.Tokens,
+		Chars:      f.Chars,
+		Threshold:  f.Threshold,
+		Over:       f.Over(),
+		Suppressed: f.Suppressed,
+	}
+}
+
+// checkPath rejects empty paths and paths outside the working tree, so
+// agents cannot read arbitrary files through the tools.
+func (s *mcpServer) checkPath(path string) error {
+	if path == "" {
+		return errors.New("path is required")
+	}
+	rel, err := relToRoot(s.root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+		return fmt.Errorf("%s is outside the working tree %s", path, s.root)
+	}
+	return nil
+}
+
+func (s *mcpServer) countTokens(ctx context.Context, path string) (mcpFile, error) {
+	if err := s.checkPath(path); err != nil {
+		return mcpFile{}, err
+	}
+	var warnings []string
+	lo := s.opts.library()
+	lo.Warnf = func(format string, args ...any) {
+		warnings = append(warnings, fmt.Sprintf(format, args...))
+	}
+	report := tokenlint.Analyze(ctx, []string{path}, lo)
+	if len(report.Files) == 0 {
+		return mcpFile{}, errors.New(strings.Join(warnings, "; "))
+	}
+	return newMCPFile(report.Files[0]), nil
+}
+
+// mcpViolations is the result of list_violations.
+type mcpViolations struct {
+	Files      int       `json:"files"`
+	Threshold  int       `json:"threshold"`
+	Violations []mcpFile `json:"violations"`
+}
+
+func (s *mcpServer) listViolations(ctx context.Context) (mcpViolations, error) {
+	files, err := tokenlint.Expand(ctx, s.paths, s.expand)
+	if err != nil {
+		return mcpViolations{}, err
+	}
+	kept := files[:0]
+	for _, path := range files {
+		if s.inScope(path) {
+			kept = append(kept, path)
+		}
+	}
+	report := tokenlint.Analyze(ctx, kept, s.opts.library())
+	v := mcpViolations{Files: len(report.Files), Threshold: s.opts.threshold, Violations: []mcpFile{}}
+	for _, f := range report.Violations {
+		v.Violations = append(v.Violations, newMCPFile(f))
+	}
+	sort.SliceStable(v.Violations, func(i, j int) bool {
+		return v.Violations[i].Tokens > v.Violations[j].Tokens
+	})
+	return v, ctx.Err()
+}
+
+// mcpSplit is the result of suggest_split.
+type mcpSplit struct {
+	Path   string         `json:"path"`
+	Tokens int            `json:"tokens"`
+	Target int            `json:"target"` // aimed-for tokens per new file
+	Files  []mcpSplitFile `json:"files"`
+}
+
+type mcpSplitFile struct {
+	Name         string   `json:"name"`
+	Tokens       int      `json:"tokens"`
+	Declarations []string `json:"declarations"`
+}
+
+func (s *mcpServer) suggestSplit(path string) (mcpSplit, error) {
+	if err := s.checkPath(path); err != nil {
+		return mcpSplit{}, err
+	}
+	src, err := s.opts.readFile(path)
+	if err != nil {
+		return mcpSplit{}, err
+	}
+	// As in token-lint suggest, leave the new files room to grow. The plan
+	// sizes declarations by their bytes, at the ratio that makes the file
+	// add up to the configured tokenizer's count.
+	target := s.opts.threshold / 2
+	ratio := s.opts.ratio
+	if len(src) > 0 {
+		ratio = float64(s.opts.count(src)) / float64(len(src))
+	}
+	plan, err := planSplit(path, src, ratio, target)
+	if err != nil {
+		return mcpSplit{}, err
+	}
+	split := mcpSplit{Path: path, Tokens: plan.tokens, Target: target}
+	for _, f := range plan.files {
+		sf := mcpSplitFile{Name: f.name, Tokens: f.tokens}
+		for _, i := range f.decls {
+			sf.Declarations = append(sf.Declarations, plan.decls[i].name)
+		}
+		split.Files = append(split.Files, sf)
+	}
+	return split, nil
+}
+
+func (s *mcpServer) reply(id json.RawMessage, result any) {
+	s.send(map[string]any{"jsonrpc": "2.0", "id": id, "result": result})
+}
+
+func (s *mcpServer) replyError(id json.RawMessage, code int, message string) {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	s.send(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      id,
+		"error":   map[string]any{"code": code, "message": message},
+	})
+}
+
+// send writes v as one line of JSON, which cannot contain raw newlines.
+func (s *mcpServer) send(v any) {
+	body, err := json.Marshal(v)
+	if err == nil {
+		_, err = s.out.Write(append(body, '\n'))
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+	}
+}
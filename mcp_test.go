@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/befabri/token-lint/pkg/tokenlint"
+)
+
+func TestMCPSession(t *testing.T) {
+	dir := t.TempDir()
+	big := filepath.Join(dir, "big.go")
+	small := filepath.Join(dir, "small.go")
+	src := "package a\n\n// T is a type.\ntype T struct{ n int }\n\nfunc (t T) N() int { return t.n }\n\n" +
+		"// Helper does nothing much.\nfunc Helper() {\n\t// " + strings.Repeat("x", 300) + "\n}\n"
+	// Files outside the working tree are off limits, also through links.
+	outside := filepath.Join(t.TempDir(), "secret.go")
+	for path, content := range map[string]string{big: src, small: "package a\n", outside: "package secret\n"} {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	link := filepath.Join(dir, "link.go")
+	if err := os.Symlink(outside, link); err != nil {
+		t.Fatal(err)
+	}
+
+	var in bytes.Buffer
+	for _, m := range []string{
+		`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-06-18","capabilities":{},"clientInfo":{"name":"test","version":"1"}}}`,
+		`{"jsonrpc":"2.0","method":"notifications/initialized"}`,
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"count_tokens","arguments":{"path":` + jsonQuote(small) + `}}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"list_violations","arguments":{}}}`,
+		`{"jsonrpc":"2.0","id":5,"method":"tools/call","params":{"name":"suggest_split","arguments":{"path":` + jsonQuote(big) + `}}}`,
+		`{"jsonrpc":"2.0","id":6,"method":"tools/call","params":{"name":"count_tokens","arguments":{"path":` + jsonQuote(filepath.Join(dir, "missing.go")) + `}}}`,
+		`{"jsonrpc":"2.0","id":7,"method":"tools/call","params":{"name":"nope","arguments":{}}}`,
+		`{"jsonrpc":"2.0","id":8,"method":"resources/list"}`,
+		`{"jsonrpc":"2.0","id":9,"method":"ping"}`,
+		`{"jsonrpc":"2.0","id":10,"method":"tools/call","params":{"name":"count_tokens","arguments":{"path":` + jsonQuote(outside) + `}}}`,
+		`{"jsonrpc":"2.0","id":11,"method":"tools/call","params":{"name":"suggest_split","arguments":{"path":` + jsonQuote(link) + `}}}`,
+	} {
+		in.WriteString(m + "\n")
+	}
+	var out bytes.Buffer
+	s := &mcpServer{
+		in:      bufio.NewReader(&in),
+		out:     &out,
+		opts:    analyzeOptions{threshold: 200, ratio: 1},
+		root:    dir,
+		paths:   []string{dir + "/..."},
+		inScope: func(path string) bool { return path != link },
+	}
+	if code := s.serve(context.Background()); code != 0 {
+		t.Errorf("serve = %d, want 0 at end of input", code)
+	}
+
+	type reply struct {
+		ID     int `json:"id"`
+		Result struct {
+			ProtocolVersion   string          `json:"protocolVersion"`
+			Tools             []mcpTool       `json:"tools"`
+			IsError           bool            `json:"isError"`
+			StructuredContent json.RawMessage `json:"structuredContent"`
+			Content           []struct {
+				Type string `json:"type"`
+				Text string `json:"text"`
+			} `json:"content"`
+		} `json:"result"`
+		Error *struct {
+			Code int `json:"code"`
+		} `json:"error"`
+	}
+	var replies []reply
+	sc := bufio.NewScanner(&out)
+	for sc.Scan() {
+		var r reply
+		if err := json.Unmarshal(sc.Bytes(), &r); err != nil {
+			t.Fatalf("reply %q: %v", sc.Text(), err)
+		}
+		replies = append(replies, r)
+	}
+	if len(replies) != 11 {
+		t.Fatalf("got %d replies, want 11 (none for the notification)", len(replies))
+	}
+	if replies[0].ID != 1 || replies[0].Result.ProtocolVersion != mcpProtocolVersion {
+		t.Errorf("initialize reply = %+v", replies[0])
+	}
+	if n := len(replies[1].Result.Tools); n != 3 {
+		t.Errorf("tools/list returned %d tools, want 3", n)
+	}
+
+	var count mcpFile
+	if err := json.Unmarshal(replies[2].Result.StructuredContent, &count); err != nil {
+		t.Fatal(err)
+	}
+	if count.Tokens != len("package a\n") || count.Threshold != 200 || count.Over {
+		t.Errorf("count_tokens = %+v", count)
+	}
+	if text := replies[2].Result.Content[0].Text; text != string(replies[2].Result.StructuredContent) {
+		t.Errorf("text content %q does not mirror the structured content", text)
+	}
+
+	var list mcpViolations
+	if err := json.Unmarshal(replies[3].Result.StructuredContent, &list); err != nil {
+		t.Fatal(err)
+	}
+	if list.Files != 2 || len(list.Violations) != 1 || list.Violations[0].Path != big {
+		t.Errorf("list_violations = %+v, want big.go only", list)
+	}
+
+	var split mcpSplit
+	if err := json.Unmarshal(replies[4].Result.StructuredContent, &split); err != nil {
+		t.Fatal(err)
+	}
+	if len(split.Files) < 2 || split.Files[0].Name != big {
+		t.Errorf("suggest_split = %+v, want several files, the first keeping the name", split)
+	}
+
+	if !replies[5].Result.IsError || !strings.Contains(replies[5].Result.Content[0].Text, "missing.go") {
+		t.Errorf("count_tokens on a missing file = %+v, want a tool error", replies[5].Result)
+	}
+	if replies[6].Error == nil || replies[6].Error.Code != mcpInvalidParams {
+		t.Errorf("unknown tool reply = %+v, want invalid params", replies[6])
+	}
+	if replies[7].Error == nil || replies[7].Error.Code != lspMethodNotFound {
+		t.Errorf("resources/list reply = %+v, want method not found", replies[7])
+	}
+	if replies[8].ID != 9 || replies[8].Error != nil {
+		t.Errorf("ping reply = %+v", replies[8])
+	}
+	for _, r := range replies[9:] {
+		if !r.Result.IsError || !strings.Contains(r.Result.Content[0].Text, "outside the working tree") {
+			t.Errorf("reply %d = %+v, want a tool error for a path outside the working tree", r.ID, r.Result)
+		}
+	}
+}
+
+func TestMCPSuggestSplitTokenizer(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.go")
+	src := "package a\n\nfunc A() {\n\t// " + strings.Repeat("x", 400) + "\n}\n\nfunc B() {\n\t// " + strings.Repeat("y", 400) + "\n}\n"
+	if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// The ratio flag says 1, but the configured tokenizer counts half.
+	s := &mcpServer{
+		opts: analyzeOptions{threshold: 400, ratio: 1, tokenizer: tokenlint.RatioTokenizer(0.5)},
+		root: dir,
+	}
+	split, err := s.suggestSplit(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := len(src) / 2; split.Tokens < want-1 || split.Tokens > want {
+		t.Errorf("suggest_split tokens = %d, want %d from the tokenizer", split.Tokens, want)
+	}
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// catalog holds the human-facing messages of the check report by language.
+// Machine-readable formats are never translated. Translations must keep
+// the verbs of the English message in the same order.
+var catalog = map[string]map[string]string{
+	"en": {
+		"violations":         "%d file(s) exceed %d token threshold:\n\n",
+		"detail":             "    ~%d tokens (%.0f%% of limit, %d chars)\n",
+		"classes":            "    ~%d in code, ~%d in comments, ~%d in string literals\n",
+		"fileThreshold":      "    File threshold: %d\n",
+		"frozen":             "    Frozen: unchanged since %s\n",
+		"baselined":          "    Baseline: recorded at ~%d tokens, not failing\n",
+		"baselineGrew":       "    Baseline: grew from ~%d tokens\n",
+		"baselineWritten":    "recorded %d violation(s) in %s",
+		"apiBudgetExhausted": "API request budget of %d exhausted; %d file(s) estimated with -ratio instead",
+		"suppressed":         "%d file(s) exempted with //tokenlint:ignore:\n\n",
+		"suppressedFile":     "    ~%d tokens, reason: %s\n",
+		"content":            "    Content: %s\n",
+		"embedded":           "    Mostly embedded %s: %s\n\n",
+		"largestDecls":       "    Largest declarations:\n",
+		"declEntry":          "      %s, line %d: ~%d tokens\n",
+		"split":              "    Consider splitting into smaller files for better LLM readability\n\n",
+		"exceeds":            " <- EXCEEDS LIMIT",
+		"allUnder":           "All %d files under %d token threshold\n",
+		"noFiles":            "no Go files found",
+		"sampling":           "sampling %d of %d files (%s, seed %s)",
+		"changedOnly":        "checking %d of %d files, those changed since %s",
+		"prBudget":           "Changes since %s touch %d Go file(s) totalling ~%d tokens, over the %d token PR budget\n",
+		"prSplit":            "    Consider splitting the change into smaller pull requests\n\n",
+		"totalBudget":        "The %d analyzed Go file(s) total ~%d tokens, over the %d token total budget\n\n",
+		"interrupted":        "interrupted",
+		"timedOut":           "timed out",
+		"canceledDiscovery":  "%s during file discovery",
+		"canceledAnalysis":   "%s after analyzing %d of %d files",
+		"watching":           "watching %d file(s) for changes; press Ctrl-C to stop",
+		"watchChanged":       "%s  %s: ~%d tokens (%+d)%s\n",
+		"watchAdded":         "%s  %s: ~%d tokens (new file)%s\n",
+		"watchRemoved":       "%s  %s: removed\n",
+		"watchOver":          ", over the %d token limit",
+		"watchCrossed":       ", now over the %d token limit",
+		"watchBack":          ", back under the %d token limit",
+		"remedy.html":        "move templates to .html files loaded with go:embed",
+		"remedy.sql":         "move queries to .sql files (loaded with go:embed or generated with sqlc)",
+		"remedy.json":        "move data to .json files under testdata or loaded with go:embed",
+		"remedy.shell":       "move //go:generate pipelines into a script and call that instead",
+		"remedy.text":        "move long text blobs to separate files loaded with go:embed",
+		"remedy.comments":    "trim long comments or move prose into doc.go or a README",
+		"findings":           "%d rule finding(s):\n\n",
+		"sarifViolation":     "~%d tokens, over the %d token threshold (%d chars)",
+		"agentFileOver":      "%s is ~%d tokens, over the %d token budget for agent instructions; every agent session pays for it",
+		"pkgDocMissing":      "package %s has no package comment; add one in doc.go",
+		"pkgDocOver":         "package %s comment is ~%d tokens, over the %d token budget; keep it a summary",
+		"exportsOver":        "declares %d exported symbols, over the limit of %d; split the public API across files",
+		"receiversOver":      "declares methods on %d receiver types (%s), over the limit of %d; give each type its own file",
+		"dataLiterals":       "%.0f%% of its ~%d tokens are data literals (largest ~%d tokens at line %d); move the data to files loaded with go:embed",
+		"packageOver":        "package is ~%d tokens across %d files, over the %d token package budget; split it into subpackages",
+	},
+	"ja": {
+		"violations":         "%d 個のファイルがトークンしきい値 %d を超えています:\n\n",
+		"detail":             "    約 %d トークン (上限の %.0f%%、%d 文字)\n",
+		"classes":            "    コード約 %d、コメント約 %d、文字列リテラル約 %d\n",
+		"fileThreshold":      "    ファイル固有のしきい値: %d\n",
+		"frozen":             "    凍結: %s 以降変更なし\n",
+		"baselined":          "    ベースライン: 約 %d トークンで記録済み、失敗扱いにしません\n",
+		"baselineGrew":       "    ベースライン: 約 %d トークンから増加しました\n",
+		"baselineWritten":    "%d 件の違反を %s に記録しました",
+		"apiBudgetExhausted": "API リクエスト上限 %d に達しました。%d 件のファイルは -ratio で推定しました",
+		"suppressed":         "//tokenlint:ignore で除外されたファイル %d 個:\n\n",
+		"suppressedFile":     "    約 %d トークン、理由: %s\n",
+		"content":            "    内容: %s\n",
+		"embedded":           "    埋め込まれた %s が大半です: %s\n\n",
+		"largestDecls":       "    大きな宣言:\n",
+		"declEntry":          "      %s (%d 行目): 約 %d トークン\n",
+		"split":              "    LLM が読みやすいよう、より小さなファイルへの分割を検討してください\n\n",
+		"exceeds":            " <- 上限超過",
+		"allUnder":           "%d 個のファイルはすべてトークンしきい値 %d 以下です\n",
+		"noFiles":            "Go ファイルが見つかりません",
+		"sampling":           "%d / %d ファイルをサンプリング (%s、シード %s)",
+		"changedOnly":        "%d / %d ファイルを検査 (%s 以降に変更されたもの)",
+		"prBudget":           "%s 以降の変更は %d 個の Go ファイル (合計約 %d トークン) に及び、PR 予算 %d トークンを超えています\n",
+		"prSplit":            "    変更をより小さなプルリクエストに分割することを検討してください\n\n",
+		"totalBudget":        "解析した %d 個の Go ファイルは合計約 %d トークンで、総予算 %d トークンを超えています\n\n",
+		"interrupted":        "中断されました",
+		"timedOut":           "タイムアウトしました",
+		"canceledDiscovery":  "%s (ファイル探索中)",
+		"canceledAnalysis":   "%s: %d / %d ファイルを解析済み",
+		"watching":           "%d 個のファイルの変更を監視しています。Ctrl-C で終了します",
+		"watchChanged":       "%s  %s: 約 %d トークン (%+d)%s\n",
+		"watchAdded":         "%s  %s: 約 %d トークン (新規ファイル)%s\n",
+		"watchRemoved":       "%s  %s: 削除されました\n",
+		"watchOver":          "、しきい値 %d を超えています",
+		"watchCrossed":       "、しきい値 %d を超えました",
+		"watchBack":          "、しきい値 %d 以下に戻りました",
+		"remedy.html":        "テンプレートを go:embed で読み込む .html ファイルに移してください",
+		"remedy.sql":         "クエリを .sql ファイルに移してください (go:embed で読み込むか sqlc で生成)",
+		"remedy.json":        "データを testdata 配下か go:embed で読み込む .json ファイルに移してください",
+		"remedy.shell":       "//go:generate のパイプラインをスクリプトに移し、それを呼び出してください",
+		"remedy.text":        "長いテキストを go:embed で読み込む別ファイルに移してください",
+		"remedy.comments":    "長いコメントを削るか、説明文を doc.go や README に移してください",
+		"findings":           "ルールによる指摘 %d 件:\n\n",
+		"sarifViolation":     "約 %d トークン、しきい値 %d トークンを超過 (%d 文字)",
+		"agentFileOver":      "%s は約 %d トークンで、エージェント指示ファイルの予算 %d トークンを超えています。すべてのエージェントセッションがこのコストを負担します",
+		"pkgDocMissing":      "パッケージ %s にパッケージコメントがありません。doc.go に追加してください",
+		"pkgDocOver":         "パッケージ %s のコメントは約 %d トークンで、予算 %d トークンを超えています。要約にとどめてください",
+		"exportsOver":        "エクスポートされたシンボルが %d 個あり、上限 %d 個を超えています。公開 API を複数のファイルに分けてください",
+		"receiversOver":      "%d 個のレシーバー型 (%s) にメソッドを定義しており、上限 %d 個を超えています。型ごとにファイルを分けてください",
+		"dataLiterals":       "約 %.0f%% (全体約 %d トークン) がデータリテラルです (最大は約 %d トークン、%d 行目)。データを go:embed で読み込むファイルに移してください",
+		"packageOver":        "パッケージは約 %d トークン (%d ファイル) で、パッケージ予算 %d トークンを超えています。サブパッケージに分割してください",
+	},
+	"de": {
+		"violations":         "%d Datei(en) überschreiten den Token-Schwellenwert von %d:\n\n",
+		"detail":             "    ~%d Tokens (%.0f%% des Limits, %d Zeichen)\n",
+		"classes":            "    ~%d in Code, ~%d in Kommentaren, ~%d in String-Literalen\n",
+		"fileThreshold":      "    Schwellenwert der Datei: %d\n",
+		"frozen":             "    Eingefroren: unverändert seit %s\n",
+		"baselined":          "    Baseline: mit ~%d Tokens erfasst, kein Fehler\n",
+		"baselineGrew":       "    Baseline: gewachsen von ~%d Tokens\n",
+		"baselineWritten":    "%d Verstoß/Verstöße in %s erfasst",
+		"apiBudgetExhausted": "API-Anfragebudget von %d aufgebraucht; %d Datei(en) stattdessen mit -ratio geschätzt",
+		"suppressed":         "%d Datei(en) mit //tokenlint:ignore ausgenommen:\n\n",
+		"suppressedFile":     "    ~%d Tokens, Grund: %s\n",
+		"content":            "    Inhalt: %s\n",
+		"embedded":           "    Überwiegend eingebettetes %s: %s\n\n",
+		"largestDecls":       "    Größte Deklarationen:\n",
+		"declEntry":          "      %s, Zeile %d: ~%d Tokens\n",
+		"split":              "    Für bessere Lesbarkeit durch LLMs in kleinere Dateien aufteilen\n\n",
+		"exceeds":            " <- LIMIT ÜBERSCHRITTEN",
+		"allUnder":           "Alle %d Dateien unter dem Token-Schwellenwert von %d\n",
+		"noFiles":            "keine Go-Dateien gefunden",
+		"sampling":           "Stichprobe von %d aus %d Dateien (%s, Seed %s)",
+		"changedOnly":        "prüfe %d von %d Dateien, die seit %s geändert wurden",
+		"prBudget":           "Änderungen seit %s betreffen %d Go-Datei(en) mit insgesamt ~%d Tokens und überschreiten das PR-Budget von %d Tokens\n",
+		"prSplit":            "    Die Änderung in kleinere Pull Requests aufteilen\n\n",
+		"totalBudget":        "Die %d analysierten Go-Datei(en) haben insgesamt ~%d Tokens und überschreiten das Gesamtbudget von %d Tokens\n\n",
+		"interrupted":        "abgebrochen",
+		"timedOut":           "Zeitüberschreitung",
+		"canceledDiscovery":  "%s während der Dateisuche",
+		"canceledAnalysis":   "%s nach Analyse von %d von %d Dateien",
+		"watching":           "überwache %d Datei(en) auf Änderungen; Strg-C beendet",
+		"watchChanged":       "%s  %s: ~%d Tokens (%+d)%s\n",
+		"watchAdded":         "%s  %s: ~%d Tokens (neue Datei)%s\n",
+		"watchRemoved":       "%s  %s: entfernt\n",
+		"watchOver":          ", über dem Limit von %d Tokens",
+		"watchCrossed":       ", jetzt über dem Limit von %d Tokens",
+		"watchBack":          ", wieder unter dem Limit von %d Tokens",
+		"remedy.html":        "Templates in .html-Dateien auslagern und mit go:embed laden",
+		"remedy.sql":         "Abfragen in .sql-Dateien auslagern (mit go:embed laden oder mit sqlc generieren)",
+		"remedy.json":        "Daten in .json-Dateien unter testdata auslagern oder mit go:embed laden",
+		"remedy.shell":       "//go:generate-Pipelines in ein Skript auslagern und dieses aufrufen",
+		"remedy.text":        "Lange Textblöcke in separate Dateien auslagern und mit go:embed laden",
+		"remedy.comments":    "Lange Kommentare kürzen oder Prosa nach doc.go bzw. in eine README verschieben",
+		"findings":           "%d Regelverstoß/Regelverstöße:\n\n",
+		"sarifViolation":     "~%d Tokens, über dem Schwellenwert von %d Tokens (%d Zeichen)",
+		"agentFileOver":      "%s hat ~%d Tokens und überschreitet das Budget von %d Tokens für Agent-Anweisungen; jede Agent-Sitzung zahlt dafür",
+		"pkgDocMissing":      "Paket %s hat keinen Paketkommentar; in doc.go ergänzen",
+		"pkgDocOver":         "Paketkommentar von %s hat ~%d Tokens und überschreitet das Budget von %d Tokens; als Zusammenfassung halten",
+		"exportsOver":        "deklariert %d exportierte Symbole, mehr als das Limit von %d; die öffentliche API auf mehrere Dateien aufteilen",
+		"receiversOver":      "deklariert Methoden für %d Empfängertypen (%s), mehr als das Limit von %d; jedem Typ eine eigene Datei geben",
+		"dataLiterals":       "%.0f%% der ~%d Tokens sind Datenliterale (größtes ~%d Tokens in Zeile %d); die Daten in mit go:embed geladene Dateien auslagern",
+		"packageOver":        "Paket hat ~%d Tokens in %d Dateien und überschreitet das Paketbudget von %d Tokens; in Unterpakete aufteilen",
+	},
+}
+
+// messages formats catalog entries in one language.
+type messages struct {
+	lang string
+}
+
+// newMessages returns messages for lang, falling back to the language of
+// the LC_ALL, LC_MESSAGES or LANG environment variables and then English.
+func newMessages(lang string) messages {
+	if lang == "" {
+		for _, env := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+			if v := os.Getenv(env); v != "" {
+				lang = v
+				break
+			}
+		}
+	}
+	// "de_DE.UTF-8" and "ja-JP" become "de" and "ja".
+	lang = strings.ToLower(lang)
+	if i := strings.IndexAny(lang, "_-.@"); i >= 0 {
+		lang = lang[:i]
+	}
+	if _, ok := catalog[lang]; !ok {
+		lang = "en"
+	}
+	return messages{lang: lang}
+}
+
+// f formats the message for key.
+func (m messages) f(key string, args ...any) string {
+	format, ok := catalog[m.lang][key]
+	if !ok {
+		format = catalog["en"][key]
+	}
+	return fmt.Sprintf(format, args...)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCatalogComplete(t *testing.T) {
+	verb := regexp.MustCompile(`%[-+# 0]*[0-9]*(\.[0-9]+)?[a-zA-Z%]`)
+	for key, en := range catalog["en"] {
+		want := verb.FindAllString(en, -1)
+		for lang, msgs := range catalog {
+			msg, ok := msgs[key]
+			if !ok {
+				t.Errorf("%s: missing %q", lang, key)
+				continue
+			}
+			if got := verb.FindAllString(msg, -1); !slices.Equal(got, want) {
+				t.Errorf("%s %q: verbs %v, want %v", lang, key, got, want)
+			}
+		}
+	}
+	for lang, msgs := range catalog {
+		for key := range msgs {
+			if _, ok := catalog["en"][key]; !ok {
+				t.Errorf("%s: %q has no English source", lang, key)
+			}
+		}
+	}
+}
+
+func TestNewMessages(t *testing.T) {
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_MESSAGES", "")
+	t.Setenv("LANG", "de_DE.UTF-8")
+
+	tests := []struct {
+		flag string
+		want string
+	}{
+		{"ja", "ja"},
+		{"ja-JP", "ja"},
+		{"fr", "en"},
+		{"", "de"},
+	}
+	for _, tt := range tests {
+		if got := newMessages(tt.flag).lang; got != tt.want {
+			t.Errorf("newMessages(%q).lang = %q, want %q", tt.flag, got, tt.want)
+		}
+	}
+}
+
+func TestRunLang(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "a.go")
+	if err := os.WriteFile(file, []byte("package a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout strings.Builder
+	if code := run([]string{"-lang", "de", file}, &stdout, io.Discard); code != 0 {
+		t.Fatalf("exit code = %d, want 0", code)
+	}
+	if want := "Alle 1 Dateien unter dem Token-Schwellenwert von 25000\n"; stdout.String() != want {
+		t.Errorf("stdout = %q, want %q", stdout.String(), want)
+	}
+
+	stdout.Reset()
+	if code := run([]string{"-lang", "ja", "-format", "jsonl", file}, &stdout, io.Discard); code != 0 {
+		t.Fatalf("exit code = %d, want 0", code)
+	}
+	if !strings.Contains(stdout.String(), `"type":"summary"`) {
+		t.Errorf("jsonl output changed by -lang: %q", stdout.String())
+	}
+}
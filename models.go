@@ -0,0 +1,74 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// modelPreset bundles the counting settings that suit one model family.
+type modelPreset struct {
+	tokenizer     string
+	encoding      string // for bpe
+	apiModel      string // for claude-api
+	ratio         float64
+	contextWindow int
+}
+
+// threshold is the default per-file budget for the model: an eighth of
+// its context window, leaving room for the rest of the conversation.
+func (p modelPreset) threshold() int {
+	return p.contextWindow / 8
+}
+
+// modelPresets maps each -model name to its preset.
+var modelPresets = map[string]modelPreset{
+	"claude-sonnet": {tokenizer: "ratio", ratio: defaultRatio, apiModel: "claude-sonnet-4-5", contextWindow: 200000},
+	"claude-opus":   {tokenizer: "ratio", ratio: defaultRatio, apiModel: "claude-opus-4-1", contextWindow: 200000},
+	"claude-haiku":  {tokenizer: "ratio", ratio: defaultRatio, apiModel: "claude-haiku-4-5", contextWindow: 200000},
+	"gpt-4o":        {tokenizer: "bpe", encoding: "o200k_base", ratio: defaultRatio, contextWindow: 128000},
+	"gpt-4":         {tokenizer: "bpe", encoding: "cl100k_base", ratio: defaultRatio, contextWindow: 128000},
+	// Llama 3 uses a 128k tiktoken-style vocabulary that extends
+	// cl100k_base; the 128k window is that of Llama 3.1 and later.
+	"llama-3": {tokenizer: "bpe", encoding: "cl100k_base", ratio: defaultRatio, contextWindow: 128000},
+}
+
+// modelNames returns the preset names, sorted.
+func modelNames() []string {
+	names := make([]string, 0, len(modelPresets))
+	for name := range modelPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// applyModelPreset sets the flags a model preset covers, leaving any the
+// user passed explicitly untouched. Flags fs does not define are skipped.
+func applyModelPreset(fs *flag.FlagSet, model string) error {
+	p, ok := modelPresets[model]
+	if !ok {
+		return fmt.Errorf("unknown model %q (want one of %v)", model, modelNames())
+	}
+
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+
+	values := map[string]string{
+		"tokenizer": p.tokenizer,
+		"encoding":  p.encoding,
+		"api-model": p.apiModel,
+		"ratio":     strconv.FormatFloat(p.ratio, 'g', -1, 64),
+		"threshold": strconv.Itoa(p.threshold()),
+	}
+	for name, value := range values {
+		if value == "" || explicit[name] || fs.Lookup(name) == nil {
+			continue
+		}
+		if err := fs.Set(name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
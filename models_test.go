@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"testing"
+)
+
+func TestApplyModelPreset(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	threshold := fs.Int("threshold", defaultThreshold, "")
+	ratio := fs.Float64("ratio", defaultRatio, "")
+	tokenizer := fs.String("tokenizer", "ratio", "")
+	encoding := fs.String("encoding", "cl100k_base", "")
+	fs.String("api-model", defaultClaudeModel, "")
+
+	if err := fs.Parse([]string{"-threshold", "9000"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := applyModelPreset(fs, "gpt-4o"); err != nil {
+		t.Fatal(err)
+	}
+
+	if *threshold != 9000 {
+		t.Errorf("threshold = %d, want explicit 9000 kept", *threshold)
+	}
+	if *tokenizer != "bpe" || *encoding != "o200k_base" {
+		t.Errorf("tokenizer = %s/%s, want bpe/o200k_base", *tokenizer, *encoding)
+	}
+	if *ratio != defaultRatio {
+		t.Errorf("ratio = %g", *ratio)
+	}
+
+	if err := applyModelPreset(fs, "nope"); err == nil {
+		t.Error("applyModelPreset accepted an unknown model")
+	}
+}
+
+func TestModelPresetThreshold(t *testing.T) {
+	// The default threshold matches the Claude presets.
+	if got := modelPresets["claude-sonnet"].threshold(); got != defaultThreshold {
+		t.Errorf("claude-sonnet threshold = %d, want %d", got, defaultThreshold)
+	}
+	for name, p := range modelPresets {
+		if _, ok := tokenizers[p.tokenizer]; !ok {
+			t.Errorf("%s: unknown tokenizer %q", name, p.tokenizer)
+		}
+	}
+}
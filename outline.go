@@ -0,0 +1,231 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/doc"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/befabri/token-lint/pkg/tokenlint"
+)
+
+// outlinePackage is one package section of an outline.
+type outlinePackage struct {
+	dir      string
+	name     string
+	synopsis string // first sentence of the package comment
+	files    []fileResult
+	symbols  []string // exported declarations, e.g. "func New", "type Server"
+}
+
+// runOutline implements `token-lint outline`, which writes a Markdown map
+// of packages, their files with token sizes and their exported symbols,
+// trimmed to fit a token budget.
+func runOutline(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("token-lint outline", flag.ContinueOnError)
+	output := fs.String("o", "", "write the outline to this file instead of stdout")
+	budget := fs.Int("budget", 8000, "maximum tokens for the outline")
+	ratio := fs.Float64("ratio", defaultRatio, "tokens per character ratio")
+
+	// Accept flags after the paths too, as in "outline ./... -o CONTEXT.md".
+	var paths []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			if err == flag.ErrHelp {
+				return 0
+			}
+			return 1
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		paths = append(paths, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+	pol, err := loadPolicy(fs, "", "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	if *ratio <= 0 {
+		fmt.Fprintln(os.Stderr, "error: ratio must be positive")
+		return 1
+	}
+	if *budget <= 0 {
+		fmt.Fprintln(os.Stderr, "error: budget must be positive")
+		return 1
+	}
+
+	if len(paths) == 0 {
+		paths = []string{"./..."}
+	}
+	files, err := pol.expand(ctx, paths)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+
+	var sources []string
+	for _, path := range files {
+		if !strings.HasSuffix(path, "_test.go") {
+			sources = append(sources, path)
+		}
+	}
+	opts, err := pol.options(defaultThreshold, *ratio)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	results, _ := analyzeFiles(ctx, sources, opts)
+	pkgs := buildOutline(results)
+	if len(pkgs) == 0 {
+		fmt.Fprintln(os.Stderr, "no Go files found")
+		return 0
+	}
+
+	text, fits := fitOutline(pkgs, *budget, tokenlint.RatioTokenizer(*ratio))
+	if !fits {
+		fmt.Fprintf(os.Stderr, "warning: outline exceeds the %d token budget even without symbols; narrow the paths\n", *budget)
+	}
+
+	if *output == "" {
+		fmt.Print(text)
+		return 0
+	}
+	if err := os.WriteFile(*output, []byte(text), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// buildOutline groups results by directory and collects each package's
+// synopsis and exported symbols. Files that do not parse are listed
+// without symbols.
+func buildOutline(results []fileResult) []outlinePackage {
+	byDir := make(map[string]*outlinePackage)
+	var p doc.Package
+	for _, r := range results {
+		dir := filepath.ToSlash(filepath.Dir(r.path))
+		pkg, ok := byDir[dir]
+		if !ok {
+			pkg = &outlinePackage{dir: dir}
+			byDir[dir] = pkg
+		}
+		pkg.files = append(pkg.files, r)
+
+		f, err := parser.ParseFile(token.NewFileSet(), r.path, nil, parser.ParseComments|parser.SkipObjectResolution)
+		if err != nil {
+			continue
+		}
+		pkg.name = f.Name.Name
+		if f.Doc != nil && pkg.synopsis == "" {
+			pkg.synopsis = p.Synopsis(f.Doc.Text())
+		}
+		for _, d := range f.Decls {
+			if exportedDecl(d) {
+				pkg.symbols = append(pkg.symbols, declName(d))
+			}
+		}
+	}
+
+	pkgs := make([]outlinePackage, 0, len(byDir))
+	for _, pkg := range byDir {
+		sort.Slice(pkg.files, func(i, j int) bool { return pkg.files[i].path < pkg.files[j].path })
+		pkgs = append(pkgs, *pkg)
+	}
+	sort.Slice(pkgs, func(i, j int) bool { return pkgs[i].dir < pkgs[j].dir })
+	return pkgs
+}
+
+// exportedDecl reports whether d declares an exported function, exported
+// method on an exported type, or any exported type, constant or variable.
+func exportedDecl(d ast.Decl) bool {
+	switch d := d.(type) {
+	case *ast.FuncDecl:
+		if !d.Name.IsExported() {
+			return false
+		}
+		if d.Recv == nil || len(d.Recv.List) == 0 {
+			return true
+		}
+		recv := strings.TrimPrefix(exprString(d.Recv.List[0].Type), "*")
+		return ast.IsExported(recv)
+	case *ast.GenDecl:
+		for _, spec := range d.Specs {
+			switch s := spec.(type) {
+			case *ast.TypeSpec:
+				if s.Name.IsExported() {
+					return true
+				}
+			case *ast.ValueSpec:
+				for _, n := range s.Names {
+					if n.IsExported() {
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+// fitOutline renders pkgs, listing fewer symbols per package until the
+// outline fits within budget tokens. It reports whether it fits.
+func fitOutline(pkgs []outlinePackage, budget int, tok tokenlint.Tokenizer) (string, bool) {
+	most := 0
+	for _, pkg := range pkgs {
+		most = max(most, len(pkg.symbols))
+	}
+	for limit := most; ; limit /= 2 {
+		var b strings.Builder
+		renderOutline(&b, pkgs, limit)
+		if tok.Count([]byte(b.String())) <= budget {
+			return b.String(), true
+		}
+		if limit == 0 {
+			return b.String(), false
+		}
+	}
+}
+
+// renderOutline writes pkgs as Markdown, listing at most maxSymbols
+// exported symbols per package.
+func renderOutline(w io.Writer, pkgs []outlinePackage, maxSymbols int) {
+	fmt.Fprintln(w, "# Code outline")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Generated by token-lint outline. Token sizes are estimates.")
+	for _, pkg := range pkgs {
+		total := 0
+		for _, f := range pkg.files {
+			total += f.tokens
+		}
+		fmt.Fprintln(w)
+		if pkg.name != "" {
+			fmt.Fprintf(w, "## %s (package %s, ~%d tokens)\n\n", pkg.dir, pkg.name, total)
+		} else {
+			fmt.Fprintf(w, "## %s (~%d tokens)\n\n", pkg.dir, total)
+		}
+		if pkg.synopsis != "" {
+			fmt.Fprintf(w, "%s\n\n", pkg.synopsis)
+		}
+		for _, f := range pkg.files {
+			fmt.Fprintf(w, "- %s (~%d tokens)\n", filepath.Base(f.path), f.tokens)
+		}
+		if n := min(len(pkg.symbols), maxSymbols); n > 0 {
+			fmt.Fprintf(w, "\nExported: %s", strings.Join(pkg.symbols[:n], "; "))
+			if n < len(pkg.symbols) {
+				fmt.Fprintf(w, "; ... (%d more)", len(pkg.symbols)-n)
+			}
+			fmt.Fprintln(w)
+		}
+	}
+}
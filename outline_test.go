@@ -0,0 +1,69 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/befabri/token-lint/pkg/tokenlint"
+)
+
+func TestBuildOutline(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "server")
+	if err := os.Mkdir(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	src := `// Package server serves things. It has more to say.
+package server
+
+type Server struct{}
+
+type handler struct{}
+
+func New() *Server { return nil }
+
+func (s *Server) Run() {}
+
+func (h handler) Run() {}
+
+func helper() {}
+
+const Version = "1"
+`
+	path := filepath.Join(dir, "server.go")
+	if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	pkgs := buildOutline([]fileResult{{path: path, tokens: 120}})
+	if len(pkgs) != 1 {
+		t.Fatalf("packages = %d, want 1", len(pkgs))
+	}
+	pkg := pkgs[0]
+	if pkg.name != "server" || pkg.synopsis != "Package server serves things." {
+		t.Errorf("package = %q %q", pkg.name, pkg.synopsis)
+	}
+	want := []string{"type Server", "func New", "method (*Server).Run", "const Version"}
+	if strings.Join(pkg.symbols, "|") != strings.Join(want, "|") {
+		t.Errorf("symbols = %q, want %q", pkg.symbols, want)
+	}
+
+	var full strings.Builder
+	renderOutline(&full, pkgs, len(want))
+	for _, s := range []string{"(package server, ~120 tokens)", "- server.go (~120 tokens)", "Exported: type Server; func New"} {
+		if !strings.Contains(full.String(), s) {
+			t.Errorf("outline missing %q:\n%s", s, full.String())
+		}
+	}
+
+	// A budget that only fits part of the symbol list trims it.
+	budget := len(full.String()) - 10
+	text, fits := fitOutline(pkgs, budget, tokenlint.RatioTokenizer(1))
+	if !fits || !strings.Contains(text, "more)") {
+		t.Errorf("fitOutline(%d) = %v:\n%s", budget, fits, text)
+	}
+	if _, fits := fitOutline(pkgs, 1, tokenlint.RatioTokenizer(1)); fits {
+		t.Error("fitOutline fits in 1 token")
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// packageTotal aggregates the results of one package directory.
+type packageTotal struct {
+	dir    string
+	files  int
+	tokens int
+}
+
+// packageTotals sums results per directory, test files included, largest
+// package first. An agent working on a package usually needs all of it in
+// context, so its total matters more than any single file.
+func packageTotals(results []fileResult) []packageTotal {
+	byDir := make(map[string]*packageTotal)
+	for _, r := range results {
+		dir := filepath.Dir(r.path)
+		p, ok := byDir[dir]
+		if !ok {
+			p = &packageTotal{dir: dir}
+			byDir[dir] = p
+		}
+		p.files++
+		p.tokens += r.tokens
+	}
+
+	totals := make([]packageTotal, 0, len(byDir))
+	for _, p := range byDir {
+		totals = append(totals, *p)
+	}
+	sort.Slice(totals, func(i, j int) bool {
+		if totals[i].tokens != totals[j].tokens {
+			return totals[i].tokens > totals[j].tokens
+		}
+		return totals[i].dir < totals[j].dir
+	})
+	return totals
+}
+
+// checkPackageBudget reports packages whose files total more than budget
+// tokens.
+func checkPackageBudget(totals []packageTotal, budget int) []finding {
+	var findings []finding
+	for _, p := range totals {
+		if p.tokens > budget {
+			findings = append(findings, finding{rule: "package-budget", path: p.dir, key: "packageOver", args: []any{p.tokens, p.files, budget}})
+		}
+	}
+	sort.Slice(findings, func(i, j int) bool { return findings[i].path < findings[j].path })
+	return findings
+}
+
+// printPackages writes the -by-package table, marking packages over
+// budget if one is set.
+func printPackages(w io.Writer, msg messages, totals []packageTotal, budget int) {
+	fmt.Fprintf(w, "%-60s %8s %8s\n", "PACKAGE", "FILES", "TOKENS")
+	fmt.Fprintln(w, strings.Repeat("-", 78))
+	for _, p := range totals {
+		marker := ""
+		if budget > 0 && p.tokens > budget {
+			marker = msg.f("exceeds")
+		}
+		fmt.Fprintf(w, "%-60s %8d %8d%s\n", quotePath(p.dir), p.files, p.tokens, marker)
+	}
+	fmt.Fprintln(w)
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPackageTotals(t *testing.T) {
+	results := []fileResult{
+		{path: filepath.Join("a", "x.go"), tokens: 100},
+		{path: filepath.Join("a", "x_test.go"), tokens: 50},
+		{path: filepath.Join("b", "y.go"), tokens: 200},
+		{path: filepath.Join("c", "z.go"), tokens: 10},
+	}
+	totals := packageTotals(results)
+	want := []packageTotal{{"b", 1, 200}, {"a", 2, 150}, {"c", 1, 10}}
+	if len(totals) != len(want) {
+		t.Fatalf("totals = %v, want %v", totals, want)
+	}
+	for i := range want {
+		if totals[i] != want[i] {
+			t.Errorf("totals[%d] = %v, want %v", i, totals[i], want[i])
+		}
+	}
+
+	findings := checkPackageBudget(totals, 120)
+	if len(findings) != 2 || findings[0].path != "a" || findings[1].path != "b" {
+		t.Fatalf("findings = %v, want a and b", findings)
+	}
+	if want := "package is ~150 tokens across 2 files, over the 120 token package budget; split it into subpackages"; findings[0].message(newMessages("en")) != want {
+		t.Errorf("message = %q, want %q", findings[0].message(newMessages("en")), want)
+	}
+
+	var b strings.Builder
+	printPackages(&b, newMessages("en"), totals, 120)
+	lines := strings.Split(b.String(), "\n")
+	if !strings.HasPrefix(lines[2], "b ") || !strings.HasSuffix(lines[2], "EXCEEDS LIMIT") {
+		t.Errorf("first package line = %q", lines[2])
+	}
+	if strings.Contains(lines[4], "EXCEEDS") {
+		t.Errorf("package under budget marked: %q", lines[4])
+	}
+}
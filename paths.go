@@ -0,0 +1,42 @@
+package main
+
+import (
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// quotePath returns path for text output. Paths that could be misread,
+// or could corrupt the terminal, such as ones with spaces, quotes, control
+// characters or invalid UTF-8, are quoted in Go syntax; printable
+// non-ASCII characters are kept as they are.
+func quotePath(path string) string {
+	if !utf8.ValidString(path) {
+		return strconv.Quote(path)
+	}
+	for _, r := range path {
+		if r == '"' || r == '\\' || unicode.IsSpace(r) || !unicode.IsPrint(r) {
+			return strconv.Quote(path)
+		}
+	}
+	return path
+}
+
+// sarifURI returns path as the relative URI reference SARIF requires,
+// percent-encoding spaces, non-ASCII and reserved characters.
+func sarifURI(path string) string {
+	u := url.URL{Path: filepath.ToSlash(path)}
+	return u.String()
+}
+
+// markdownPath returns path as inline code for a Markdown table cell.
+func markdownPath(path string) string {
+	p := strings.ReplaceAll(quotePath(path), "|", `\|`)
+	if strings.Contains(p, "`") {
+		return "`` " + p + " ``"
+	}
+	return "`" + p + "`"
+}
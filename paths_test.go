@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// adversarialPaths are file names that break naive output formatting.
+var adversarialPaths = []string{
+	"plain/file.go",
+	"with space/a b.go",
+	"naïve/日本語.go",
+	"tab\tand\nnewline.go",
+	`quote"and\backslash.go`,
+	"percent%20#hash?.go",
+	"colon:first.go",
+	"pipe|and`tick`.go",
+	"esc\x1b[31mred.go",
+	"bad\xffutf8.go",
+}
+
+func TestQuotePath(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"plain/file.go", "plain/file.go"},
+		{"naïve/日本語.go", "naïve/日本語.go"},
+		{"with space/a b.go", `"with space/a b.go"`},
+		{"tab\tand\nnewline.go", `"tab\tand\nnewline.go"`},
+		{"esc\x1b[31mred.go", `"esc\x1b[31mred.go"`},
+		{"bad\xffutf8.go", `"bad\xffutf8.go"`},
+	}
+	for _, tt := range tests {
+		if got := quotePath(tt.in); got != tt.want {
+			t.Errorf("quotePath(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestTextOutputPaths(t *testing.T) {
+	var violations []fileResult
+	for _, p := range adversarialPaths {
+		violations = append(violations, fileResult{path: p, tokens: 200, threshold: 100, chars: 300})
+	}
+	var out bytes.Buffer
+	printViolations(&out, newMessages("en"), violations, 100)
+	// After the heading, every line is indented: no path broke a line.
+	for _, line := range strings.Split(out.String(), "\n")[1:] {
+		if strings.ContainsAny(line, "\t\x1b") || line != "" && !strings.HasPrefix(line, "  ") {
+			t.Errorf("unsafe or split line %q", line)
+		}
+	}
+}
+
+func TestSARIFPaths(t *testing.T) {
+	var violations []fileResult
+	for _, p := range adversarialPaths {
+		violations = append(violations, fileResult{path: p, tokens: 200, threshold: 100})
+	}
+	var out bytes.Buffer
+	if err := writeSARIF(&out, violations, nil, false); err != nil {
+		t.Fatal(err)
+	}
+	var log struct {
+		Runs []struct {
+			Artifacts []struct {
+				Location struct {
+					URI string `json:"uri"`
+				} `json:"location"`
+			} `json:"artifacts"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &log); err != nil {
+		t.Fatal(err)
+	}
+	for i, a := range log.Runs[0].Artifacts {
+		u, err := url.Parse(a.Location.URI)
+		if err != nil {
+			t.Errorf("uri %q: %v", a.Location.URI, err)
+			continue
+		}
+		if u.Scheme != "" || u.RawQuery != "" || u.Fragment != "" || strings.ContainsAny(a.Location.URI, " \t\n") {
+			t.Errorf("uri %q is not a plain relative reference", a.Location.URI)
+		}
+		if got := strings.TrimPrefix(u.Path, "./"); got != adversarialPaths[i] {
+			t.Errorf("uri %q decodes to %q, want %q", a.Location.URI, got, adversarialPaths[i])
+		}
+	}
+}
+
+func TestMachineFormatPaths(t *testing.T) {
+	var results []fileResult
+	for _, p := range adversarialPaths {
+		results = append(results, fileResult{path: p, tokens: 200, threshold: 100})
+	}
+
+	// JSON keeps every valid UTF-8 path intact.
+	var js bytes.Buffer
+	if err := writeJSONReport(&js, results, nil, 100, false); err != nil {
+		t.Fatal(err)
+	}
+	var report jsonReport
+	if err := json.Unmarshal(js.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	for i, f := range report.Files[:len(results)-1] {
+		if f.Path != adversarialPaths[i] {
+			t.Errorf("json path = %q, want %q", f.Path, adversarialPaths[i])
+		}
+	}
+
+	// GitHub workflow commands stay one per line.
+	var gh bytes.Buffer
+	writeGitHubAnnotations(&gh, results, nil, false)
+	sc := bufio.NewScanner(&gh)
+	lines := 0
+	for sc.Scan() {
+		if !strings.HasPrefix(sc.Text(), "::error file=") {
+			t.Errorf("annotation line %q", sc.Text())
+		}
+		lines++
+	}
+	if lines != len(results) {
+		t.Errorf("%d annotation lines, want %d", lines, len(results))
+	}
+
+	// JUnit stays well-formed XML.
+	var ju bytes.Buffer
+	if err := writeJUnit(&ju, results, results, nil, false); err != nil {
+		t.Fatal(err)
+	}
+	dec := xml.NewDecoder(&ju)
+	for {
+		if _, err := dec.Token(); err != nil {
+			if err.Error() != "EOF" {
+				t.Errorf("junit: %v", err)
+			}
+			break
+		}
+	}
+
+	// Markdown table rows keep their four cell separators.
+	var md strings.Builder
+	writeMarkdownTable(&md, results, 100)
+	for _, row := range strings.Split(strings.TrimSpace(md.String()), "\n")[2:] {
+		if n := strings.Count(row, "|") - strings.Count(row, `\|`); n != 4 {
+			t.Errorf("markdown row %q has %d separators, want 4", row, n)
+		}
+	}
+}
+
+func TestHeatmapCSVPaths(t *testing.T) {
+	var cells []heatmapCell
+	for _, p := range adversarialPaths {
+		cells = append(cells, heatmapCell{Team: "@team", Package: p})
+	}
+	var out bytes.Buffer
+	if err := writeHeatmapCSV(&out, cells); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, rec := range records[1:] {
+		if rec[1] != adversarialPaths[i] {
+			t.Errorf("csv package = %q, want %q", rec[1], adversarialPaths[i])
+		}
+	}
+}
@@ -0,0 +1,143 @@
+// Package analyzer provides token-lint's token limit check as a
+// go/analysis Analyzer, for go vet -vettool, analysis drivers and tests
+// with analysistest.
+//
+// Token counts are estimated from byte counts with a fixed ratio, like
+// token-lint's default tokenizer. The //tokenlint:ignore reason and
+// //tokenlint:threshold=N header directives are honored, and generated
+// files are skipped: those with a generated-code header and those matching
+// token-lint's generated path patterns, which Config.Generated can extend.
+package analyzer
+
+import (
+	"fmt"
+	"go/ast"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/befabri/token-lint/pkg/tokenlint"
+	"golang.org/x/tools/go/analysis"
+)
+
+// Defaults match the token-lint command.
+const (
+	DefaultThreshold = tokenlint.DefaultThreshold
+	DefaultRatio     = tokenlint.DefaultRatio
+)
+
+// Config holds the settings of an Analyzer.
+type Config struct {
+	Threshold     int     // maximum tokens per file
+	TestThreshold int     // maximum tokens per _test.go file; 0 uses Threshold
+	Ratio         float64 // tokens per byte
+
+	// Exclude skips files whose slash-separated path matches any of
+	// these, such as _mock\.go$ or /internal/legacy/.
+	Exclude []*regexp.Regexp
+	// Skip leaves out the files it reports true for, if set, such as
+	// those matching gitignore-style globs.
+	Skip func(path string) bool
+
+	// Generated extends or replaces the built-in generated path
+	// patterns; nil applies the built-in ones.
+	Generated *tokenlint.GeneratedRules
+}
+
+// Analyzer reports files over DefaultThreshold tokens. Its flags, such as
+// -threshold, adjust it.
+var Analyzer = New(Config{Threshold: DefaultThreshold, Ratio: DefaultRatio})
+
+const doc = `report Go files with too many tokens for LLM context windows
+
+The tokenlint analyzer estimates each file's token count from its size and
+reports files over the threshold at their package clause. A file can set
+its own limit with a //tokenlint:threshold=N comment before the package
+clause, or opt out with //tokenlint:ignore followed by a reason.`
+
+// New returns an Analyzer using c, whose zero fields take the defaults.
+// Its flags start out at c's values.
+func New(c Config) *analysis.Analyzer {
+	if c.Threshold == 0 {
+		c.Threshold = DefaultThreshold
+	}
+	if c.Ratio == 0 {
+		c.Ratio = DefaultRatio
+	}
+	a := &analysis.Analyzer{
+		Name: "tokenlint",
+		Doc:  doc,
+		URL:  "https://github.com/befabri/token-lint",
+	}
+	a.Flags.IntVar(&c.Threshold, "threshold", c.Threshold, "maximum tokens per file")
+	a.Flags.IntVar(&c.TestThreshold, "test-threshold", c.TestThreshold, "maximum tokens per _test.go file (0 uses -threshold)")
+	a.Flags.Float64Var(&c.Ratio, "ratio", c.Ratio, "tokens per byte")
+	a.Run = func(pass *analysis.Pass) (any, error) {
+		return nil, c.run(pass)
+	}
+	return a
+}
+
+func (c *Config) run(pass *analysis.Pass) error {
+	if c.Threshold <= 0 || c.Ratio <= 0 {
+		return fmt.Errorf("threshold and ratio must be positive")
+	}
+	read := pass.ReadFile
+	if read == nil {
+		read = os.ReadFile
+	}
+	for _, f := range pass.Files {
+		if ast.IsGenerated(f) {
+			continue
+		}
+		name := pass.Fset.File(f.Pos()).Name()
+		if !strings.HasSuffix(name, ".go") || c.excluded(name) || c.Generated.Match(name) {
+			continue // excluded, generated, cgo-processed or otherwise synthesized
+		}
+		content, err := read(name)
+		if err != nil {
+			return err
+		}
+		threshold, ignored := c.threshold(name, content)
+		if ignored {
+			continue
+		}
+		if tokens := tokenlint.RatioTokenizer(c.Ratio).Count(content); tokens > threshold {
+			pass.Reportf(f.Package, "~%d tokens, over the %d token threshold (%d chars)", tokens, threshold, len(content))
+		}
+	}
+	return nil
+}
+
+func (c *Config) excluded(name string) bool {
+	if c.Skip != nil && c.Skip(name) {
+		return true
+	}
+	slashed := filepath.ToSlash(name)
+	for _, re := range c.Exclude {
+		if re.MatchString(slashed) {
+			return true
+		}
+	}
+	return false
+}
+
+// threshold returns the threshold for the file name with the given
+// content, and whether the file opts out of the check. Malformed
+// directives and //tokenlint:ignore without a reason are disregarded, as
+// by token-lint.
+func (c *Config) threshold(name string, content []byte) (threshold int, ignored bool) {
+	d := tokenlint.ParseDirectives(content)
+	if d.Ignore && d.IgnoreReason != "" {
+		return 0, true
+	}
+	threshold = c.Threshold
+	if c.TestThreshold > 0 && strings.HasSuffix(name, "_test.go") {
+		threshold = c.TestThreshold
+	}
+	if d.Threshold > 0 {
+		threshold = d.Threshold
+	}
+	return threshold, false
+}
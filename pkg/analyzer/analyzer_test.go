@@ -0,0 +1,39 @@
+package analyzer_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/befabri/token-lint/pkg/analyzer"
+	"github.com/befabri/token-lint/pkg/tokenlint"
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer(t *testing.T) {
+	a := analyzer.New(analyzer.Config{Threshold: 200, TestThreshold: 400, Ratio: 1})
+	analysistest.Run(t, analysistest.TestData(), a, "a")
+}
+
+func TestAnalyzerFlags(t *testing.T) {
+	a := analyzer.New(analyzer.Config{})
+	if err := a.Flags.Set("threshold", "200"); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Flags.Set("ratio", "1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Flags.Set("test-threshold", "400"); err != nil {
+		t.Fatal(err)
+	}
+	analysistest.Run(t, analysistest.TestData(), a, "a")
+}
+
+func TestAnalyzerGenerated(t *testing.T) {
+	dir := analysistest.TestData()
+	var rules tokenlint.GeneratedRules
+	if err := rules.AddPatterns(filepath.Join(dir, "src", "models"), []string{"zz_*.go"}); err != nil {
+		t.Fatal(err)
+	}
+	a := analyzer.New(analyzer.Config{Threshold: 200, Ratio: 1, Generated: &rules})
+	analysistest.Run(t, dir, a, "models")
+}
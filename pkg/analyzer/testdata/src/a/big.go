@@ -0,0 +1,5 @@
+package a // want `~\d+ tokens, over the 200 token threshold \(\d+ chars\)`
+
+// Big has a comment long enough to push this file over the threshold of
+// two hundred tokens that the test configures, counting one per byte.
+func Big() {}
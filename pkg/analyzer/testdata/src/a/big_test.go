@@ -0,0 +1,6 @@
+package a
+
+// Test files get the test threshold of four hundred tokens instead of the
+// regular two hundred, so this comment of well over two hundred bytes does
+// not push the file over its limit.
+func helper() {}
@@ -0,0 +1,7 @@
+//tokenlint:ignore vendored lookup table
+
+package a
+
+// Ignored opts out of the check with a reason, so this comment of well
+// over two hundred bytes is never reported, however much longer it gets.
+func Ignored() {}
@@ -0,0 +1,7 @@
+//tokenlint:ignore
+
+package a // want `over the 200 token threshold`
+
+// NoReason's directive lacks a reason and is disregarded, so the file is
+// still checked against the threshold like any other.
+func NoReason() {}
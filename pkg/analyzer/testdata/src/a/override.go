@@ -0,0 +1,7 @@
+//tokenlint:threshold=1000
+
+package a
+
+// Override raises its own threshold with a directive, so this comment of
+// well over two hundred bytes does not put the file over its limit.
+func Override() {}
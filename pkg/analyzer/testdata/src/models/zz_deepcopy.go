@@ -0,0 +1,6 @@
+package models
+
+// DeepCopy has no generated-code header either, but the zz_*.go pattern
+// the test configures marks it as generated, so it is never reported, even
+// past the two hundred token threshold of the test.
+func DeepCopy() {}
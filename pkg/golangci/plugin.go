@@ -0,0 +1,133 @@
+// Package golangci registers token-lint's analyzer as a golangci-lint
+// module plugin named tokenlint.
+//
+// Build a custom golangci-lint with it by listing the module in
+// .custom-gcl.yml:
+//
+//	version: v2.5.0
+//	plugins:
+//	  - module: github.com/befabri/token-lint
+//	    import: github.com/befabri/token-lint/pkg/golangci
+//	    version: latest
+//
+// then enable and configure it in .golangci.yml:
+//
+//	linters:
+//	  enable:
+//	    - tokenlint
+//	  settings:
+//	    custom:
+//	      tokenlint:
+//	        type: module
+//	        description: Reports Go files with too many tokens for LLM context windows
+//	        settings:
+//	          threshold: 20000
+//	          test-threshold: 30000
+//	          ratio: 0.65
+//	          exclude:
+//	            - "*_mock.go"
+//	            - internal/legacy/**
+//	          generated:
+//	            - "zz_generated*.go"
+package golangci
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/befabri/token-lint/pkg/analyzer"
+	"github.com/befabri/token-lint/pkg/tokenlint"
+	"github.com/golangci/plugin-module-register/register"
+	"golang.org/x/tools/go/analysis"
+)
+
+func init() {
+	register.Plugin("tokenlint", New)
+}
+
+// Settings are the plugin's settings in .golangci.yml. Zero values take
+// token-lint's defaults.
+type Settings struct {
+	Threshold     int      `json:"threshold"`
+	TestThreshold int      `json:"test-threshold"`
+	Ratio         float64  `json:"ratio"`
+	Exclude       []string `json:"exclude"` // gitignore-style globs, relative to the directory golangci-lint runs in
+
+	// Generated adds gitignore-style globs, relative to the same
+	// directory, for generated files; GeneratedBuiltin false makes them
+	// replace the built-in patterns, as generated_builtin does in
+	// .token-lint.yaml.
+	Generated        []string `json:"generated"`
+	GeneratedBuiltin *bool    `json:"generated-builtin"`
+}
+
+type plugin struct {
+	config analyzer.Config
+}
+
+// New returns the plugin configured by the raw settings golangci-lint
+// passes in.
+func New(raw any) (register.LinterPlugin, error) {
+	s, err := register.DecodeSettings[Settings](raw)
+	if err != nil {
+		return nil, err
+	}
+	if s.Threshold < 0 || s.TestThreshold < 0 || s.Ratio < 0 {
+		return nil, fmt.Errorf("tokenlint: threshold, test-threshold and ratio must not be negative")
+	}
+	p := &plugin{config: analyzer.Config{
+		Threshold:     s.Threshold,
+		TestThreshold: s.TestThreshold,
+		Ratio:         s.Ratio,
+	}}
+	var globs []*regexp.Regexp
+	for _, pattern := range s.Exclude {
+		re, err := tokenlint.CompileGlob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("tokenlint: exclude %q: %v", pattern, err)
+		}
+		globs = append(globs, re)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, fmt.Errorf("tokenlint: %v", err)
+	}
+	if len(globs) > 0 {
+		p.config.Skip = func(path string) bool { return matchGlobs(globs, wd, path) }
+	}
+	if len(s.Generated) > 0 || s.GeneratedBuiltin != nil {
+		rules := &tokenlint.GeneratedRules{NoBuiltin: s.GeneratedBuiltin != nil && !*s.GeneratedBuiltin}
+		if err := rules.AddPatterns(wd, s.Generated); err != nil {
+			return nil, fmt.Errorf("tokenlint: generated: %v", err)
+		}
+		p.config.Generated = rules
+	}
+	return p, nil
+}
+
+// matchGlobs reports whether path, made relative to dir when it lies
+// beneath it, matches any of globs, like token-lint's exclude patterns.
+func matchGlobs(globs []*regexp.Regexp, dir, path string) bool {
+	if rel, err := filepath.Rel(dir, path); err == nil && filepath.IsAbs(path) && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		path = rel
+	}
+	slashed := filepath.ToSlash(path)
+	for _, re := range globs {
+		if re.MatchString(slashed) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *plugin) BuildAnalyzers() ([]*analysis.Analyzer, error) {
+	return []*analysis.Analyzer{analyzer.New(p.config)}, nil
+}
+
+// GetLoadMode reports that the analyzer needs no type information.
+func (p *plugin) GetLoadMode() string {
+	return register.LoadModeSyntax
+}
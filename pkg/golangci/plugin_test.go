@@ -0,0 +1,81 @@
+package golangci
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/befabri/token-lint/pkg/tokenlint"
+	"github.com/golangci/plugin-module-register/register"
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestPlugin(t *testing.T) {
+	newPlugin, err := register.GetPlugin("tokenlint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := newPlugin(map[string]any{
+		"threshold": 150,
+		"ratio":     1.0,
+		"exclude":   []any{"*_mock.go"},
+		"generated": []any{"zz_*.go"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := p.GetLoadMode(); mode != register.LoadModeSyntax {
+		t.Errorf("load mode = %q, want %q", mode, register.LoadModeSyntax)
+	}
+	analyzers, err := p.BuildAnalyzers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(analyzers) != 1 {
+		t.Fatalf("got %d analyzers, want 1", len(analyzers))
+	}
+	analysistest.Run(t, analysistest.TestData(), analyzers[0], "p")
+}
+
+func TestMatchGlobs(t *testing.T) {
+	var globs []*regexp.Regexp
+	for _, pattern := range []string{"*_mock.go", "internal/legacy/**"} {
+		re, err := tokenlint.CompileGlob(pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+		globs = append(globs, re)
+	}
+	dir := filepath.FromSlash("/repo")
+	tests := map[string]bool{
+		"/repo/p/store_mock.go":           true,
+		"/repo/internal/legacy/old.go":    true,
+		"/repo/internal/legacy.go":        false,
+		"/repo/pkg/internal/legacy/x.go":  false,
+		"/elsewhere/p/store_mock.go":      true,
+		"/elsewhere/internal/legacy/x.go": false,
+	}
+	for path, want := range tests {
+		if got := matchGlobs(globs, dir, filepath.FromSlash(path)); got != want {
+			t.Errorf("matchGlobs(%s) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestNewInvalidSettings(t *testing.T) {
+	tests := []struct {
+		settings map[string]any
+		want     string
+	}{
+		{map[string]any{"treshold": 100}, "unknown field"},
+		{map[string]any{"ratio": -1}, "must not be negative"},
+		{map[string]any{"exclude": []any{"[z-a]"}}, `exclude "[z-a]"`},
+	}
+	for _, tt := range tests {
+		_, err := New(tt.settings)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("New(%v) error = %v, want it to mention %q", tt.settings, err, tt.want)
+		}
+	}
+}
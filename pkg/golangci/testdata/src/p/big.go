@@ -0,0 +1,5 @@
+package p // want `~\d+ tokens, over the 150 token threshold`
+
+// Big carries a comment long enough to take the file past the threshold
+// of one hundred and fifty tokens set in the plugin settings.
+func Big() {}
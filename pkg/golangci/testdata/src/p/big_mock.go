@@ -0,0 +1,5 @@
+package p
+
+// BigMock is just as long as Big, but its file matches the exclude
+// pattern from the plugin settings and is therefore never reported.
+func BigMock() {}
@@ -0,0 +1,5 @@
+package p
+
+// Models is just as long as Big, but its file matches the generated
+// pattern from the plugin settings and is therefore never reported.
+func Models() {}
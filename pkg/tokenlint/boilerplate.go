@@ -0,0 +1,33 @@
+package tokenlint
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+)
+
+// StripBoilerplate returns src without its package clause and import
+// declarations. It returns src unchanged if it does not parse.
+func StripBoilerplate(src []byte) []byte {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, parser.ImportsOnly)
+	if err != nil {
+		return src
+	}
+
+	type span struct{ start, end int }
+	spans := []span{{fset.Position(f.Package).Offset, fset.Position(f.Name.End()).Offset}}
+	for _, d := range f.Decls {
+		if gd, ok := d.(*ast.GenDecl); ok && gd.Tok == token.IMPORT {
+			spans = append(spans, span{fset.Position(gd.Pos()).Offset, fset.Position(gd.End()).Offset})
+		}
+	}
+
+	out := make([]byte, 0, len(src))
+	prev := 0
+	for _, s := range spans {
+		out = append(out, src[prev:s.start]...)
+		prev = s.end
+	}
+	return append(out, src[prev:]...)
+}
@@ -0,0 +1,46 @@
+package tokenlint
+
+import (
+	"fmt"
+
+	"github.com/pkoukk/tiktoken-go"
+	tiktoken_loader "github.com/pkoukk/tiktoken-go-loader"
+)
+
+// BPEEncodings lists the BPE vocabularies embedded in the package.
+var BPEEncodings = []string{"cl100k_base", "o200k_base"}
+
+func init() {
+	// Use the vocabularies embedded by the loader module instead of
+	// downloading them on first use.
+	tiktoken.SetBpeLoader(tiktoken_loader.NewOfflineLoader())
+}
+
+// loadBPE returns the embedded BPE encoding with the given name.
+func loadBPE(encoding string) (*tiktoken.Tiktoken, error) {
+	for _, name := range BPEEncodings {
+		if name == encoding {
+			return tiktoken.GetEncoding(encoding)
+		}
+	}
+	return nil, fmt.Errorf("unknown BPE encoding %q (want one of %v)", encoding, BPEEncodings)
+}
+
+// bpeTokenizer counts tokens exactly with a BPE vocabulary.
+type bpeTokenizer struct {
+	enc *tiktoken.Tiktoken
+}
+
+// NewBPETokenizer returns a Tokenizer that counts tokens exactly with the
+// embedded BPE vocabulary of the given name, one of BPEEncodings.
+func NewBPETokenizer(encoding string) (Tokenizer, error) {
+	enc, err := loadBPE(encoding)
+	if err != nil {
+		return nil, err
+	}
+	return bpeTokenizer{enc}, nil
+}
+
+func (t bpeTokenizer) Count(content []byte) int {
+	return len(t.enc.EncodeOrdinary(string(content)))
+}
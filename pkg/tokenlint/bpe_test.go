@@ -0,0 +1,22 @@
+package tokenlint
+
+import "testing"
+
+func TestLoadBPE(t *testing.T) {
+	for _, encoding := range BPEEncodings {
+		t.Run(encoding, func(t *testing.T) {
+			enc, err := loadBPE(encoding)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// "hello world" is two tokens in both vocabularies.
+			if n := len(enc.EncodeOrdinary("hello world")); n != 2 {
+				t.Errorf("hello world = %d tokens, want 2", n)
+			}
+		})
+	}
+
+	if _, err := loadBPE("nope"); err == nil {
+		t.Error("loadBPE accepted an unknown encoding")
+	}
+}
@@ -0,0 +1,28 @@
+package tokenlint
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+)
+
+// Categories are the kinds of file that tokenize differently enough to
+// deserve their own ratio, in the order they are tested.
+var Categories = []string{"protobuf", "generated", "test", "handler"}
+
+// Category classifies a file by its path and content, returning "" for
+// ordinary handwritten code.
+func Category(path string, content []byte) string {
+	path = filepath.ToSlash(path)
+	switch {
+	case strings.HasSuffix(path, ".pb.go"):
+		return "protobuf"
+	case IsGenerated(path) || GeneratedHeader(bytes.NewReader(content)):
+		return "generated"
+	case strings.HasSuffix(path, "_test.go"):
+		return "test"
+	case strings.Contains(strings.ToLower(path), "handler"):
+		return "handler"
+	}
+	return ""
+}
@@ -0,0 +1,24 @@
+package tokenlint
+
+import "testing"
+
+func TestFileCategory(t *testing.T) {
+	tests := []struct {
+		path    string
+		content string
+		want    string
+	}{
+		{"main.go", "package tokenlint\n", ""},
+		{"api/user.pb.go", "// Code generated by protoc-gen-go. DO NOT EDIT.\npackage api\n", "protobuf"},
+		{"db/query.sql.go", "package db\n", "generated"},
+		{"mocks/store.go", "// Code generated by MockGen. DO NOT EDIT.\npackage mocks\n", "generated"},
+		{"server/user_handler_test.go", "package server\n", "test"},
+		{"server/user_handler.go", "package server\n", "handler"},
+		{"internal/handlers/auth.go", "package handlers\n", "handler"},
+	}
+	for _, tt := range tests {
+		if got := Category(tt.path, []byte(tt.content)); got != tt.want {
+			t.Errorf("Category(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package tokenlint
+
+import (
+	"bufio"
+	"bytes"
+	"strconv"
+	"strings"
+)
+
+// Directives are the //tokenlint: comments of a file header, the lines
+// before the package clause, where //go:build lines live too.
+type Directives struct {
+	Ignore       bool   // from //tokenlint:ignore REASON
+	IgnoreReason string // the REASON, which may be empty
+	Threshold    int    // from //tokenlint:threshold=N; 0 if unset
+	Invalid      string // first malformed directive, if any
+}
+
+// ParseDirectives reads the directives from the header of a Go file.
+func ParseDirectives(src []byte) Directives {
+	var d Directives
+	sc := bufio.NewScanner(bytes.NewReader(src))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if strings.HasPrefix(line, "package ") {
+			break
+		}
+		rest, ok := strings.CutPrefix(line, "//tokenlint:")
+		if !ok {
+			continue
+		}
+		name, arg, _ := strings.Cut(rest, " ")
+		switch {
+		case name == "ignore":
+			d.Ignore = true
+			d.IgnoreReason = strings.TrimSpace(arg)
+		case strings.HasPrefix(name, "threshold="):
+			n, err := strconv.Atoi(strings.TrimPrefix(name, "threshold="))
+			if err != nil || n <= 0 {
+				if d.Invalid == "" {
+					d.Invalid = line
+				}
+				continue
+			}
+			d.Threshold = n
+		default:
+			if d.Invalid == "" {
+				d.Invalid = line
+			}
+		}
+	}
+	return d
+}
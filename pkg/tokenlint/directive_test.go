@@ -0,0 +1,53 @@
+package tokenlint
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseThresholdDirective(t *testing.T) {
+	d := ParseDirectives([]byte("//tokenlint:threshold=40000\npackage a\n"))
+	if d.Threshold != 40000 || d.Invalid != "" {
+		t.Errorf("threshold = %d, invalid = %q; want 40000", d.Threshold, d.Invalid)
+	}
+	for _, src := range []string{"//tokenlint:threshold=big\npackage a\n", "//tokenlint:threshold=0\npackage a\n", "//tokenlint:treshold=1\npackage a\n"} {
+		if d := ParseDirectives([]byte(src)); d.Threshold != 0 || d.Invalid == "" {
+			t.Errorf("ParseDirectives(%q) = %+v, want invalid", src, d)
+		}
+	}
+}
+
+func TestParseDirectives(t *testing.T) {
+	tests := []struct {
+		src    string
+		ignore bool
+		reason string
+	}{
+		{"//tokenlint:ignore generated lookup tables\npackage a\n", true, "generated lookup tables"},
+		{"//go:build linux\n\n// Package a.\n//tokenlint:ignore   spec mirror  \npackage a\n", true, "spec mirror"},
+		{"//tokenlint:ignore\npackage a\n", true, ""},
+		{"package a\n\n//tokenlint:ignore too late\n", false, ""},
+		{"// tokenlint:ignore not a directive\npackage a\n", false, ""},
+	}
+	for _, tt := range tests {
+		d := ParseDirectives([]byte(tt.src))
+		if d.Ignore != tt.ignore || d.IgnoreReason != tt.reason {
+			t.Errorf("ParseDirectives(%q) = %v %q, want %v %q", tt.src, d.Ignore, d.IgnoreReason, tt.ignore, tt.reason)
+		}
+	}
+}
+
+func FuzzParseDirectives(f *testing.F) {
+	f.Add([]byte("//tokenlint:ignore generated table\npackage a\n"))
+	f.Add([]byte("//go:build linux\n//tokenlint:threshold=40000\n\npackage a\n"))
+	f.Add([]byte("//tokenlint:threshold=\n//tokenlint:\npackage"))
+	f.Fuzz(func(t *testing.T, src []byte) {
+		d := ParseDirectives(src)
+		if d.Threshold < 0 {
+			t.Errorf("negative threshold %d", d.Threshold)
+		}
+		if d.Ignore && d.IgnoreReason != strings.TrimSpace(d.IgnoreReason) {
+			t.Errorf("reason %q not trimmed", d.IgnoreReason)
+		}
+	})
+}
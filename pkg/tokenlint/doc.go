@@ -0,0 +1,27 @@
+// Package tokenlint finds Go files with too many tokens for LLM context
+// windows. It is the core of the token-lint command, for bots, dashboards
+// and other tools that would rather embed the check than run it:
+//
+//	report, err := tokenlint.Scan(ctx, tokenlint.Options{
+//		Paths:     []string{"./..."},
+//		Threshold: 20000,
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	for _, f := range report.Violations {
+//		fmt.Printf("%s: ~%d tokens (limit %d)\n", f.Path, f.Tokens, f.Threshold)
+//	}
+//
+// Scan discovers files like the go tool: dot- and underscore-prefixed
+// names, .gitignore'd paths and generated code are skipped unless
+// ExpandOptions say otherwise. Each file's threshold starts from
+// Options.Threshold and can be replaced by TestThreshold for tests, a
+// PathThreshold override and a //tokenlint:threshold=N comment before the
+// package clause, in that order; //tokenlint:ignore followed by a reason
+// suppresses the file instead.
+//
+// Counts are estimated from byte counts with Options.Ratio unless a
+// Tokenizer is given, such as the exact BPE ones from NewBPETokenizer.
+// Configuration files and the output formats are features of the command.
+package tokenlint
@@ -0,0 +1,125 @@
+package tokenlint
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ExpandOptions controls file discovery.
+type ExpandOptions struct {
+	IncludeHidden    bool            // scan dot- and underscore-prefixed files and directories
+	NoGitignore      bool            // expand recursive patterns without honoring .gitignore files
+	IncludeGenerated bool            // keep files with generated paths or a "Code generated" header
+	Generated        *GeneratedRules // generated path patterns; nil uses IsGenerated
+}
+
+// Expand resolves path arguments to Go files: files, directories and
+// recursive patterns such as "./...". Files reachable from several
+// overlapping arguments or through symlinks are returned once, under the
+// first path they were found by. Like the go tool, files and directories
+// whose names start with "." or "_" are skipped unless opts.IncludeHidden
+// is set; explicitly named files are always included. Discovery stops
+// with ctx.Err() once ctx is canceled.
+func Expand(ctx context.Context, args []string, opts ExpandOptions) ([]string, error) {
+	var files []string
+	seen := make(map[string]bool)
+	add := func(path string) {
+		key := path
+		if abs, err := filepath.Abs(path); err == nil {
+			key = abs
+		}
+		// Symlinks are keyed by their target, so a file reachable under
+		// several names is analyzed once.
+		if real, err := filepath.EvalSymlinks(key); err == nil {
+			key = real
+		}
+		if !seen[key] {
+			seen[key] = true
+			files = append(files, path)
+		}
+	}
+
+	for _, arg := range args {
+		if err := ctx.Err(); err != nil {
+			return files, err
+		}
+
+		if dir, ok := SplitRecursive(arg); ok {
+			var ignores gitignores
+			if !opts.NoGitignore {
+				var err error
+				if ignores, err = newGitignores(dir); err != nil {
+					return nil, err
+				}
+			}
+			// Recursively find .go files in directory
+			err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+				if err != nil {
+					return err
+				}
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+				if path != dir && (!opts.IncludeHidden && IsHidden(info.Name()) || ignores.ignored(path, info.IsDir())) {
+					if info.IsDir() {
+						return filepath.SkipDir
+					}
+					return nil
+				}
+				if info.IsDir() && path != dir && ignores != nil {
+					if abs, err := filepath.Abs(path); err == nil {
+						if err := ignores.load(abs); err != nil {
+							return err
+						}
+					}
+				}
+				if !info.IsDir() && strings.HasSuffix(path, ".go") &&
+					(opts.IncludeGenerated || !opts.Generated.Match(path) && !HasGeneratedHeader(path)) {
+					add(path)
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		} else if info, err := os.Stat(arg); err == nil && info.IsDir() {
+			// Find .go files in directory (non-recursive)
+			entries, err := os.ReadDir(arg)
+			if err != nil {
+				return nil, err
+			}
+			for _, e := range entries {
+				if !e.IsDir() && strings.HasSuffix(e.Name(), ".go") && (opts.IncludeHidden || !IsHidden(e.Name())) {
+					add(filepath.Join(arg, e.Name()))
+				}
+			}
+		} else {
+			// Single file
+			add(arg)
+		}
+	}
+
+	return files, nil
+}
+
+// SplitRecursive reports whether arg is a recursive pattern such as
+// "./...", "dir/..." or "dir\..." and returns its root directory. Both
+// separators are accepted, and mixed, on every platform.
+func SplitRecursive(arg string) (string, bool) {
+	dir, ok := strings.CutSuffix(strings.ReplaceAll(arg, `\`, "/"), "/...")
+	if !ok {
+		return "", false
+	}
+	if dir == "" {
+		dir = "/"
+	}
+	return filepath.FromSlash(dir), true
+}
+
+// IsHidden reports whether the go tool would ignore a file or directory
+// with this name.
+func IsHidden(name string) bool {
+	return strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")
+}
@@ -0,0 +1,176 @@
+package tokenlint
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSplitRecursive(t *testing.T) {
+	tests := []struct {
+		arg     string
+		wantDir string
+		wantOK  bool
+	}{
+		{"./...", ".", true},
+		{`.\...`, ".", true},
+		{"pkg/...", "pkg", true},
+		{`pkg\...`, "pkg", true},
+		{`pkg\sub/...`, filepath.FromSlash("pkg/sub"), true},
+		{"pkg", "", false},
+		{"pkg/file.go", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.arg, func(t *testing.T) {
+			dir, ok := SplitRecursive(tt.arg)
+			if dir != tt.wantDir || ok != tt.wantOK {
+				t.Errorf("SplitRecursive(%q) = %q, %v, want %q, %v", tt.arg, dir, ok, tt.wantDir, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestExpand(t *testing.T) {
+	dir := t.TempDir()
+
+	files := []string{"a.go", "b.go", "c.txt"}
+	for _, f := range files {
+		path := filepath.Join(dir, f)
+		if err := os.WriteFile(path, []byte("test"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	subdir := filepath.Join(dir, "sub")
+	if err := os.Mkdir(subdir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(subdir, "d.go"), []byte("test"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("single file", func(t *testing.T) {
+		got, err := Expand(context.Background(), []string{filepath.Join(dir, "a.go")}, ExpandOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 1 {
+			t.Errorf("got %d files, want 1", len(got))
+		}
+	})
+
+	t.Run("directory non-recursive", func(t *testing.T) {
+		got, err := Expand(context.Background(), []string{dir}, ExpandOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 2 {
+			t.Errorf("got %d files, want 2 (.go files only)", len(got))
+		}
+	})
+
+	t.Run("directory recursive", func(t *testing.T) {
+		got, err := Expand(context.Background(), []string{dir + "/..."}, ExpandOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 3 {
+			t.Errorf("got %d files, want 3", len(got))
+		}
+	})
+
+	t.Run("directory recursive with backslash", func(t *testing.T) {
+		got, err := Expand(context.Background(), []string{dir + `\...`}, ExpandOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 3 {
+			t.Errorf("got %d files, want 3", len(got))
+		}
+	})
+
+	t.Run("overlapping arguments", func(t *testing.T) {
+		got, err := Expand(context.Background(), []string{
+			dir + "/...",
+			dir,
+			filepath.Join(dir, "a.go"),
+			filepath.Join(dir, "sub", "..", "b.go"),
+		}, ExpandOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != 3 {
+			t.Errorf("got %d files %v, want 3 after deduplication", len(got), got)
+		}
+	})
+}
+
+func TestExpandSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	pkg := filepath.Join(dir, "pkg")
+	if err := os.Mkdir(pkg, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(pkg, "a.go"), []byte("package pkg\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(pkg, "a.go"), filepath.Join(dir, "alias.go")); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	if err := os.Symlink(pkg, filepath.Join(dir, "linked")); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Expand(context.Background(), []string{
+		dir + "/...",
+		filepath.Join(dir, "linked", "a.go"),
+		filepath.Join(dir, "linked"),
+	}, ExpandOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 {
+		t.Errorf("got %v, want one file after resolving symlinks", got)
+	}
+}
+
+func TestExpandHidden(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, f := range []string{"a.go", ".hidden.go", "_tmp.go", ".cache/b.go", "_build/c.go", "pkg/d.go"} {
+		path := filepath.Join(dir, f)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("test"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		arg  string
+		opts ExpandOptions
+		want int
+	}{
+		{"recursive default", dir + "/...", ExpandOptions{}, 2},
+		{"recursive include hidden", dir + "/...", ExpandOptions{IncludeHidden: true}, 6},
+		{"directory default", dir, ExpandOptions{}, 1},
+		{"directory include hidden", dir, ExpandOptions{IncludeHidden: true}, 3},
+		{"explicit hidden file", filepath.Join(dir, ".cache", "b.go"), ExpandOptions{}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Expand(context.Background(), []string{tt.arg}, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != tt.want {
+				t.Errorf("got %d files %v, want %d", len(got), got, tt.want)
+			}
+		})
+	}
+}
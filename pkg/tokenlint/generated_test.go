@@ -0,0 +1,52 @@
+package tokenlint
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGeneratedHeader(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want bool
+	}{
+		{"marker", "// Code generated by protoc-gen-go. DO NOT EDIT.\n\npackage pb\n", true},
+		{"after license", "// Copyright 2026 Example\n\n/*\n * Licensed under MIT.\n */\n\n// Code generated by stringer; DO NOT EDIT.\r\n\npackage a\n", true},
+		{"after build tag", "//go:build linux\n\n// Code generated by mockgen. DO NOT EDIT.\npackage mocks\n", true},
+		{"handwritten", "// Package a does things.\npackage a\n", false},
+		{"after package", "package a\n\n// Code generated by hand. DO NOT EDIT.\n", false},
+		{"no period", "// Code generated by tool. DO NOT EDIT\npackage a\n", false},
+		{"indented", "  // Code generated by tool. DO NOT EDIT.\npackage a\n", false},
+	}
+	for _, tt := range tests {
+		if got := GeneratedHeader(strings.NewReader(tt.src)); got != tt.want {
+			t.Errorf("%s: GeneratedHeader = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIsGenerated(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"foo.go", false},
+		{"pkg/handler.go", false},
+		{"internal/gen/types.go", true},
+		{"foo_gen.go", true},
+		{"api.pb.go", true},
+		{"queries.sql.go", true},
+		{"gen/foo.go", false}, // must have /gen/ not just gen/
+		{"/gen/foo.go", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got := IsGenerated(tt.path)
+			if got != tt.want {
+				t.Errorf("IsGenerated(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package tokenlint
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// gitignores holds the .gitignore files that apply during a directory
+// walk, keyed by the absolute directory containing each.
+type gitignores map[string]*IgnoreRules
+
+// newGitignores loads the .gitignore files in root and, if root lies
+// inside a git working tree, in its parents up to the top of that tree.
+// Outside a working tree only root's own file is loaded.
+func newGitignores(root string) (gitignores, error) {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	g := make(gitignores)
+	if err := g.load(abs); err != nil {
+		return nil, err
+	}
+	var parents []string
+	for dir := abs; ; {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			for _, p := range parents {
+				if err := g.load(p); err != nil {
+					return nil, err
+				}
+			}
+			break
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+		parents = append(parents, dir)
+	}
+	return g, nil
+}
+
+// load reads dir's .gitignore, if it has one.
+func (g gitignores) load(dir string) error {
+	ig, err := LoadIgnoreFile(filepath.Join(dir, ".gitignore"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	g[dir] = ig
+	return nil
+}
+
+// ignored reports whether path, a directory if isDir, is excluded. As in
+// git, the nearest .gitignore with a matching pattern decides.
+func (g gitignores) ignored(path string, isDir bool) bool {
+	if len(g) == 0 {
+		return false
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	for dir := filepath.Dir(abs); ; {
+		if ig, ok := g[dir]; ok {
+			if rel, err := filepath.Rel(dir, abs); err == nil {
+				if ignored, matched := ig.decide(filepath.ToSlash(rel), isDir); matched {
+					return ignored
+				}
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return false
+		}
+		dir = parent
+	}
+}
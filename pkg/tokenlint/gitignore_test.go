@@ -0,0 +1,76 @@
+package tokenlint
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestExpandArgsGitignore(t *testing.T) {
+	dir := t.TempDir()
+	// Mark dir as the top of a working tree, so parent .gitignore files
+	// apply to walks that start further down.
+	if err := os.Mkdir(filepath.Join(dir, ".git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		".gitignore":           "build/\n*.tmp.go\n",
+		"main.go":              "package tokenlint\n",
+		"scratch.tmp.go":       "package tokenlint\n",
+		"build/out.go":         "package build\n",
+		"pkg/.gitignore":       "fixtures/\n!keep.tmp.go\n",
+		"pkg/a.go":             "package pkg\n",
+		"pkg/keep.tmp.go":      "package pkg\n",
+		"pkg/fixtures/big.go":  "package fixtures\n",
+		"other/fixtures/f.go":  "package fixtures\n",
+		"other/drop.tmp.go":    "package other\n",
+		"other/nested/deep.go": "package nested\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rel := func(got []string) []string {
+		var out []string
+		for _, p := range got {
+			r, _ := filepath.Rel(dir, p)
+			out = append(out, filepath.ToSlash(r))
+		}
+		slices.Sort(out)
+		return out
+	}
+
+	got, err := Expand(context.Background(), []string{dir + "/..."}, ExpandOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"main.go", "other/fixtures/f.go", "other/nested/deep.go", "pkg/a.go", "pkg/keep.tmp.go"}
+	if !slices.Equal(rel(got), want) {
+		t.Errorf("Expand = %v, want %v", rel(got), want)
+	}
+
+	// Starting below the top-level .gitignore still honors it.
+	got, err = Expand(context.Background(), []string{filepath.Join(dir, "other") + "/..."}, ExpandOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"other/fixtures/f.go", "other/nested/deep.go"}; !slices.Equal(rel(got), want) {
+		t.Errorf("Expand(other/...) = %v, want %v", rel(got), want)
+	}
+
+	got, err = Expand(context.Background(), []string{dir + "/..."}, ExpandOptions{NoGitignore: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 9 {
+		t.Errorf("Expand with NoGitignore = %v, want all 9 files", rel(got))
+	}
+}
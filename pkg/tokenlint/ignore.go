@@ -0,0 +1,121 @@
+package tokenlint
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ignoreRule is one line of an ignore file.
+type ignoreRule struct {
+	re      *regexp.Regexp
+	negate  bool // "!pattern" re-includes matching paths
+	dirOnly bool // "pattern/" matches directories only
+}
+
+// IgnoreRules is a parsed ignore file, such as .tokenlintignore. The last matching rule decides,
+// so a negated pattern can re-include a file below an ignored directory.
+type IgnoreRules struct {
+	dir   string // directory the patterns are relative to
+	rules []ignoreRule
+}
+
+// LoadIgnoreFile reads an ignore file whose patterns are relative to the
+// directory containing it.
+func LoadIgnoreFile(name string) (*IgnoreRules, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	dir, err := filepath.Abs(filepath.Dir(name))
+	if err != nil {
+		return nil, err
+	}
+	ig, err := ParseIgnore(f, dir)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	return ig, nil
+}
+
+// ParseIgnore parses gitignore syntax: blank lines and lines starting
+// with "#" are skipped, "!" negates a pattern and a trailing "/" limits it
+// to directories. "\#" and "\!" match a literal leading character.
+func ParseIgnore(r io.Reader, dir string) (*IgnoreRules, error) {
+	ig := &IgnoreRules{dir: dir}
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimRight(sc.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := ig.Add(line); err != nil {
+			return nil, fmt.Errorf("line %d: %v", n, err)
+		}
+	}
+	return ig, sc.Err()
+}
+
+// Add appends a rule for pattern, which may be negated with "!".
+func (ig *IgnoreRules) Add(pattern string) error {
+	var rule ignoreRule
+	if strings.HasPrefix(pattern, "!") {
+		rule.negate = true
+		pattern = pattern[1:]
+	}
+	rule.dirOnly = strings.HasSuffix(pattern, "/")
+	re, err := CompileGlob(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid pattern %q: %v", pattern, err)
+	}
+	rule.re = re
+	ig.rules = append(ig.rules, rule)
+	return nil
+}
+
+// Ignored reports whether name, a file, is excluded. Files outside the
+// ignore file's directory never are.
+func (ig *IgnoreRules) Ignored(name string) bool {
+	abs, err := filepath.Abs(name)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(ig.dir, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return false
+	}
+	return ig.match(filepath.ToSlash(rel))
+}
+
+// match reports whether the slash-separated file path rel is excluded.
+func (ig *IgnoreRules) match(rel string) bool {
+	ignored, _ := ig.decide(rel, false)
+	return ignored
+}
+
+// decide applies the rules to the slash-separated path rel, a directory if
+// isDir. It reports whether rel is excluded and whether any rule matched
+// at all, so that rules in a parent directory can decide otherwise.
+func (ig *IgnoreRules) decide(rel string, isDir bool) (ignored, matched bool) {
+	for i := len(ig.rules) - 1; i >= 0; i-- {
+		r := ig.rules[i]
+		target := rel
+		if r.dirOnly && !isDir {
+			// A directory pattern matches the file through its parent;
+			// compiled globs also match everything beneath a directory.
+			if target = path.Dir(rel); target == "." {
+				continue
+			}
+		}
+		if r.re.MatchString(target) {
+			return !r.negate, true
+		}
+	}
+	return false, false
+}
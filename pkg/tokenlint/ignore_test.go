@@ -0,0 +1,79 @@
+package tokenlint
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIgnoreRules(t *testing.T) {
+	ig, err := ParseIgnore(strings.NewReader(`# fixtures and vendored code
+testdata/
+vendor/**
+*.golden.go
+!testdata/keep.go
+\#odd.go
+build/
+`), "/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"main.go", false},
+		{"testdata/big.go", true},
+		{"pkg/testdata/big.go", true},
+		{"testdata/keep.go", false}, // negation re-includes
+		{"vendor/x/y.go", true},
+		{"snap.golden.go", true},
+		{"pkg/snap.golden.go", true},
+		{"#odd.go", true},
+		{"build", false}, // directory-only pattern, but a file
+		{"build/out.go", true},
+	}
+	for _, tt := range tests {
+		if got := ig.match(tt.path); got != tt.want {
+			t.Errorf("match(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestLoadIgnoreFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".tokenlintignore")
+	if err := os.WriteFile(path, []byte("fixtures/\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	ig, err := LoadIgnoreFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ig.Ignored(filepath.Join(dir, "pkg", "fixtures", "a.go")) {
+		t.Error("fixture not ignored")
+	}
+	if ig.Ignored(filepath.Join(dir, "pkg", "a.go")) {
+		t.Error("regular file ignored")
+	}
+	if ig.Ignored(filepath.Join(filepath.Dir(dir), "fixtures", "a.go")) {
+		t.Error("file outside the ignore file's directory ignored")
+	}
+}
+
+func FuzzParseIgnore(f *testing.F) {
+	f.Add("# comment\ntestdata/\n!keep.go\n**/mocks/**\n/root.go\n*.pb.go\n")
+	f.Add("\\#literal\n\\!bang\n[a-z]*.go\nfoo/**/bar\n")
+	f.Fuzz(func(t *testing.T, patterns string) {
+		ig, err := ParseIgnore(strings.NewReader(patterns), "/repo")
+		if err != nil {
+			return
+		}
+		for _, rel := range []string{"a.go", "testdata/x.go", "pkg/mocks/m.go", "deep/a/b/c.pb.go"} {
+			ig.decide(rel, false)
+			ig.decide(rel, true)
+		}
+		ig.Ignored("/repo/pkg/a.go")
+	})
+}
@@ -0,0 +1,41 @@
+package tokenlint
+
+import "testing"
+
+func TestCompileGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "pkg/sub/main.go", true},
+		{"*.go", "main.txt", false},
+		{"docs/", "docs/index.md", true},
+		{"docs/", "pkg/docs/index.md", true},
+		{"/docs", "pkg/docs/index.md", false},
+		{"pkg/api", "pkg/api/client.go", true},
+		{"pkg/api", "other/pkg/api/client.go", false},
+		{"internal/*.go", "internal/a.go", true},
+		{"internal/*.go", "internal/sub/a.go", false},
+		{"**/mocks/**", "a/b/mocks/m.go", true},
+		{"**/mocks/**", "mocks/m.go", true},
+		{"cmd/**/main.go", "cmd/main.go", true},
+		{"cmd/**/main.go", "cmd/tool/x/main.go", true},
+		{"zz_generated*.go", "api/zz_generated.deepcopy.go", true},
+		{"file?.go", "file1.go", true},
+		{"file[0-9].go", "file7.go", true},
+		{"file[!0-9].go", "file7.go", false},
+		{"*", "anything/at/all.go", true},
+	}
+
+	for _, tt := range tests {
+		re, err := CompileGlob(tt.pattern)
+		if err != nil {
+			t.Fatalf("CompileGlob(%q): %v", tt.pattern, err)
+		}
+		if got := re.MatchString(tt.path); got != tt.want {
+			t.Errorf("CompileGlob(%q) match %q = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package tokenlint
+
+import "context"
+
+// Tokenizer counts the tokens in a piece of source.
+type Tokenizer interface {
+	Count(content []byte) int
+}
+
+// ContextTokenizer is a Tokenizer whose counts can be canceled, such as
+// one backed by a remote service. Analyze counts with CountContext and its
+// own context; a canceled count may return any number.
+type ContextTokenizer interface {
+	Tokenizer
+	CountContext(ctx context.Context, content []byte) int
+}
+
+// RatioTokenizer estimates tokens as a fixed fraction of the byte count.
+type RatioTokenizer float64
+
+func (r RatioTokenizer) Count(content []byte) int {
+	return int(float64(len(content)) * float64(r))
+}
@@ -0,0 +1,304 @@
+package tokenlint
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Defaults match the token-lint command.
+const (
+	DefaultThreshold = 25000
+	DefaultRatio     = 0.65
+)
+
+// Options controls how files are found, counted and judged. The zero
+// value checks ./... against DefaultThreshold, estimating with
+// DefaultRatio.
+type Options struct {
+	// Paths, Expand and Skip select the files for Scan; Analyze is given
+	// its files directly.
+	Paths  []string               // files, directories and patterns such as ./...; none means ./...
+	Expand ExpandOptions          // file discovery
+	Skip   func(path string) bool // leaves out matching files, e.g. (*IgnoreRules).Ignored, if set
+
+	Threshold     int                                  // maximum tokens per file; 0 uses DefaultThreshold
+	TestThreshold int                                  // threshold for _test.go files; 0 uses Threshold
+	PathThreshold func(path string) (int, bool)        // per-path override of the threshold, if set
+	Limit         func(path string, threshold int) int // final adjustment of each file's threshold, if set
+
+	Ratio          float64            // tokens per byte for estimates; 0 uses DefaultRatio
+	CategoryRatios map[string]float64 // ratio per Category, for estimates
+	Tokenizer      Tokenizer          // counting backend; nil estimates from Ratio
+	IgnoreImports  bool               // exclude the package clause and imports from counts
+	MaxFileBytes   int64              // files larger than this are skipped; 0 means no limit
+
+	Read func(path string) ([]byte, error) // file source; nil reads the file system
+	Jobs int                               // files analyzed concurrently; 0 uses GOMAXPROCS
+
+	// Inspect is called with each file and its content as soon as it is
+	// measured, possibly concurrently, for callers that look closer at
+	// some files. OnFile is called as each file is analyzed, in order.
+	// Warnf receives problems with single files, such as unreadable ones,
+	// in order too. Each is optional.
+	Inspect func(f File, content []byte)
+	OnFile  func(f File)
+	Warnf   func(format string, args ...any)
+}
+
+// File is the analysis of one file.
+type File struct {
+	Path       string
+	Tokens     int
+	Chars      int    // bytes counted, after IgnoreImports
+	Threshold  int    // effective threshold for this file
+	SHA256     string // hex SHA-256 of the content as read
+	Category   string // Category, "" for ordinary code
+	Suppressed string // reason given by a //tokenlint:ignore directive
+}
+
+// Over reports whether f exceeds its threshold without being suppressed.
+func (f File) Over() bool {
+	return f.Tokens > f.Threshold && f.Suppressed == ""
+}
+
+// Report is the outcome of a scan.
+type Report struct {
+	Files      []File // every analyzed file, in the order of discovery
+	Violations []File // the files that are Over, in the same order
+}
+
+// Scan expands opts.Paths and analyzes the files found. If ctx is
+// canceled it returns the report gathered so far with ctx.Err().
+func Scan(ctx context.Context, opts Options) (Report, error) {
+	paths := opts.Paths
+	if len(paths) == 0 {
+		paths = []string{"./..."}
+	}
+	files, err := Expand(ctx, paths, opts.Expand)
+	if err != nil {
+		return Report{}, err
+	}
+	if opts.Skip != nil {
+		kept := files[:0]
+		for _, path := range files {
+			if !opts.Skip(path) {
+				kept = append(kept, path)
+			}
+		}
+		files = kept
+	}
+	r := Analyze(ctx, files, opts)
+	return r, ctx.Err()
+}
+
+// Analyze counts tokens for each file, analyzing up to opts.Jobs files at
+// once. The report, warnings and OnFile calls follow the order of files
+// regardless. Files that are skipped or cannot be read are left out after
+// a warning. If ctx is canceled it stops early and returns the files
+// analyzed so far.
+func Analyze(ctx context.Context, files []string, opts Options) Report {
+	opts = opts.withDefaults()
+	var r Report
+
+	// Workers take indexes from next and fill slot i before closing
+	// done[i]; the caller's goroutine consumes the slots in order.
+	type slot struct {
+		f        File
+		ok       bool
+		warnings []string
+	}
+	slots := make([]slot, len(files))
+	done := make([]chan struct{}, len(files))
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+	next := make(chan int)
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	defer close(stop)
+
+	jobs := opts.Jobs
+	if jobs <= 0 {
+		jobs = runtime.GOMAXPROCS(0)
+	}
+	for range min(jobs, len(files)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				s := &slots[i]
+				warnf := func(format string, args ...any) {
+					s.warnings = append(s.warnings, fmt.Sprintf(format, args...))
+				}
+				s.f, s.ok = measure(ctx, files[i], opts, warnf)
+				close(done[i])
+			}
+		}()
+	}
+	go func() {
+		defer close(next)
+		for i := range files {
+			select {
+			case next <- i:
+			case <-stop:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for i := range files {
+		if ctx.Err() != nil {
+			break
+		}
+		select {
+		case <-done[i]:
+		case <-ctx.Done():
+			return r
+		}
+		s := slots[i]
+		if opts.Warnf != nil {
+			for _, w := range s.warnings {
+				opts.Warnf("%s", w)
+			}
+		}
+		if !s.ok {
+			continue
+		}
+		r.Files = append(r.Files, s.f)
+		if opts.OnFile != nil {
+			opts.OnFile(s.f)
+		}
+		if s.f.Over() {
+			r.Violations = append(r.Violations, s.f)
+		}
+	}
+
+	return r
+}
+
+// measure counts the tokens of one file. It reports ok false for files
+// that are skipped or cannot be read, after explaining why through warnf.
+// It may run concurrently with itself.
+func measure(ctx context.Context, path string, opts Options, warnf func(format string, args ...any)) (f File, ok bool) {
+	if opts.MaxFileBytes > 0 && opts.Read == nil {
+		if info, err := os.Stat(path); err == nil && info.Size() > opts.MaxFileBytes {
+			warnf("skipping %s: %d bytes exceeds the %d byte limit", path, info.Size(), opts.MaxFileBytes)
+			return f, false
+		}
+	}
+
+	read := opts.Read
+	if read == nil {
+		read = os.ReadFile
+	}
+	content, err := read(path)
+	if err != nil {
+		warnf("%v", err)
+		return f, false
+	}
+	if opts.MaxFileBytes > 0 && opts.Read != nil && int64(len(content)) > opts.MaxFileBytes {
+		warnf("skipping %s: %d bytes exceeds the %d byte limit", path, len(content), opts.MaxFileBytes)
+		return f, false
+	}
+
+	counted := content
+	if opts.IgnoreImports {
+		counted = StripBoilerplate(content)
+	}
+	category := Category(path, content)
+	sum := sha256.Sum256(content)
+	dirs := ParseDirectives(content)
+	if dirs.Invalid != "" {
+		warnf("%s: malformed directive %q ignored", path, dirs.Invalid)
+	}
+	if dirs.Ignore && dirs.IgnoreReason == "" {
+		warnf("%s: //tokenlint:ignore needs a reason; directive ignored", path)
+		dirs.Ignore = false
+	}
+	f = File{
+		Path:      path,
+		Tokens:    opts.countFile(ctx, category, counted),
+		Chars:     len(counted),
+		Threshold: opts.fileThreshold(path, dirs),
+		SHA256:    hex.EncodeToString(sum[:]),
+		Category:  category,
+	}
+	if dirs.Ignore {
+		f.Suppressed = dirs.IgnoreReason
+	}
+	if opts.Inspect != nil {
+		opts.Inspect(f, content)
+	}
+	return f, true
+}
+
+// withDefaults fills in the zero settings that have defaults.
+func (o Options) withDefaults() Options {
+	if o.Threshold == 0 {
+		o.Threshold = DefaultThreshold
+	}
+	if o.Ratio == 0 {
+		o.Ratio = DefaultRatio
+	}
+	return o
+}
+
+// Count returns the token count of content with o's tokenizer.
+func (o Options) Count(content []byte) int {
+	return o.count(context.Background(), content)
+}
+
+// count is Count for a tokenizer that may honor ctx.
+func (o Options) count(ctx context.Context, content []byte) int {
+	if t, ok := o.Tokenizer.(ContextTokenizer); ok {
+		return t.CountContext(ctx, content)
+	}
+	if o.Tokenizer != nil {
+		return o.Tokenizer.Count(content)
+	}
+	return RatioTokenizer(o.withDefaults().Ratio).Count(content)
+}
+
+// countFile returns the token count of counted, the part of a file of the
+// given category that is measured. Ratio estimates use the category's own
+// ratio when one is configured.
+func (o Options) countFile(ctx context.Context, category string, counted []byte) int {
+	if _, isRatio := o.Tokenizer.(RatioTokenizer); o.Tokenizer == nil || isRatio {
+		if ratio, ok := o.CategoryRatios[category]; ok {
+			return RatioTokenizer(ratio).Count(counted)
+		}
+	}
+	return o.count(ctx, counted)
+}
+
+// fileThreshold returns the threshold that applies to path, whose header
+// directives are d. The most specific setting wins: a //tokenlint:threshold
+// directive, then path overrides, then TestThreshold. Limit has the last
+// word.
+func (o Options) fileThreshold(path string, d Directives) int {
+	t := o.Threshold
+	if o.TestThreshold > 0 && strings.HasSuffix(path, "_test.go") {
+		t = o.TestThreshold
+	}
+	if o.PathThreshold != nil {
+		if override, ok := o.PathThreshold(path); ok {
+			t = override
+		}
+	}
+	if d.Threshold > 0 {
+		t = d.Threshold
+	}
+	if o.Limit != nil {
+		t = o.Limit(path, t)
+	}
+	return t
+}
@@ -0,0 +1,133 @@
+package tokenlint
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestScan(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"small.go":        "package a\n",
+		"large.go":        "package a\n" + strings.Repeat("// padding\n", 200),
+		"tables.go":       "//tokenlint:ignore lookup tables\npackage a\n" + strings.Repeat("// padding\n", 200),
+		"skipped/big.go":  "package skipped\n" + strings.Repeat("// padding\n", 200),
+		"api/types.pb.go": "package api\n" + strings.Repeat("// padding\n", 200),
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var mu sync.Mutex
+	var inspected, streamed []string
+	report, err := Scan(context.Background(), Options{
+		Paths:     []string{dir + "/..."},
+		Skip:      func(path string) bool { return strings.Contains(filepath.ToSlash(path), "/skipped/") },
+		Threshold: 1000,
+		Ratio:     1,
+		Inspect: func(f File, content []byte) {
+			mu.Lock()
+			defer mu.Unlock()
+			if len(content) != f.Chars {
+				t.Errorf("%s: Inspect got %d bytes, want %d", f.Path, len(content), f.Chars)
+			}
+			inspected = append(inspected, filepath.Base(f.Path))
+		},
+		OnFile: func(f File) { streamed = append(streamed, filepath.Base(f.Path)) },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, f := range report.Files {
+		got = append(got, filepath.Base(f.Path))
+	}
+	if want := []string{"large.go", "small.go", "tables.go"}; !slices.Equal(got, want) {
+		t.Errorf("files = %v, want %v", got, want)
+	}
+	if !slices.Equal(streamed, got) {
+		t.Errorf("OnFile order %v differs from the report %v", streamed, got)
+	}
+	slices.Sort(inspected)
+	if !slices.Equal(inspected, got) {
+		t.Errorf("inspected %v, want %v", inspected, got)
+	}
+	if len(report.Violations) != 1 || filepath.Base(report.Violations[0].Path) != "large.go" {
+		t.Errorf("violations = %v, want only large.go", report.Violations)
+	}
+	for _, f := range report.Files {
+		if filepath.Base(f.Path) == "tables.go" && (f.Suppressed != "lookup tables" || f.Over()) {
+			t.Errorf("tables.go = %+v, want suppressed", f)
+		}
+	}
+}
+
+func TestScanCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Scan(ctx, Options{Paths: []string{t.TempDir() + "/..."}}); err != context.Canceled {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+}
+
+func TestAnalyzeWarnings(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "a.go")
+	if err := os.WriteFile(file, []byte("//tokenlint:treshold=1\npackage a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var warnings []string
+	report := Analyze(context.Background(), []string{filepath.Join(dir, "missing.go"), file}, Options{
+		Warnf: func(format string, args ...any) { warnings = append(warnings, format) },
+	})
+	if len(report.Files) != 1 || report.Files[0].Threshold != DefaultThreshold {
+		t.Errorf("files = %+v, want a.go at the default threshold", report.Files)
+	}
+	if len(warnings) != 2 {
+		t.Errorf("warnings = %q, want the missing file and the malformed directive", warnings)
+	}
+}
+
+func TestFileThreshold(t *testing.T) {
+	opts := Options{
+		Threshold:     100,
+		TestThreshold: 300,
+		PathThreshold: func(path string) (int, bool) {
+			return 50, strings.HasPrefix(path, "cmd/")
+		},
+	}
+	tests := []struct {
+		path      string
+		directive int
+		want      int
+	}{
+		{"a.go", 0, 100},
+		{"a_test.go", 0, 300},
+		{"cmd/a_test.go", 0, 50}, // path overrides are more specific
+		{"cmd/a.go", 40000, 40000},
+	}
+	for _, tt := range tests {
+		if got := opts.fileThreshold(tt.path, Directives{Threshold: tt.directive}); got != tt.want {
+			t.Errorf("fileThreshold(%s) = %d, want %d", tt.path, got, tt.want)
+		}
+	}
+
+	// Limit has the last word, even over directives.
+	opts.Limit = func(path string, t int) int { return min(t, 80) }
+	if got := opts.fileThreshold("cmd/a.go", Directives{Threshold: 40000}); got != 80 {
+		t.Errorf("fileThreshold with Limit = %d, want 80", got)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// checkPackageDocs reports packages among files that have no package
+// comment, or whose package comment costs more than budget tokens. Agents
+// read package comments first, so they should summarize, not document.
+func checkPackageDocs(files []string, budget int, count func([]byte) int, read func(string) ([]byte, error)) []finding {
+	dirs := make(map[string][]string)
+	for _, path := range files {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		dir := filepath.Dir(path)
+		dirs[dir] = append(dirs[dir], path)
+	}
+
+	var findings []finding
+	for dir, paths := range dirs {
+		fset := token.NewFileSet()
+		var name string
+		var tokens int
+		var documented bool
+		for _, path := range paths {
+			src, err := read(path)
+			if err != nil {
+				continue
+			}
+			f, err := parser.ParseFile(fset, path, src, parser.PackageClauseOnly|parser.ParseComments)
+			if err != nil {
+				continue
+			}
+			name = f.Name.Name
+			if f.Doc == nil {
+				continue
+			}
+			documented = true
+			// go doc joins the package comments of all files.
+			tokens += count([]byte(f.Doc.Text()))
+		}
+		switch {
+		case name == "":
+			// Nothing parsed; the token check reports such files anyway.
+		case !documented:
+			findings = append(findings, finding{rule: "package-doc", path: dir, key: "pkgDocMissing", args: []any{name}})
+		case tokens > budget:
+			findings = append(findings, finding{rule: "package-doc", path: dir, key: "pkgDocOver", args: []any{name, tokens, budget}})
+		}
+	}
+
+	sort.Slice(findings, func(i, j int) bool { return findings[i].path < findings[j].path })
+	return findings
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckPackageDocs(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"good/doc.go":    "// Package good is short.\npackage good\n",
+		"good/a.go":      "package good\n",
+		"none/a.go":      "package none\n",
+		"none/a_test.go": "// Package none is documented only in a test.\npackage none\n",
+		"long/doc.go":    "// Package long has a package comment that goes on and on and on.\npackage long\n",
+	}
+	var paths []string
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+
+	count := func(b []byte) int { return len(b) }
+	got := checkPackageDocs(paths, 40, count, os.ReadFile)
+	if len(got) != 2 {
+		t.Fatalf("findings = %v, want 2", got)
+	}
+
+	en := newMessages("en")
+	if got[0].path != filepath.Join(dir, "long") || got[0].key != "pkgDocOver" {
+		t.Errorf("findings[0] = %v, want long over budget", got[0])
+	}
+	if want := "package none has no package comment; add one in doc.go"; got[1].message(en) != want {
+		t.Errorf("findings[1] = %q, want %q", got[1].message(en), want)
+	}
+}
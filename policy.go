@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"path/filepath"
+
+	"github.com/befabri/token-lint/pkg/tokenlint"
+)
+
+// policy is the check policy a repository commits: its config file and
+// its ignore file. Subcommands load it like the main command does, so
+// every report judges files the same way.
+type policy struct {
+	cfg       *config                   // nil without a config file
+	ignore    *tokenlint.IgnoreRules    // nil without an ignore file
+	generated *tokenlint.GeneratedRules // from the config, nil for the built-in rules
+}
+
+// loadPolicy loads the config file at configPath and the ignore file at
+// ignorePath, or the nearest ones from the working directory up when
+// these are empty, and sets the flags of fs the config covers unless
+// they were given explicitly.
+func loadPolicy(fs *flag.FlagSet, configPath, ignorePath string) (*policy, error) {
+	var err error
+	if configPath == "" {
+		if configPath, err = findConfig("."); err != nil {
+			return nil, err
+		}
+	}
+	if ignorePath == "" {
+		if ignorePath, err = findUp(".", ignoreFileName); err != nil {
+			return nil, err
+		}
+	}
+	return readPolicy(fs, configPath, ignorePath)
+}
+
+// readPolicy is loadPolicy without the lookup; empty paths mean no file.
+func readPolicy(fs *flag.FlagSet, configPath, ignorePath string) (*policy, error) {
+	p := &policy{}
+	var err error
+	if configPath != "" {
+		if p.cfg, err = loadConfig(configPath); err != nil {
+			return nil, err
+		}
+		if err := p.cfg.apply(fs); err != nil {
+			return nil, fmt.Errorf("%s: %v", configPath, err)
+		}
+	}
+	if ignorePath != "" {
+		if p.ignore, err = tokenlint.LoadIgnoreFile(ignorePath); err != nil {
+			return nil, err
+		}
+	}
+	if p.generated, err = newGeneratedRules(p.cfg, nil, false); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// inScope reports whether path is neither excluded by the config nor
+// listed in the ignore file.
+func (p *policy) inScope(path string) bool {
+	return (p.cfg == nil || !p.cfg.excluded(path)) && (p.ignore == nil || !p.ignore.Ignored(path))
+}
+
+// filter returns the files in scope, reusing the backing array of files.
+func (p *policy) filter(files []string) []string {
+	kept := files[:0]
+	for _, path := range files {
+		if p.inScope(path) {
+			kept = append(kept, path)
+		}
+	}
+	return kept
+}
+
+// skipsRel reports whether the file at rel, relative to the repository
+// root, is generated or out of scope, for commands that read files from
+// git rather than the working tree.
+func (p *policy) skipsRel(root, rel string) bool {
+	path := filepath.Join(root, filepath.FromSlash(rel))
+	return p.generated.Match(path) || !p.inScope(path)
+}
+
+// expandOptions returns the file discovery options of the policy.
+func (p *policy) expandOptions() tokenlint.ExpandOptions {
+	return tokenlint.ExpandOptions{Generated: p.generated}
+}
+
+// expand expands paths like the main command and keeps the files in
+// scope.
+func (p *policy) expand(ctx context.Context, paths []string) ([]string, error) {
+	files, err := tokenlint.Expand(ctx, paths, p.expandOptions())
+	if err != nil {
+		return nil, err
+	}
+	return p.filter(files), nil
+}
+
+// options returns the analyzeOptions for threshold and ratio, which the
+// config has already filled in through the command's flags, plus the
+// config settings no subcommand has a flag for: the test threshold, path
+// overrides, category ratios and tokenizer.
+func (p *policy) options(threshold int, ratio float64) (analyzeOptions, error) {
+	o := analyzeOptions{
+		threshold:     threshold,
+		ratio:         ratio,
+		pathThreshold: pathThresholdFunc(p.cfg, nil),
+	}
+	if p.cfg == nil {
+		return o, nil
+	}
+	o.testThreshold = p.cfg.TestThreshold
+	o.categoryRatios = p.cfg.Ratios
+	if name, tc := p.cfg.tokenizer(); name != "" && name != "ratio" {
+		tc.ratio = ratio
+		tok, err := newTokenizer(name, tc)
+		if err != nil {
+			return o, err
+		}
+		o.tokenizer = tok
+	}
+	return o, nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadPolicy(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		configFileName: "threshold: {start: 40000, target: 25000, from: 2020-01-01, by: 2020-06-01}\n" +
+			"test_threshold: 50000\ntokenizer: bpe\nexclude: [legacy/**]\noverrides:\n  cmd/**: 15000\n",
+		ignoreFileName: "testdata/\n",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(dir)
+
+	// A subcommand with -threshold and -ratio only.
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	threshold := fs.Int("threshold", defaultThreshold, "")
+	ratio := fs.Float64("ratio", defaultRatio, "")
+	if err := fs.Parse(nil); err != nil {
+		t.Fatal(err)
+	}
+	pol, err := loadPolicy(fs, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *threshold != 25000 {
+		t.Errorf("threshold = %d, want 25000 from the finished ramp", *threshold)
+	}
+
+	files := pol.filter([]string{"a.go", "legacy/old.go", "testdata/x.go", "cmd/main.go"})
+	if len(files) != 2 || files[0] != "a.go" || files[1] != "cmd/main.go" {
+		t.Errorf("filter = %v, want a.go and cmd/main.go", files)
+	}
+
+	opts, err := pol.options(*threshold, *ratio)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.tokenizer == nil || opts.testThreshold != 50000 {
+		t.Errorf("options = %+v, want the bpe tokenizer and test threshold from the config", opts)
+	}
+	if t15, ok := opts.pathThreshold(filepath.Join(dir, "cmd", "main.go")); !ok || t15 != 15000 {
+		t.Errorf("pathThreshold(cmd/main.go) = %d, %v; want 15000", t15, ok)
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// priority is a violation ranked by how much it is over the limit and how
+// often it changes.
+type priority struct {
+	fileResult
+	over    int
+	commits int
+	score   int
+}
+
+// runPrioritize implements `token-lint prioritize`, which ranks violating
+// files by tokens over the limit times recent commit frequency.
+func runPrioritize(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("token-lint prioritize", flag.ContinueOnError)
+	threshold := fs.Int("threshold", defaultThreshold, "maximum tokens before warning")
+	ratio := fs.Float64("ratio", defaultRatio, "tokens per character ratio")
+	since := fs.String("since", "90 days ago", "count commits after this date (any format git accepts)")
+
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 1
+	}
+	pol, err := loadPolicy(fs, "", "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+
+	if *ratio <= 0 {
+		fmt.Fprintln(os.Stderr, "error: ratio must be positive")
+		return 1
+	}
+	if *threshold <= 0 {
+		fmt.Fprintln(os.Stderr, "error: threshold must be positive")
+		return 1
+	}
+
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{"./..."}
+	}
+
+	files, err := pol.expand(ctx, paths)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+
+	opts, err := pol.options(*threshold, *ratio)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	_, violations := analyzeFiles(ctx, files, opts)
+	if len(violations) == 0 {
+		fmt.Printf("No files exceed %d token threshold\n", *threshold)
+		return 0
+	}
+
+	counts, err := commitCounts(*since)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	root, err := repoRoot()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+
+	ranked := rankByChurn(violations, *threshold, func(path string) int {
+		rel, err := relToRoot(root, path)
+		if err != nil {
+			return 0
+		}
+		return counts[rel]
+	})
+
+	fmt.Printf("%-60s %8s %8s %10s\n", "FILE", "OVER", "COMMITS", "SCORE")
+	fmt.Println(strings.Repeat("-", 89))
+	for _, p := range ranked {
+		fmt.Printf("%-60s %8d %8d %10d\n", quotePath(p.path), p.over, p.commits, p.score)
+	}
+	return 0
+}
+
+// rankByChurn scores each violation as tokens over threshold times its
+// commit count and sorts by descending score, then by tokens over.
+func rankByChurn(violations []fileResult, threshold int, commits func(path string) int) []priority {
+	ranked := make([]priority, len(violations))
+	for i, v := range violations {
+		p := priority{fileResult: v, over: v.tokens - threshold, commits: commits(v.path)}
+		p.score = p.over * p.commits
+		ranked[i] = p
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].score != ranked[j].score {
+			return ranked[i].score > ranked[j].score
+		}
+		return ranked[i].over > ranked[j].over
+	})
+	return ranked
+}
@@ -0,0 +1,30 @@
+package main
+
+import "testing"
+
+func TestRankByChurn(t *testing.T) {
+	violations := []fileResult{
+		{path: "huge_but_frozen.go", tokens: 90000},
+		{path: "hot.go", tokens: 30000},
+		{path: "warm.go", tokens: 40000},
+		{path: "cold.go", tokens: 26000},
+	}
+	commits := map[string]int{"hot.go": 20, "warm.go": 5, "huge_but_frozen.go": 0}
+
+	ranked := rankByChurn(violations, 25000, func(path string) int { return commits[path] })
+
+	want := []struct {
+		path  string
+		score int
+	}{
+		{"hot.go", 100000},
+		{"warm.go", 75000},
+		{"huge_but_frozen.go", 0},
+		{"cold.go", 0},
+	}
+	for i, w := range want {
+		if ranked[i].path != w.path || ranked[i].score != w.score {
+			t.Errorf("rank %d = %s (score %d), want %s (score %d)", i, ranked[i].path, ranked[i].score, w.path, w.score)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// thresholdRamp tightens the threshold linearly from start on the from
+// date to target on the by date.
+type thresholdRamp struct {
+	start, target int
+	from, by      time.Time
+}
+
+// parseRamp parses a -ramp value of the form "START@FROM,TARGET@BY", for
+// example "40000@2026-01-01,25000@2026-06-01".
+func parseRamp(s string) (thresholdRamp, error) {
+	var r thresholdRamp
+	startSpec, targetSpec, ok := strings.Cut(s, ",")
+	if !ok {
+		return r, fmt.Errorf("invalid ramp %q: want START@FROM,TARGET@BY", s)
+	}
+	var err error
+	if r.start, r.from, err = parseRampPoint(startSpec); err != nil {
+		return r, err
+	}
+	if r.target, r.by, err = parseRampPoint(targetSpec); err != nil {
+		return r, err
+	}
+	if !r.by.After(r.from) {
+		return r, fmt.Errorf("invalid ramp %q: end date must be after start date", s)
+	}
+	return r, nil
+}
+
+func parseRampPoint(s string) (int, time.Time, error) {
+	tokens, date, ok := strings.Cut(strings.TrimSpace(s), "@")
+	if !ok {
+		return 0, time.Time{}, fmt.Errorf("invalid ramp point %q: want TOKENS@YYYY-MM-DD", s)
+	}
+	n, err := strconv.Atoi(tokens)
+	if err != nil || n <= 0 {
+		return 0, time.Time{}, fmt.Errorf("invalid ramp threshold %q", tokens)
+	}
+	t, err := time.Parse(time.DateOnly, date)
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("invalid ramp date %q: want YYYY-MM-DD", date)
+	}
+	return n, t, nil
+}
+
+// at returns the effective threshold at now.
+func (r thresholdRamp) at(now time.Time) int {
+	switch {
+	case !now.After(r.from):
+		return r.start
+	case !now.Before(r.by):
+		return r.target
+	}
+	progress := float64(now.Sub(r.from)) / float64(r.by.Sub(r.from))
+	return r.start + int(float64(r.target-r.start)*progress)
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestThresholdRamp(t *testing.T) {
+	r, err := parseRamp("40000@2026-01-01,25000@2026-01-31")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		date string
+		want int
+	}{
+		{"2025-12-01", 40000},
+		{"2026-01-01", 40000},
+		{"2026-01-16", 32500},
+		{"2026-01-31", 25000},
+		{"2027-01-01", 25000},
+	}
+	for _, tt := range tests {
+		now, _ := time.Parse(time.DateOnly, tt.date)
+		if got := r.at(now); got != tt.want {
+			t.Errorf("at(%s) = %d, want %d", tt.date, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"40000", "x@2026-01-01,1@2026-02-01", "1@2026-02-01,1@2026-01-01", "1@2026/01/01,1@2026-02-01"} {
+		if _, err := parseRamp(bad); err == nil {
+			t.Errorf("parseRamp(%q) succeeded", bad)
+		}
+	}
+}
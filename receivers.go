@@ -0,0 +1,54 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// checkReceivers reports non-test files that declare methods on more than
+// max distinct receiver types. Methods of several types in one file are a
+// sign it mixes concerns, even while it is still under the token limit.
+func checkReceivers(files []string, max int, read func(string) ([]byte, error)) []finding {
+	var findings []finding
+	for _, path := range files {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		src, err := read(path)
+		if err != nil {
+			continue
+		}
+		f, err := parser.ParseFile(token.NewFileSet(), path, src, parser.SkipObjectResolution)
+		if err != nil {
+			continue
+		}
+		if types := receiverTypes(f); len(types) > max {
+			findings = append(findings, finding{rule: "receivers", path: path, key: "receiversOver",
+				args: []any{len(types), strings.Join(types, ", "), max}})
+		}
+	}
+	sort.Slice(findings, func(i, j int) bool { return findings[i].path < findings[j].path })
+	return findings
+}
+
+// receiverTypes returns the sorted names of the types f declares methods
+// on.
+func receiverTypes(f *ast.File) []string {
+	seen := make(map[string]bool)
+	var types []string
+	for _, d := range f.Decls {
+		fn, ok := d.(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+		if name := receiverName(fn); name != "" && !seen[name] {
+			seen[name] = true
+			types = append(types, name)
+		}
+	}
+	sort.Strings(types)
+	return types
+}
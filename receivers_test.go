@@ -0,0 +1,32 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckReceivers(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"mixed.go":      "package p\n\ntype A struct{}\ntype B[T any] struct{}\ntype C int\n\nfunc (A) m()     {}\nfunc (*A) n()    {}\nfunc (B[T]) m()  {}\nfunc (c *C) m()  {}\n",
+		"single.go":     "package p\n\ntype D struct{}\n\nfunc (D) m() {}\nfunc (*D) n() {}\nfunc f()      {}\n",
+		"mixed_test.go": "package p\n\ntype E struct{}\ntype F struct{}\ntype G struct{}\n\nfunc (E) m() {}\nfunc (F) m() {}\nfunc (G) m() {}\n",
+	}
+	var paths []string
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+
+	got := checkReceivers(paths, 2, os.ReadFile)
+	if len(got) != 1 || got[0].path != filepath.Join(dir, "mixed.go") {
+		t.Fatalf("findings = %v, want only mixed.go", got)
+	}
+	if want := "declares methods on 3 receiver types (A, B, C), over the limit of 2; give each type its own file"; got[0].message(newMessages("en")) != want {
+		t.Errorf("message = %q, want %q", got[0].message(newMessages("en")), want)
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"time"
+)
+
+// ratchetStep is one quarter of a suggested threshold schedule.
+type ratchetStep struct {
+	quarter   string
+	threshold int
+}
+
+// runRecommend implements `token-lint recommend`, which suggests a
+// threshold from the current token distribution.
+func runRecommend(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("token-lint recommend", flag.ContinueOnError)
+	ratio := fs.Float64("ratio", defaultRatio, "tokens per character ratio")
+	percentile := fs.Float64("percentile", 95, "percentile of file sizes to start the threshold at")
+	quarters := fs.Int("quarters", 4, "number of quarters in the ratchet schedule")
+	step := fs.Float64("step", 5, "percent to lower the threshold by each quarter")
+
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 1
+	}
+	pol, err := loadPolicy(fs, "", "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+
+	if *ratio <= 0 {
+		fmt.Fprintln(os.Stderr, "error: ratio must be positive")
+		return 1
+	}
+	if *percentile <= 0 || *percentile > 100 {
+		fmt.Fprintln(os.Stderr, "error: percentile must be in (0, 100]")
+		return 1
+	}
+
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{"./..."}
+	}
+
+	files, err := pol.expand(ctx, paths)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+
+	// Every file is a result; the threshold only matters for violations.
+	opts, err := pol.options(math.MaxInt, *ratio)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	results, _ := analyzeFiles(ctx, files, opts)
+	if len(results) == 0 {
+		fmt.Fprintln(os.Stderr, "no Go files found")
+		return 0
+	}
+
+	tokens := make([]int, len(results))
+	total := 0
+	for i, r := range results {
+		tokens[i] = r.tokens
+		total += r.tokens
+	}
+	sort.Ints(tokens)
+
+	suggested := roundUp(percentileOf(tokens, *percentile), 1000)
+	over := 0
+	for _, n := range tokens {
+		if n > suggested {
+			over++
+		}
+	}
+
+	fmt.Printf("Analyzed %d files (~%d tokens)\n\n", len(tokens), total)
+	for _, p := range []float64{50, 90, 95, 99} {
+		fmt.Printf("  p%-5g %8d\n", p, percentileOf(tokens, p))
+	}
+	fmt.Printf("  max    %8d\n\n", tokens[len(tokens)-1])
+
+	fmt.Printf("Suggested threshold: %d (p%g, rounded up to the nearest 1000)\n", suggested, *percentile)
+	fmt.Printf("Files currently over it: %d\n\n", over)
+
+	fmt.Printf("Ratchet schedule (-%g%% per quarter):\n", *step)
+	for _, s := range ratchet(suggested, *quarters, *step, time.Now()) {
+		fmt.Printf("  %s  %d\n", s.quarter, s.threshold)
+	}
+
+	fmt.Printf("\nCI snippet:\n\n  token-lint -threshold %d ./...\n", suggested)
+	return 0
+}
+
+// percentileOf returns the nearest-rank percentile p of sorted values.
+func percentileOf(sorted []int, p float64) int {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	rank = min(max(rank, 1), len(sorted))
+	return sorted[rank-1]
+}
+
+func roundUp(n, to int) int {
+	if n%to == 0 {
+		return n
+	}
+	return (n/to + 1) * to
+}
+
+// ratchet returns a schedule starting at threshold for the quarter
+// containing now and lowering it by step percent each following quarter.
+func ratchet(threshold, quarters int, step float64, now time.Time) []ratchetStep {
+	year, q := now.Year(), (int(now.Month())-1)/3+1
+	steps := make([]ratchetStep, 0, quarters)
+	t := float64(threshold)
+	for range quarters {
+		steps = append(steps, ratchetStep{
+			quarter:   fmt.Sprintf("%d-Q%d", year, q),
+			threshold: roundUp(int(t), 100),
+		})
+		t *= 1 - step/100
+		if q++; q > 4 {
+			year, q = year+1, 1
+		}
+	}
+	return steps
+}
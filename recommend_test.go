@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPercentileOf(t *testing.T) {
+	sorted := []int{10, 20, 30, 40, 50, 60, 70, 80, 90, 100}
+	tests := []struct {
+		p    float64
+		want int
+	}{
+		{50, 50},
+		{90, 90},
+		{95, 100},
+		{100, 100},
+		{1, 10},
+	}
+
+	for _, tt := range tests {
+		if got := percentileOf(sorted, tt.p); got != tt.want {
+			t.Errorf("percentileOf(p%g) = %d, want %d", tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestRatchet(t *testing.T) {
+	now := time.Date(2026, time.November, 3, 0, 0, 0, 0, time.UTC)
+	got := ratchet(20000, 3, 5, now)
+
+	want := []ratchetStep{
+		{"2026-Q4", 20000},
+		{"2027-Q1", 19000},
+		{"2027-Q2", 18100},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d steps, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("step %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/befabri/token-lint/pkg/tokenlint"
+)
+
+// refSource reads Go files as they are at a git ref, or in the index if
+// the ref is empty, without a checkout.
+type refSource struct {
+	ref  string
+	root string
+	rel  map[string]string // listed path to path relative to root
+}
+
+// read returns the content of p at the ref. p is a path listed by
+// refFiles or any other path in the repository, such as CLAUDE.md.
+func (s refSource) read(p string) ([]byte, error) {
+	rel, ok := s.rel[p]
+	if !ok {
+		var err error
+		if rel, err = relToRoot(s.root, p); err != nil {
+			return nil, err
+		}
+	}
+	return gitBlob(s.ref, rel)
+}
+
+// refFiles expands args like tokenlint.Expand, but against the tree of ref
+// (a commit, branch, tag or stash such as stash@{0}) instead of the
+// working tree. Returned paths are relative to the working directory.
+func refFiles(ctx context.Context, ref string, args []string, opts tokenlint.ExpandOptions) ([]string, refSource, error) {
+	src := refSource{ref: ref, rel: make(map[string]string)}
+	root, err := repoRoot()
+	if err != nil {
+		return nil, src, err
+	}
+	src.root = root
+	out, err := gitRawOutput("-C", root, "ls-tree", "-r", "-z", "--name-only", ref)
+	if err != nil {
+		return nil, src, err
+	}
+	return src.expand(ctx, splitNul(out), args, opts)
+}
+
+// indexFiles expands args like tokenlint.Expand, but only to files with staged
+// additions or modifications, read from the index as they will be
+// committed. Returned paths are relative to the working directory.
+func indexFiles(ctx context.Context, args []string, opts tokenlint.ExpandOptions) ([]string, refSource, error) {
+	src := refSource{rel: make(map[string]string)}
+	root, err := repoRoot()
+	if err != nil {
+		return nil, src, err
+	}
+	src.root = root
+	out, err := gitRawOutput("-C", root, "diff", "--cached", "-z", "--name-only", "--diff-filter=d")
+	if err != nil {
+		return nil, src, err
+	}
+	return src.expand(ctx, splitNul(out), args, opts)
+}
+
+func splitNul(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimRight(s, "\x00"), "\x00")
+}
+
+// expand matches args against tree, the root-relative paths of the files
+// at the source, and records the files it returns. Naming a file that is
+// not in the index is not an error: it just has no staged changes.
+func (src refSource) expand(ctx context.Context, tree, args []string, opts tokenlint.ExpandOptions) ([]string, refSource, error) {
+	root := src.root
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, src, err
+	}
+
+	var files []string
+	displayPath := func(rel string) string {
+		display := filepath.FromSlash(rel)
+		if p, err := filepath.Rel(cwd, filepath.Join(root, display)); err == nil {
+			display = p
+		}
+		return display
+	}
+	add := func(rel string) {
+		display := displayPath(rel)
+		if _, ok := src.rel[display]; !ok {
+			src.rel[display] = rel
+			files = append(files, display)
+		}
+	}
+
+	for _, arg := range args {
+		if err := ctx.Err(); err != nil {
+			return files, src, err
+		}
+
+		dir, recursive := tokenlint.SplitRecursive(arg)
+		if !recursive {
+			dir = arg
+		}
+		base, err := relToRoot(root, dir)
+		if err != nil {
+			return nil, src, err
+		}
+
+		matched := false
+		for _, rel := range tree {
+			if rel == base {
+				// A single file is taken as given.
+				add(rel)
+				matched = true
+				continue
+			}
+			sub, ok := strings.CutPrefix(rel, base+"/")
+			if base == "." {
+				sub, ok = rel, true
+			}
+			if !ok || !strings.HasSuffix(rel, ".go") || !opts.IncludeGenerated && opts.Generated.Match(displayPath(rel)) {
+				continue
+			}
+			if !recursive && strings.Contains(sub, "/") {
+				continue
+			}
+			if !opts.IncludeHidden && hiddenPath(sub) {
+				continue
+			}
+			add(rel)
+			matched = true
+		}
+		if !matched && !recursive && src.ref != "" {
+			return nil, src, fmt.Errorf("%s: no such file or directory at %s", arg, src.ref)
+		}
+	}
+	return files, src, nil
+}
+
+// hiddenPath reports whether any element of the slash-separated path is
+// hidden.
+func hiddenPath(p string) bool {
+	for p != "." && p != "/" && p != "" {
+		if tokenlint.IsHidden(path.Base(p)) {
+			return true
+		}
+		p = path.Dir(p)
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/befabri/token-lint/pkg/tokenlint"
+)
+
+func TestRefFiles(t *testing.T) {
+	dir := newTestRepo(t)
+	testCommit(t, dir, "", map[string]string{
+		"main.go":          "package main\n",
+		"pkg/a.go":         "package pkg // at HEAD~1\n",
+		"pkg/sub/b.go":     "package sub\n",
+		"pkg/.hidden/c.go": "package hidden\n",
+		"pkg/notes.txt":    "not go\n",
+	})
+	testCommit(t, dir, "", map[string]string{"pkg/a.go": "package pkg // changed\n", "late.go": "package main\n"})
+	t.Chdir(filepath.Join(dir, "pkg"))
+
+	files, src, err := refFiles(context.Background(), "HEAD~1", []string{"./..."}, tokenlint.ExpandOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(files)
+	if want := []string{"a.go", filepath.Join("sub", "b.go")}; !slices.Equal(files, want) {
+		t.Errorf("files = %v, want %v", files, want)
+	}
+	content, err := src.read("a.go")
+	if err != nil || string(content) != "package pkg // at HEAD~1\n" {
+		t.Errorf("read(a.go) = %q, %v", content, err)
+	}
+	if content, err := src.read("notes.txt"); err != nil || string(content) != "not go\n" {
+		t.Errorf("read(notes.txt) = %q, %v", content, err)
+	}
+
+	files, _, err = refFiles(context.Background(), "HEAD~1", []string{".."}, tokenlint.ExpandOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{filepath.Join("..", "main.go")}; !slices.Equal(files, want) {
+		t.Errorf("files = %v, want %v", files, want)
+	}
+
+	if _, _, err := refFiles(context.Background(), "HEAD~1", []string{"../late.go"}, tokenlint.ExpandOptions{}); err == nil {
+		t.Error("refFiles found a file added after the ref")
+	}
+}
+
+func TestIndexFiles(t *testing.T) {
+	dir := newTestRepo(t)
+	testCommit(t, dir, "", map[string]string{"a.go": "package a\n", "b.go": "package a\n", "c.go": "package a\n"})
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a.go", "package a // staged\n")
+	write("new.go", "package a\n")
+	testGit(t, dir, "", "add", "a.go", "new.go")
+	testGit(t, dir, "", "rm", "--quiet", "b.go")
+	write("a.go", "package a // not staged\n")
+	write("c.go", "package a // not staged\n")
+	t.Chdir(dir)
+
+	files, src, err := indexFiles(context.Background(), []string{"./..."}, tokenlint.ExpandOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(files)
+	if want := []string{"a.go", "new.go"}; !slices.Equal(files, want) {
+		t.Errorf("files = %v, want %v", files, want)
+	}
+	if content, err := src.read("a.go"); err != nil || string(content) != "package a // staged\n" {
+		t.Errorf("read(a.go) = %q, %v", content, err)
+	}
+
+	files, _, err = indexFiles(context.Background(), []string{"c.go"}, tokenlint.ExpandOptions{})
+	if err != nil || len(files) != 0 {
+		t.Errorf("indexFiles(c.go) = %v, %v; want no files and no error", files, err)
+	}
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"html/template"
+	"net/smtp"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// reportSummary is the data rendered by the report subcommand.
+type reportSummary struct {
+	Generated   time.Time
+	Threshold   int
+	Files       int
+	TotalTokens int
+	Violations  []fileResult
+	Largest     []fileResult
+}
+
+// smtpConfig holds the mail settings used by `report -email`.
+type smtpConfig struct {
+	host     string
+	port     string
+	user     string
+	password string
+	from     string
+}
+
+// newSMTPConfig returns the mail settings of the smtp section of cfg,
+// which may be nil. The credentials, being secrets, may come from the
+// TOKEN_LINT_SMTP_USER and TOKEN_LINT_SMTP_PASSWORD variables instead.
+func newSMTPConfig(cfg *config) smtpConfig {
+	var s smtpSettings
+	if cfg != nil {
+		s = cfg.SMTP
+	}
+	c := smtpConfig{
+		host:     s.Host,
+		user:     s.User,
+		password: os.Getenv("TOKEN_LINT_SMTP_PASSWORD"),
+		from:     s.From,
+	}
+	if s.Port != 0 {
+		c.port = strconv.Itoa(s.Port)
+	}
+	if c.user == "" {
+		c.user = os.Getenv("TOKEN_LINT_SMTP_USER")
+	}
+	if c.port == "" {
+		c.port = "587"
+	}
+	if c.from == "" {
+		c.from = c.user
+	}
+	return c
+}
+
+// runReport implements `token-lint report`. It always exits 0 on a
+// successful scan so it can run from cron without failing anything.
+func runReport(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("token-lint report", flag.ContinueOnError)
+	threshold := fs.Int("threshold", defaultThreshold, "maximum tokens before warning")
+	ratio := fs.Float64("ratio", defaultRatio, "tokens per character ratio")
+	format := fs.String("format", "markdown", "report format: markdown or html")
+	email := fs.String("email", "", "comma-separated recipients to mail the report to")
+	subject := fs.String("subject", "token-lint report", "email subject")
+	top := fs.Int("top", 10, "number of largest files to list")
+
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 1
+	}
+	pol, err := loadPolicy(fs, "", "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+
+	if *ratio <= 0 {
+		fmt.Fprintln(os.Stderr, "error: ratio must be positive")
+		return 1
+	}
+	if *threshold <= 0 {
+		fmt.Fprintln(os.Stderr, "error: threshold must be positive")
+		return 1
+	}
+	if *format != "markdown" && *format != "html" {
+		fmt.Fprintf(os.Stderr, "error: unknown report format %q\n", *format)
+		return 1
+	}
+
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{"./..."}
+	}
+
+	files, err := pol.expand(ctx, paths)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+
+	opts, err := pol.options(*threshold, *ratio)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	results, violations := analyzeFiles(ctx, files, opts)
+	summary := summarize(results, violations, *threshold, *top)
+
+	var body string
+	if *format == "html" {
+		body, err = renderHTMLReport(summary)
+	} else {
+		body = renderMarkdownReport(summary)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+
+	if *email == "" {
+		fmt.Print(body)
+		return 0
+	}
+
+	if err := sendReport(newSMTPConfig(pol.cfg), splitList(*email), *subject, *format, body); err != nil {
+		fmt.Fprintf(os.Stderr, "error: sending report: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func summarize(results, violations []fileResult, threshold, top int) reportSummary {
+	s := reportSummary{
+		Generated:  time.Now(),
+		Threshold:  threshold,
+		Files:      len(results),
+		Violations: violations,
+	}
+	for _, r := range results {
+		s.TotalTokens += r.tokens
+	}
+
+	sorted := append([]fileResult(nil), results...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].tokens > sorted[j].tokens
+	})
+	if len(sorted) > top {
+		sorted = sorted[:top]
+	}
+	s.Largest = sorted
+	sort.Slice(s.Violations, func(i, j int) bool {
+		return s.Violations[i].tokens > s.Violations[j].tokens
+	})
+	return s
+}
+
+func renderMarkdownReport(s reportSummary) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# token-lint report\n\n")
+	fmt.Fprintf(&b, "Generated %s. Scanned %d files (~%d tokens) against a %d token threshold.\n\n",
+		s.Generated.Format("2006-01-02"), s.Files, s.TotalTokens, s.Threshold)
+
+	fmt.Fprintf(&b, "## Violations (%d)\n\n", len(s.Violations))
+	if len(s.Violations) == 0 {
+		b.WriteString("None.\n\n")
+	} else {
+		writeMarkdownTable(&b, s.Violations, s.Threshold)
+	}
+
+	fmt.Fprintf(&b, "## Largest files\n\n")
+	writeMarkdownTable(&b, s.Largest, s.Threshold)
+	return b.String()
+}
+
+func writeMarkdownTable(b *strings.Builder, results []fileResult, threshold int) {
+	b.WriteString("| File | Tokens | % of limit |\n")
+	b.WriteString("|------|-------:|-----------:|\n")
+	for _, r := range results {
+		pct := float64(r.tokens) / float64(threshold) * 100
+		fmt.Fprintf(b, "| %s | %d | %.0f%% |\n", markdownPath(r.path), r.tokens, pct)
+	}
+	b.WriteString("\n")
+}
+
+// reportRow is a template-friendly view of a fileResult.
+type reportRow struct {
+	Path   string
+	Tokens int
+	Pct    string
+}
+
+func reportRows(results []fileResult, threshold int) []reportRow {
+	rows := make([]reportRow, len(results))
+	for i, r := range results {
+		pct := float64(r.tokens) / float64(threshold) * 100
+		rows[i] = reportRow{Path: r.path, Tokens: r.tokens, Pct: fmt.Sprintf("%.0f%%", pct)}
+	}
+	return rows
+}
+
+var htmlReport = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>token-lint report</title></head>
+<body>
+<h1>token-lint report</h1>
+<p>Generated {{.Generated}}. Scanned {{.Files}} files (~{{.TotalTokens}} tokens) against a {{.Threshold}} token threshold.</p>
+<h2>Violations ({{len .Violations}})</h2>
+{{if .Violations}}{{template "table" .Violations}}{{else}}<p>None.</p>{{end}}
+<h2>Largest files</h2>
+{{template "table" .Largest}}
+</body>
+</html>
+{{define "table"}}<table>
+<tr><th>File</th><th>Tokens</th><th>% of limit</th></tr>
+{{range .}}<tr><td><code>{{.Path}}</code></td><td>{{.Tokens}}</td><td>{{.Pct}}</td></tr>
+{{end}}</table>{{end}}`))
+
+func renderHTMLReport(s reportSummary) (string, error) {
+	data := struct {
+		Generated   string
+		Threshold   int
+		Files       int
+		TotalTokens int
+		Violations  []reportRow
+		Largest     []reportRow
+	}{
+		Generated:   s.Generated.Format("2006-01-02"),
+		Threshold:   s.Threshold,
+		Files:       s.Files,
+		TotalTokens: s.TotalTokens,
+		Violations:  reportRows(s.Violations, s.Threshold),
+		Largest:     reportRows(s.Largest, s.Threshold),
+	}
+
+	var buf bytes.Buffer
+	if err := htmlReport.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// sendReport mails body to the given recipients.
+func sendReport(cfg smtpConfig, to []string, subject, format, body string) error {
+	if cfg.host == "" {
+		return fmt.Errorf("smtp.host is not set in %s", configFileName)
+	}
+	if cfg.from == "" {
+		return fmt.Errorf("smtp.from is not set in %s", configFileName)
+	}
+	if len(to) == 0 {
+		return fmt.Errorf("no recipients")
+	}
+
+	var auth smtp.Auth
+	if cfg.user != "" {
+		auth = smtp.PlainAuth("", cfg.user, cfg.password, cfg.host)
+	}
+	msg := buildMessage(cfg.from, to, subject, format, body)
+	return smtp.SendMail(cfg.host+":"+cfg.port, auth, cfg.from, to, msg)
+}
+
+func buildMessage(from string, to []string, subject, format, body string) []byte {
+	contentType := "text/markdown; charset=utf-8"
+	if format == "html" {
+		contentType = "text/html; charset=utf-8"
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", subject)
+	fmt.Fprintf(&b, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&b, "Content-Type: %s\r\n", contentType)
+	fmt.Fprintf(&b, "\r\n")
+	b.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	return b.Bytes()
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries.
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderReports(t *testing.T) {
+	results := []fileResult{
+		{path: "small.go", tokens: 100, chars: 154},
+		{path: "big.go", tokens: 30000, chars: 46154},
+	}
+	violations := []fileResult{results[1]}
+	s := summarize(results, violations, 25000, 1)
+
+	if s.TotalTokens != 30100 {
+		t.Errorf("TotalTokens = %d, want 30100", s.TotalTokens)
+	}
+	if len(s.Largest) != 1 || s.Largest[0].path != "big.go" {
+		t.Errorf("Largest = %v, want [big.go]", s.Largest)
+	}
+
+	md := renderMarkdownReport(s)
+	for _, want := range []string{"## Violations (1)", "| `big.go` | 30000 | 120% |"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown report missing %q:\n%s", want, md)
+		}
+	}
+
+	html, err := renderHTMLReport(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(html, "<td><code>big.go</code></td><td>30000</td><td>120%</td>") {
+		t.Errorf("html report missing violation row:\n%s", html)
+	}
+}
+
+func TestBuildMessage(t *testing.T) {
+	msg := string(buildMessage("lint@example.com", []string{"a@example.com", "b@example.com"}, "weekly", "html", "<p>hi</p>\n"))
+
+	for _, want := range []string{
+		"To: a@example.com, b@example.com\r\n",
+		"Content-Type: text/html; charset=utf-8\r\n",
+		"\r\n\r\n<p>hi</p>\r\n",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("message missing %q:\n%q", want, msg)
+		}
+	}
+}
+
+func TestNewSMTPConfig(t *testing.T) {
+	t.Setenv("TOKEN_LINT_SMTP_USER", "env-user")
+	t.Setenv("TOKEN_LINT_SMTP_PASSWORD", "secret")
+	cfg, err := parseConfig([]byte("smtp:\n  host: smtp.example.com\n  from: lint@example.com\n"), "/repo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := newSMTPConfig(cfg)
+	want := smtpConfig{host: "smtp.example.com", port: "587", user: "env-user", password: "secret", from: "lint@example.com"}
+	if got != want {
+		t.Errorf("newSMTPConfig = %+v, want %+v", got, want)
+	}
+	if got := newSMTPConfig(nil); got.host != "" || got.from != "env-user" {
+		t.Errorf("newSMTPConfig(nil) = %+v, want no host and the user as sender", got)
+	}
+}
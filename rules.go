@@ -0,0 +1,28 @@
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// finding is a problem reported by a rule other than the per-file token
+// limit. Its message is a catalog key and arguments, so it can be shown in
+// the report language and in English for machine-readable formats.
+type finding struct {
+	rule string // short rule name, e.g. "package-doc"
+	path string // file or directory the finding is about
+	key  string
+	args []any
+}
+
+func (f finding) message(msg messages) string {
+	return msg.f(f.key, f.args...)
+}
+
+// printFindings writes findings in the text report format.
+func printFindings(w io.Writer, msg messages, findings []finding) {
+	fmt.Fprint(w, msg.f("findings", len(findings)))
+	for _, f := range findings {
+		fmt.Fprintf(w, "  %s\n    %s [%s]\n\n", quotePath(f.path), f.message(msg), f.rule)
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// parseSample parses a -sample value such as "10%" or "0.1" into a
+// fraction in (0, 1].
+func parseSample(s string) (float64, error) {
+	var f float64
+	var err error
+	if pct, ok := strings.CutSuffix(s, "%"); ok {
+		f, err = strconv.ParseFloat(pct, 64)
+		f /= 100
+	} else {
+		f, err = strconv.ParseFloat(s, 64)
+	}
+	if err != nil || f <= 0 || f > 1 {
+		return 0, fmt.Errorf("invalid sample %q: want a percentage like 10%% or a fraction in (0, 1]", s)
+	}
+	return f, nil
+}
+
+// sampleSeed returns the seed used for sampling: the current commit SHA,
+// or a fixed value outside a git repository.
+func sampleSeed() string {
+	if sha, err := gitOutput("rev-parse", "HEAD"); err == nil {
+		return sha
+	}
+	return "token-lint"
+}
+
+// sampleFiles keeps roughly fraction of files. Each file is kept or dropped
+// by hashing it with the seed, so the same seed always selects the same
+// files regardless of argument order.
+func sampleFiles(files []string, fraction float64, seed string) []string {
+	var kept []string
+	for _, path := range files {
+		if float64(sampleRank(path, seed))/math.MaxUint64 < fraction {
+			kept = append(kept, path)
+		}
+	}
+	return kept
+}
+
+// sampleRank hashes path with seed into a uniformly distributed number
+// that orders files independently of how they were listed.
+func sampleRank(path, seed string) uint64 {
+	sum := sha256.Sum256([]byte(seed + "\x00" + filepath.ToSlash(filepath.Clean(path))))
+	return binary.BigEndian.Uint64(sum[:8])
+}
+
+// sizeClass buckets a file size for stratified sampling: under 4 KiB,
+// under 32 KiB, or larger.
+func sizeClass(size int64) int {
+	switch {
+	case size < 4<<10:
+		return 0
+	case size < 32<<10:
+		return 1
+	}
+	return 2
+}
+
+// stratifiedSample keeps fraction of files, spread proportionally over
+// strata of directory and size class so that neither a large package nor
+// a mass of small files dominates. Strata are visited in sorted order and
+// each contributes its share rounded so that the running total tracks
+// fraction of the files seen; within a stratum the files with the lowest
+// seeded rank are kept. The result is sorted and depends only on the set
+// of files, their sizes and the seed.
+func stratifiedSample(files []string, size func(string) int64, fraction float64, seed string) []string {
+	type stratum struct {
+		dir   string
+		class int
+	}
+	groups := make(map[stratum][]string)
+	for _, p := range files {
+		k := stratum{filepath.ToSlash(filepath.Dir(filepath.Clean(p))), sizeClass(size(p))}
+		groups[k] = append(groups[k], p)
+	}
+	keys := make([]stratum, 0, len(groups))
+	for k := range groups {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].dir != keys[j].dir {
+			return keys[i].dir < keys[j].dir
+		}
+		return keys[i].class < keys[j].class
+	})
+
+	var kept []string
+	seen, taken := 0, 0
+	for _, k := range keys {
+		g := groups[k]
+		seen += len(g)
+		n := int(math.Round(float64(seen)*fraction)) - taken
+		if n <= 0 {
+			continue
+		}
+		sort.Slice(g, func(i, j int) bool { return sampleRank(g[i], seed) < sampleRank(g[j], seed) })
+		kept = append(kept, g[:n]...)
+		taken += n
+	}
+	sort.Strings(kept)
+	return kept
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseSample(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    float64
+		wantErr bool
+	}{
+		{"10%", 0.1, false},
+		{"100%", 1, false},
+		{"0.25", 0.25, false},
+		{"0%", 0, true},
+		{"150%", 0, true},
+		{"abc", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseSample(tt.in)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSample(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseSample(%q) = %v, want %v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSampleFiles(t *testing.T) {
+	var files []string
+	for i := range 1000 {
+		files = append(files, fmt.Sprintf("pkg%d/file%d.go", i%10, i))
+	}
+
+	a := sampleFiles(files, 0.1, "abc123")
+	if len(a) < 50 || len(a) > 150 {
+		t.Errorf("sampled %d of 1000 files at 10%%, want roughly 100", len(a))
+	}
+
+	reversed := slices.Clone(files)
+	slices.Reverse(reversed)
+	b := sampleFiles(reversed, 0.1, "abc123")
+	slices.Sort(a)
+	slices.Sort(b)
+	if !slices.Equal(a, b) {
+		t.Error("same seed selected different files")
+	}
+
+	if c := sampleFiles(files, 0.1, "def456"); slices.Equal(a, c) {
+		t.Error("different seeds selected identical samples")
+	}
+
+	if all := sampleFiles(files, 1, "abc123"); len(all) != len(files) {
+		t.Errorf("sampled %d files at 100%%, want all %d", len(all), len(files))
+	}
+}
+
+func TestStratifiedSample(t *testing.T) {
+	var files []string
+	sizes := make(map[string]int64)
+	for i := range 1000 {
+		// pkg10 holds half the files; every tenth file is large.
+		p := fmt.Sprintf("pkg%d/file%d.go", min(i%20, 10), i)
+		files = append(files, p)
+		sizes[p] = 1000
+		if i%10 == 0 {
+			sizes[p] = 100000
+		}
+	}
+	size := func(p string) int64 { return sizes[p] }
+
+	a := stratifiedSample(files, size, 0.1, "abc123")
+	if len(a) != 100 {
+		t.Errorf("sampled %d of 1000 files at 10%%, want 100", len(a))
+	}
+	perDir := make(map[string]int)
+	large := 0
+	for _, p := range a {
+		perDir[p[:strings.IndexByte(p, '/')]]++
+		if sizes[p] > 32<<10 {
+			large++
+		}
+	}
+	if perDir["pkg10"] != 50 || perDir["pkg0"] != 5 {
+		t.Errorf("per-directory counts = %v, want 50 from pkg10 and 5 from pkg0", perDir)
+	}
+	if large != 10 {
+		t.Errorf("sampled %d large files, want 10", large)
+	}
+
+	reversed := slices.Clone(files)
+	slices.Reverse(reversed)
+	if b := stratifiedSample(reversed, size, 0.1, "abc123"); !slices.Equal(a, b) {
+		t.Error("same seed selected different files")
+	}
+	if c := stratifiedSample(files, size, 0.1, "def456"); slices.Equal(a, c) {
+		t.Error("different seeds selected identical samples")
+	}
+}
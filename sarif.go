@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// SARIF 2.1.0 types, limited to what token-lint reports.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool      sarifTool       `json:"tool"`
+	Artifacts []sarifArtifact `json:"artifacts,omitempty"`
+	Results   []sarifResult   `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifArtifact struct {
+	Location sarifArtifactLocation `json:"location"`
+	Hashes   map[string]string     `json:"hashes,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI   string `json:"uri"`
+	Index *int   `json:"index,omitempty"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+// sarifRules describes every rule token-lint can report.
+var sarifRules = []sarifRule{
+	{ID: "token-limit", ShortDescription: sarifMessage{"Go file exceeds the token threshold"}},
+	{ID: "package-doc", ShortDescription: sarifMessage{"Package comment missing or over its token budget"}},
+	{ID: "agent-files", ShortDescription: sarifMessage{"Agent instruction file over its token budget"}},
+	{ID: "package-budget", ShortDescription: sarifMessage{"Go package exceeds its token budget"}},
+	{ID: "receivers", ShortDescription: sarifMessage{"Go file declares methods on too many receiver types"}},
+	{ID: "data-literals", ShortDescription: sarifMessage{"Go file dominated by embedded data literals"}},
+	{ID: "exported-symbols", ShortDescription: sarifMessage{"Go file declares too many exported symbols"}},
+}
+
+// writeSARIF writes violations and findings as a SARIF 2.1.0 log with one
+// result each. Tolerated violations are reported as notes.
+func writeSARIF(w io.Writer, violations []fileResult, findings []finding, failFrozen bool) error {
+	en := newMessages("en")
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "token-lint",
+			Version:        currentBuildInfo().version,
+			InformationURI: "https://github.com/befabri/token-lint",
+			Rules:          sarifRules,
+		}},
+		Results: []sarifResult{},
+	}
+
+	for _, v := range violations {
+		index := len(run.Artifacts)
+		uri := sarifURI(v.path)
+		run.Artifacts = append(run.Artifacts, sarifArtifact{
+			Location: sarifArtifactLocation{URI: uri},
+			Hashes:   map[string]string{"sha-256": v.sha256},
+		})
+		level := "error"
+		if v.tolerated(failFrozen) {
+			level = "note"
+		}
+		run.Results = append(run.Results, sarifResult{
+			RuleID:  "token-limit",
+			Level:   level,
+			Message: sarifMessage{en.f("sarifViolation", v.tokens, v.threshold, v.chars)},
+			Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: uri, Index: &index},
+				Region:           &sarifRegion{StartLine: 1},
+			}}},
+		})
+	}
+
+	for _, f := range findings {
+		run.Results = append(run.Results, sarifResult{
+			RuleID:  f.rule,
+			Level:   "error",
+			Message: sarifMessage{f.message(en)},
+			Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: sarifURI(f.path)},
+			}}},
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	})
+}
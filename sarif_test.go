@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestWriteSARIF(t *testing.T) {
+	var out strings.Builder
+	violations := []fileResult{
+		{path: "pkg/big.go", tokens: 30000, threshold: 25000, chars: 46000, sha256: "ab12"},
+		{path: "old.go", tokens: 26000, threshold: 25000, chars: 40000, frozen: true},
+	}
+	findings := []finding{{rule: "package-doc", path: "pkg", key: "pkgDocMissing", args: []any{"pkg"}}}
+	if err := writeSARIF(&out, violations, findings, false); err != nil {
+		t.Fatal(err)
+	}
+
+	var log sarifLog
+	if err := json.Unmarshal([]byte(out.String()), &log); err != nil {
+		t.Fatal(err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("log = %+v", log)
+	}
+	results := log.Runs[0].Results
+	if len(results) != 3 {
+		t.Fatalf("results = %d, want 3", len(results))
+	}
+	if r := results[0]; r.RuleID != "token-limit" || r.Level != "error" || r.Locations[0].PhysicalLocation.ArtifactLocation.URI != "pkg/big.go" {
+		t.Errorf("results[0] = %+v", r)
+	}
+	if want := "~30000 tokens, over the 25000 token threshold (46000 chars)"; results[0].Message.Text != want {
+		t.Errorf("message = %q, want %q", results[0].Message.Text, want)
+	}
+	if results[1].Level != "note" {
+		t.Errorf("frozen level = %q, want note", results[1].Level)
+	}
+	if results[2].RuleID != "package-doc" {
+		t.Errorf("results[2].ruleId = %q", results[2].RuleID)
+	}
+	if h := log.Runs[0].Artifacts[0].Hashes["sha-256"]; h != "ab12" {
+		t.Errorf("artifact hash = %q", h)
+	}
+}
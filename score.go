@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/befabri/token-lint/pkg/tokenlint"
+)
+
+// scoreComponent is one weighted part of the health score.
+type scoreComponent struct {
+	Name   string  `json:"name"`
+	Points float64 `json:"points"`
+	Weight float64 `json:"weight"`
+	Detail string  `json:"detail"`
+}
+
+// healthScore is the result of `token-lint score`.
+type healthScore struct {
+	Score      int              `json:"score"`
+	Files      int              `json:"files"`
+	Violations int              `json:"violations"`
+	Tokens     int              `json:"tokens"`
+	Largest    string           `json:"largest,omitempty"`
+	MaxTokens  int              `json:"maxTokens"`
+	Growth     *float64         `json:"growth,omitempty"` // fractional change since the base commit
+	Components []scoreComponent `json:"components"`
+}
+
+// runScore implements `token-lint score`, which condenses token health
+// into a single 0-100 number for dashboards comparing repositories.
+func runScore(ctx context.Context, args []string) int {
+	fs := flag.NewFlagSet("token-lint score", flag.ContinueOnError)
+	threshold := fs.Int("threshold", defaultThreshold, "maximum tokens per file")
+	ratio := fs.Float64("ratio", defaultRatio, "tokens per character ratio")
+	since := fs.String("since", "90 days ago", "measure growth against the last commit before this date (empty disables)")
+	format := fs.String("format", "text", "output format: text or json")
+
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 1
+	}
+	pol, err := loadPolicy(fs, "", "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	if *ratio <= 0 || *threshold <= 0 {
+		fmt.Fprintln(os.Stderr, "error: ratio and threshold must be positive")
+		return 1
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "error: unknown score format %q\n", *format)
+		return 1
+	}
+
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{"./..."}
+	}
+	files, err := pol.expand(ctx, paths)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+
+	opts, err := pol.options(*threshold, *ratio)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	results, _ := analyzeFiles(ctx, files, opts)
+	if len(results) == 0 {
+		fmt.Fprintln(os.Stderr, "no Go files found")
+		return 0
+	}
+
+	var growth *float64
+	if *since != "" {
+		g, err := tokenGrowth(results, *since, *ratio)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: growth not scored: %v\n", err)
+		} else {
+			growth = &g
+		}
+	}
+
+	s := computeScore(results, *threshold, growth)
+	if *format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(s); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+	printScore(os.Stdout, s)
+	return 0
+}
+
+// tokenGrowth returns the fractional growth of results' total tokens
+// since the last commit before date. Files that did not exist then count
+// as entirely new.
+func tokenGrowth(results []fileResult, date string, ratio float64) (float64, error) {
+	root, err := repoRoot()
+	if err != nil {
+		return 0, err
+	}
+	base, err := commitBefore(date)
+	if err != nil {
+		return 0, err
+	}
+	renames, err := renamedFiles(root, base)
+	if err != nil {
+		return 0, err
+	}
+
+	var now, then int
+	for _, r := range results {
+		now += r.tokens
+		rel, err := relToRoot(root, r.path)
+		if err != nil {
+			return 0, err
+		}
+		if old, ok := renames[rel]; ok {
+			rel = old
+		}
+		if content, err := gitBlob(base, rel); err == nil {
+			then += tokenlint.RatioTokenizer(ratio).Count(content)
+		}
+	}
+	if then == 0 {
+		return 0, fmt.Errorf("no tokens at %.12s", base)
+	}
+	return float64(now-then) / float64(then), nil
+}
+
+// computeScore weighs four components into a 0-100 score:
+//
+//   - violations (40): full marks with none, zero once 10% of files are over
+//   - largest file (20): full marks at or under the threshold, zero at twice it
+//   - average size (20): full marks at 10% of the threshold, zero at 50%
+//   - growth (20): full marks when shrinking, zero at 20% growth
+//
+// Without growth data the other components are scaled to 100.
+func computeScore(results []fileResult, threshold int, growth *float64) healthScore {
+	s := healthScore{Files: len(results), Growth: growth}
+	for _, r := range results {
+		s.Tokens += r.tokens
+		if r.tokens > threshold {
+			s.Violations++
+		}
+		if r.tokens > s.MaxTokens {
+			s.MaxTokens = r.tokens
+			s.Largest = filepath.ToSlash(r.path)
+		}
+	}
+	if s.Files == 0 {
+		s.Score = 100
+		return s
+	}
+
+	share := float64(s.Violations) / float64(s.Files)
+	largest := float64(s.MaxTokens) / float64(threshold)
+	average := float64(s.Tokens) / float64(s.Files) / float64(threshold)
+
+	s.Components = []scoreComponent{
+		{"violations", 40 * linearScore(share, 0, 0.1), 40,
+			fmt.Sprintf("%d of %d files over %d tokens", s.Violations, s.Files, threshold)},
+		{"largest file", 20 * linearScore(largest, 1, 2), 20,
+			fmt.Sprintf("%s, ~%d tokens", s.Largest, s.MaxTokens)},
+		{"average size", 20 * linearScore(average, 0.1, 0.5), 20,
+			fmt.Sprintf("~%d tokens per file", s.Tokens/s.Files)},
+	}
+	if growth != nil {
+		s.Components = append(s.Components, scoreComponent{"growth", 20 * linearScore(*growth, 0, 0.2), 20,
+			fmt.Sprintf("%+.1f%% tokens", *growth*100)})
+	}
+
+	var points, weight float64
+	for _, c := range s.Components {
+		points += c.Points
+		weight += c.Weight
+	}
+	s.Score = int(points/weight*100 + 0.5)
+	return s
+}
+
+// linearScore maps v to 1 at or below good, 0 at or above bad, and
+// linearly in between.
+func linearScore(v, good, bad float64) float64 {
+	switch {
+	case v <= good:
+		return 1
+	case v >= bad:
+		return 0
+	}
+	return (bad - v) / (bad - good)
+}
+
+func printScore(w io.Writer, s healthScore) {
+	fmt.Fprintf(w, "Health score: %d/100\n\n", s.Score)
+	for _, c := range s.Components {
+		fmt.Fprintf(w, "  %-14s %4.1f/%-3g %s\n", c.Name, c.Points, c.Weight, c.Detail)
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestComputeScore(t *testing.T) {
+	results := []fileResult{
+		{path: "a.go", tokens: 50},
+		{path: "b.go", tokens: 100},
+	}
+	if s := computeScore(results, 1000, nil); s.Score != 100 || len(s.Components) != 3 {
+		t.Errorf("healthy score = %d (%d components), want 100 (3)", s.Score, len(s.Components))
+	}
+
+	// One of two files over and 1.5x the threshold, average 1000 of 1000
+	// tokens: violations 0/40, largest 10/20, average 0/20, growth 10/20.
+	results = []fileResult{
+		{path: "a.go", tokens: 500},
+		{path: "big.go", tokens: 1500},
+	}
+	growth := 0.1
+	s := computeScore(results, 1000, &growth)
+	if s.Score != 20 {
+		t.Errorf("score = %d, want 20: %+v", s.Score, s.Components)
+	}
+	if s.Largest != "big.go" || s.Violations != 1 || s.Tokens != 2000 {
+		t.Errorf("summary = %+v", s)
+	}
+}
+
+func TestLinearScore(t *testing.T) {
+	tests := []struct{ v, want float64 }{
+		{-1, 1}, {0, 1}, {0.05, 0.5}, {0.1, 0}, {5, 0},
+	}
+	for _, tt := range tests {
+		if got := linearScore(tt.v, 0, 0.1); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("linearScore(%g) = %g, want %g", tt.v, got, tt.want)
+		}
+	}
+}
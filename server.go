@@ -0,0 +1,74 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+)
+
+// serverFlags are the flags shared by the long-running servers, lsp and
+// mcp, which recount files too often for a remote API and so support the
+// local tokenizers only.
+type serverFlags struct {
+	configPath    *string
+	threshold     *int
+	testThreshold *int
+	ramp          *string
+	ratio         *float64
+	tokenizer     *string
+	encoding      *string
+}
+
+func addServerFlags(fs *flag.FlagSet) *serverFlags {
+	return &serverFlags{
+		configPath:    fs.String("config", "", "config file (default: nearest "+configFileName+" from the working directory up)"),
+		threshold:     fs.Int("threshold", defaultThreshold, "maximum tokens before warning"),
+		testThreshold: fs.Int("test-threshold", 0, "threshold for _test.go files (0 uses -threshold)"),
+		ramp:          fs.String("ramp", "", "tighten the threshold linearly over time (overrides -threshold)"),
+		ratio:         fs.Float64("ratio", defaultRatio, "tokens per character ratio"),
+		tokenizer:     fs.String("tokenizer", "ratio", "token counting backend: ratio or bpe"),
+		encoding:      fs.String("encoding", "cl100k_base", "vocabulary for -tokenizer bpe: cl100k_base or o200k_base"),
+	}
+}
+
+// load loads the config and ignore file after fs has been parsed and
+// returns the policy and the options the server counts with. command
+// names the server in errors.
+func (f *serverFlags) load(fs *flag.FlagSet, command string) (*policy, analyzeOptions, error) {
+	pol, err := loadPolicy(fs, *f.configPath, "")
+	if err != nil {
+		return nil, analyzeOptions{}, err
+	}
+	if *f.ramp != "" {
+		r, err := parseRamp(*f.ramp)
+		if err != nil {
+			return nil, analyzeOptions{}, err
+		}
+		*f.threshold = r.at(time.Now())
+	}
+	if *f.ratio <= 0 || *f.threshold <= 0 {
+		return nil, analyzeOptions{}, errors.New("ratio and threshold must be positive")
+	}
+	if *f.tokenizer != "ratio" && *f.tokenizer != "bpe" {
+		return nil, analyzeOptions{}, fmt.Errorf("%s supports the ratio and bpe tokenizers, not %s", command, *f.tokenizer)
+	}
+	tok, err := newTokenizer(*f.tokenizer, tokenizerConfig{ratio: *f.ratio, encoding: *f.encoding})
+	if err != nil {
+		return nil, analyzeOptions{}, err
+	}
+
+	opts := analyzeOptions{
+		threshold:     *f.threshold,
+		ratio:         *f.ratio,
+		testThreshold: *f.testThreshold,
+		tokenizer:     tok,
+		pathThreshold: pathThresholdFunc(pol.cfg, nil),
+		stderr:        os.Stderr, // stdout carries the protocol
+	}
+	if pol.cfg != nil {
+		opts.categoryRatios = pol.cfg.Ratios
+	}
+	return pol, opts, nil
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+)
+
+// sink delivers the JSON report to another system. Each -sink flag names
+// one as SCHEME:TARGET; exec is the only scheme so far. Diagnostics from
+// the sink go to stderr.
+type sink interface {
+	deliver(ctx context.Context, report []byte, stderr io.Writer) error
+	String() string
+}
+
+// sinkConstructors maps each sink scheme to its constructor.
+var sinkConstructors = map[string]func(target string) (sink, error){
+	"exec": newExecSink,
+}
+
+// sinkList is a repeatable -sink flag.
+type sinkList []sink
+
+func (s *sinkList) Set(spec string) error {
+	scheme, target, ok := strings.Cut(spec, ":")
+	ctor, known := sinkConstructors[scheme]
+	if !ok || !known {
+		return fmt.Errorf("want SCHEME:TARGET with scheme exec, got %q", spec)
+	}
+	k, err := ctor(target)
+	if err != nil {
+		return err
+	}
+	*s = append(*s, k)
+	return nil
+}
+
+func (s *sinkList) String() string {
+	var specs []string
+	for _, k := range *s {
+		specs = append(specs, k.String())
+	}
+	return strings.Join(specs, ",")
+}
+
+// deliver sends report to every sink and returns the failures joined.
+func (s sinkList) deliver(ctx context.Context, report []byte, stderr io.Writer) error {
+	var errs []error
+	for _, k := range s {
+		if err := k.deliver(ctx, report, stderr); err != nil {
+			errs = append(errs, fmt.Errorf("sink %s: %w", k, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// execSink runs a command with the report on its standard input. The
+// command line is split on spaces, without a shell. Its output goes to
+// stderr so it cannot corrupt machine-readable stdout.
+type execSink struct {
+	args []string
+}
+
+func newExecSink(target string) (sink, error) {
+	args := strings.Fields(target)
+	if len(args) == 0 {
+		return nil, errors.New("exec sink needs a command, e.g. exec:./upload-report")
+	}
+	return &execSink{args: args}, nil
+}
+
+func (e *execSink) deliver(ctx context.Context, report []byte, stderr io.Writer) error {
+	cmd := exec.CommandContext(ctx, e.args[0], e.args[1:]...)
+	cmd.Stdin = bytes.NewReader(report)
+	cmd.Stdout = stderr
+	cmd.Stderr = stderr
+	return cmd.Run()
+}
+
+func (e *execSink) String() string {
+	return "exec:" + strings.Join(e.args, " ")
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestSinkListSet(t *testing.T) {
+	var s sinkList
+	if err := s.Set("exec:./upload --team core"); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.String(); got != "exec:./upload --team core" {
+		t.Errorf("String = %q", got)
+	}
+	for _, spec := range []string{"./upload", "http://example.com", "exec:", "exec:  "} {
+		if err := s.Set(spec); err == nil {
+			t.Errorf("Set(%q) succeeded", spec)
+		}
+	}
+}
+
+func TestExecSink(t *testing.T) {
+	for _, name := range []string{"cat", "false"} {
+		if _, err := exec.LookPath(name); err != nil {
+			t.Skipf("%s not available", name)
+		}
+	}
+
+	var report bytes.Buffer
+	results := []fileResult{{path: "a.go", tokens: 65, threshold: 50}}
+	if err := writeJSONReport(&report, results, nil, 50, false); err != nil {
+		t.Fatal(err)
+	}
+
+	var s sinkList
+	s.Set("exec:cat")
+	var out bytes.Buffer
+	if err := s.deliver(context.Background(), report.Bytes(), &out); err != nil {
+		t.Fatal(err)
+	}
+	var got jsonReport
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil || len(got.Files) != 1 || got.Files[0].Path != "a.go" {
+		t.Errorf("sink received %q (%v), want the JSON report", out.String(), err)
+	}
+
+	s.Set("exec:false")
+	err := s.deliver(context.Background(), report.Bytes(), &out)
+	if err == nil || !strings.Contains(err.Error(), "sink exec:false") {
+		t.Errorf("deliver error = %v, want failure of exec:false", err)
+	}
+}
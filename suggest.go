@@ -0,0 +1,442 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// runSuggest implements `token-lint suggest`, which proposes how to split
+// a file into smaller ones whose declarations belong together.
+func runSuggest(args []string) int {
+	fs := flag.NewFlagSet("token-lint suggest", flag.ContinueOnError)
+	ratio := fs.Float64("ratio", defaultRatio, "tokens per character ratio")
+	threshold := fs.Int("threshold", defaultThreshold, "token threshold the file should get under")
+	target := fs.Int("target", 0, "aim for new files of at most this many tokens (0 means half of -threshold, leaving room to grow)")
+
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 1
+	}
+	if _, err := loadPolicy(fs, "", ""); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	if *ratio <= 0 {
+		fmt.Fprintln(os.Stderr, "error: ratio must be positive")
+		return 1
+	}
+	if *threshold <= 0 || *target < 0 {
+		fmt.Fprintln(os.Stderr, "error: threshold must be positive")
+		return 1
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: token-lint suggest [flags] FILE")
+		return 1
+	}
+	if *target == 0 {
+		*target = *threshold / 2
+	}
+
+	path := fs.Arg(0)
+	src, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	plan, err := planSplit(path, src, *ratio, *target)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	renderPlan(os.Stdout, plan, *threshold)
+	return 0
+}
+
+// splitPlan is a proposed split of one file.
+type splitPlan struct {
+	path   string
+	tokens int
+	fset   *token.FileSet
+	file   *ast.File
+	src    []byte
+	decls  []declInfo // top-level declarations in source order, as in file.Decls
+	files  []plannedFile
+}
+
+// plannedFile is one file of a splitPlan. The first keeps the original
+// name.
+type plannedFile struct {
+	name   string
+	decls  []int // indexes into splitPlan.decls, in source order
+	tokens int   // estimate, including the package clause and imports
+}
+
+// planSplit groups the declarations of src into files of about target
+// tokens. Types stay with their methods and New constructors; other
+// declarations are merged with the groups they reference most, and groups
+// with nothing in common are packed together to avoid tiny files.
+func planSplit(path string, src []byte, ratio float64, target int) (*splitPlan, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	p := &splitPlan{
+		path:   path,
+		tokens: int(float64(len(src)) * ratio),
+		fset:   fset,
+		file:   f,
+		src:    src,
+		decls:  fileDecls(fset, f),
+	}
+
+	// Imports are repeated in every file as needed, so they are counted
+	// as header rather than split.
+	var movable []int
+	headerEnd := len(src)
+	for i, d := range f.Decls {
+		if gd, ok := d.(*ast.GenDecl); ok && gd.Tok == token.IMPORT {
+			continue
+		}
+		movable = append(movable, i)
+		headerEnd = min(headerEnd, p.decls[i].start)
+	}
+	header := int(float64(headerEnd) * ratio)
+	size := func(i int) int { return int(float64(p.decls[i].end-p.decls[i].start) * ratio) }
+
+	groups := newDeclGroups(movable, size, declReferences(f, movable))
+	for _, i := range movable {
+		if owner, ok := declOwner(f, i); ok {
+			groups.union(i, owner)
+		}
+	}
+
+	// Merge the most strongly connected groups while they fit. Ties go to
+	// the lowest pair of roots, so the plan does not depend on map order.
+	for {
+		best, bi, bj := 0, -1, -1
+		for a, edges := range groups.weights {
+			for b, w := range edges {
+				if a > b || w < best || groups.sizes[a]+groups.sizes[b]+header > target {
+					continue
+				}
+				if w > best || a < bi || a == bi && b < bj {
+					best, bi, bj = w, a, b
+				}
+			}
+		}
+		if bi < 0 {
+			break
+		}
+		groups.union(bi, bj)
+	}
+
+	// Pack what is left, largest first, into the file it has most in
+	// common with, else the first with room.
+	roots := groups.roots()
+	sort.SliceStable(roots, func(x, y int) bool { return groups.sizes[roots[x]] > groups.sizes[roots[y]] })
+	var bins [][]int
+	var binTokens []int
+	for _, r := range roots {
+		s := groups.sizes[r]
+		best, bestWeight := -1, -1
+		for b, members := range bins {
+			if binTokens[b]+s > target-header {
+				continue
+			}
+			w := 0
+			for _, m := range members {
+				w += groups.weights[m][r]
+			}
+			if w > bestWeight {
+				best, bestWeight = b, w
+			}
+		}
+		if best < 0 {
+			bins = append(bins, nil)
+			binTokens = append(binTokens, 0)
+			best = len(bins) - 1
+		}
+		bins[best] = append(bins[best], r)
+		binTokens[best] += s
+	}
+
+	for _, members := range bins {
+		var decls []int
+		for _, r := range members {
+			decls = append(decls, groups.members[r]...)
+		}
+		sort.Ints(decls)
+		tokens := header
+		for _, i := range decls {
+			tokens += size(i)
+		}
+		p.files = append(p.files, plannedFile{decls: decls, tokens: tokens})
+	}
+	// The largest file keeps the original name.
+	sort.SliceStable(p.files, func(x, y int) bool { return p.files[x].tokens > p.files[y].tokens })
+	p.nameFiles(size)
+	return p, nil
+}
+
+// nameFiles names each planned file after its largest type, or else its
+// largest declaration, keeping the original name for the first.
+func (p *splitPlan) nameFiles(size func(int) int) {
+	dir, base := filepath.Split(p.path)
+	stem, suffix := strings.TrimSuffix(base, ".go"), ".go"
+	if s, ok := strings.CutSuffix(stem, "_test"); ok {
+		stem, suffix = s, "_test.go"
+	}
+	used := map[string]bool{base: true}
+	for k := range p.files {
+		if k == 0 {
+			p.files[k].name = p.path
+			continue
+		}
+		anchor, anchorSize, anchorType := "", -1, false
+		for _, i := range p.files[k].decls {
+			name, isType := declIdent(p.file.Decls[i])
+			if name == "" {
+				continue
+			}
+			if isType && !anchorType || isType == anchorType && size(i) > anchorSize {
+				anchor, anchorSize, anchorType = name, size(i), isType
+			}
+		}
+		name := stem + "_" + snakeCase(anchor) + suffix
+		for n := 2; used[name]; n++ {
+			name = fmt.Sprintf("%s_%s%d%s", stem, snakeCase(anchor), n, suffix)
+		}
+		used[name] = true
+		p.files[k].name = filepath.Join(dir, name)
+	}
+}
+
+// renderPlan writes a plan in source order within each proposed file.
+func renderPlan(w io.Writer, p *splitPlan, threshold int) {
+	fmt.Fprintf(w, "Suggested split of %s (~%d tokens, limit %d) into %d files:\n", quotePath(p.path), p.tokens, threshold, len(p.files))
+	for _, f := range p.files {
+		fmt.Fprintf(w, "\n  %s  ~%d tokens\n", quotePath(f.name), f.tokens)
+		for _, i := range f.decls {
+			fmt.Fprintf(w, "    %s\n", p.decls[i].name)
+		}
+	}
+}
+
+// declIdent returns the main name a declaration introduces and whether it
+// is a type. Methods report their receiver's type name.
+func declIdent(d ast.Decl) (string, bool) {
+	switch d := d.(type) {
+	case *ast.FuncDecl:
+		if d.Recv != nil {
+			return receiverName(d), false
+		}
+		return d.Name.Name, false
+	case *ast.GenDecl:
+		for _, spec := range d.Specs {
+			switch s := spec.(type) {
+			case *ast.TypeSpec:
+				return s.Name.Name, true
+			case *ast.ValueSpec:
+				return s.Names[0].Name, false
+			}
+		}
+	}
+	return "", false
+}
+
+// receiverName returns the base type name of a method's receiver.
+func receiverName(d *ast.FuncDecl) string {
+	if d.Recv == nil || len(d.Recv.List) == 0 {
+		return ""
+	}
+	e := d.Recv.List[0].Type
+	for {
+		switch t := e.(type) {
+		case *ast.StarExpr:
+			e = t.X
+		case *ast.IndexExpr:
+			e = t.X
+		case *ast.IndexListExpr:
+			e = t.X
+		case *ast.Ident:
+			return t.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// declOwner returns the type declaration a method or NewT constructor
+// belongs with, if that type is declared in the same file.
+func declOwner(f *ast.File, i int) (int, bool) {
+	fd, ok := f.Decls[i].(*ast.FuncDecl)
+	if !ok {
+		return 0, false
+	}
+	owner := receiverName(fd)
+	if fd.Recv == nil {
+		owner, ok = strings.CutPrefix(fd.Name.Name, "New")
+		if !ok {
+			owner, ok = strings.CutPrefix(fd.Name.Name, "new")
+		}
+		if !ok || owner == "" {
+			return 0, false
+		}
+	}
+	for j, d := range f.Decls {
+		if gd, ok := d.(*ast.GenDecl); ok && gd.Tok == token.TYPE {
+			for _, spec := range gd.Specs {
+				if name := spec.(*ast.TypeSpec).Name.Name; strings.EqualFold(name, owner) {
+					return j, true
+				}
+			}
+		}
+	}
+	return 0, false
+}
+
+// declReferences counts, for each pair of movable declarations, how often
+// one refers to a name the other declares. Method calls are matched by
+// name, which is good enough for grouping.
+func declReferences(f *ast.File, movable []int) map[[2]int]int {
+	defs := make(map[string][]int)
+	for _, i := range movable {
+		switch d := f.Decls[i].(type) {
+		case *ast.FuncDecl:
+			defs[d.Name.Name] = append(defs[d.Name.Name], i)
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					defs[s.Name.Name] = append(defs[s.Name.Name], i)
+				case *ast.ValueSpec:
+					for _, n := range s.Names {
+						defs[n.Name] = append(defs[n.Name], i)
+					}
+				}
+			}
+		}
+	}
+
+	weights := make(map[[2]int]int)
+	for _, i := range movable {
+		ast.Inspect(f.Decls[i], func(n ast.Node) bool {
+			id, ok := n.(*ast.Ident)
+			if !ok {
+				return true
+			}
+			for _, j := range defs[id.Name] {
+				if j != i {
+					weights[[2]int{min(i, j), max(i, j)}]++
+				}
+			}
+			return true
+		})
+	}
+	return weights
+}
+
+// declGroups is a union-find over declaration indexes that keeps, for
+// each group, its members, its size in tokens and its references to every
+// other group, so merging two groups costs only their neighbours.
+type declGroups struct {
+	parent  map[int]int
+	members map[int][]int       // by root, in source order
+	sizes   map[int]int         // by root
+	weights map[int]map[int]int // by root, then the other root
+}
+
+func newDeclGroups(decls []int, size func(int) int, refs map[[2]int]int) *declGroups {
+	g := &declGroups{
+		parent:  make(map[int]int),
+		members: make(map[int][]int),
+		sizes:   make(map[int]int),
+		weights: make(map[int]map[int]int),
+	}
+	for _, i := range decls {
+		g.parent[i] = i
+		g.members[i] = []int{i}
+		g.sizes[i] = size(i)
+		g.weights[i] = make(map[int]int)
+	}
+	for pair, w := range refs {
+		g.weights[pair[0]][pair[1]] += w
+		g.weights[pair[1]][pair[0]] += w
+	}
+	return g
+}
+
+func (g *declGroups) find(i int) int {
+	for g.parent[i] != i {
+		g.parent[i] = g.parent[g.parent[i]]
+		i = g.parent[i]
+	}
+	return i
+}
+
+// union merges the groups of a and b into the lower root, folding the
+// higher root's tables into it.
+func (g *declGroups) union(a, b int) {
+	ra, rb := g.find(a), g.find(b)
+	if ra == rb {
+		return
+	}
+	if rb < ra {
+		ra, rb = rb, ra
+	}
+	g.parent[rb] = ra
+	g.members[ra] = append(g.members[ra], g.members[rb]...)
+	sort.Ints(g.members[ra])
+	g.sizes[ra] += g.sizes[rb]
+	for c, w := range g.weights[rb] {
+		delete(g.weights[c], rb)
+		if c != ra {
+			g.weights[ra][c] += w
+			g.weights[c][ra] += w
+		}
+	}
+	delete(g.weights[ra], rb)
+	delete(g.members, rb)
+	delete(g.sizes, rb)
+	delete(g.weights, rb)
+}
+
+// roots returns one representative per group, in ascending order.
+func (g *declGroups) roots() []int {
+	roots := make([]int, 0, len(g.sizes))
+	for r := range g.sizes {
+		roots = append(roots, r)
+	}
+	sort.Ints(roots)
+	return roots
+}
+
+// snakeCase converts a Go identifier such as HTTPServer or handleUpload
+// to a file name part such as http_server or handle_upload.
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			prevLower := i > 0 && !unicode.IsUpper(runes[i-1])
+			nextLower := i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if (prevLower || nextLower) && b.Len() > 0 && runes[i-1] != '_' {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
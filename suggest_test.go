@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestPlanSplit(t *testing.T) {
+	body := strings.Repeat("\t_ = 0\n", 40)
+	src := `package shop
+
+import "fmt"
+
+type Cart struct{ items []string }
+
+func NewCart() *Cart { return &Cart{} }
+
+func (c *Cart) Add(s string) {
+` + body + `}
+
+func (c *Cart) Total() int {
+` + body + `	return cartTotal(c)
+}
+
+func cartTotal(c *Cart) int { return len(c.items) }
+
+type Invoice struct{ id int }
+
+func (i Invoice) String() string {
+` + body + `	return fmt.Sprint(i.id)
+}
+
+func (i Invoice) Render() string {
+` + body + `	return i.String()
+}
+`
+	plan, err := planSplit("shop/cart.go", []byte(src), 1, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.files) != 2 {
+		t.Fatalf("planned %d files, want 2", len(plan.files))
+	}
+	var names [][]string
+	for _, f := range plan.files {
+		var decls []string
+		for _, i := range f.decls {
+			decls = append(decls, plan.decls[i].name)
+		}
+		names = append(names, decls)
+		if f.tokens > 1000 {
+			t.Errorf("%s has %d tokens, over the 1000 target", f.name, f.tokens)
+		}
+	}
+	want := [][]string{
+		{"type Cart", "func NewCart", "method (*Cart).Add", "method (*Cart).Total", "func cartTotal"},
+		{"type Invoice", "method (Invoice).String", "method (Invoice).Render"},
+	}
+	for k := range want {
+		if strings.Join(names[k], ", ") != strings.Join(want[k], ", ") {
+			t.Errorf("file %d holds %v, want %v", k, names[k], want[k])
+		}
+	}
+	if plan.files[0].name != "shop/cart.go" || plan.files[1].name != "shop/cart_invoice.go" {
+		t.Errorf("names = %q, %q; want shop/cart.go, shop/cart_invoice.go", plan.files[0].name, plan.files[1].name)
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	tests := map[string]string{
+		"handleUpload": "handle_upload",
+		"HTTPServer":   "http_server",
+		"Invoice":      "invoice",
+		"parseURL":     "parse_url",
+		"v2Client":     "v2_client",
+	}
+	for in, want := range tests {
+		if got := snakeCase(in); got != want {
+			t.Errorf("snakeCase(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func FuzzSplit(f *testing.F) {
+	body := strings.Repeat("\t_ = 0\n", 20)
+	f.Add([]byte("//go:build linux\n\npackage p\n\nimport (\n\t\"fmt\"\n\tstr \"strings\"\n)\n\ntype A struct{}\n\nfunc (A) M() {\n" + body + "\tfmt.Println()\n}\n\ntype B struct{}\n\nfunc (B) M() string {\n" + body + "\treturn str.ToUpper(\"\")\n}\n"))
+	f.Add([]byte("package p\n\n// Doc.\nvar (\n\tx = 1\n\ty = 2\n)\n\nfunc f() int { return x + y }\n\nfunc g[T any](t T) T { return t }\n"))
+	f.Fuzz(func(t *testing.T, src []byte) {
+		plan, err := planSplit("p.go", src, 0.3, 40)
+		if err != nil || len(plan.files) < 2 {
+			return
+		}
+		applySplit(plan)
+	})
+}
+
+// BenchmarkPlanSplit plans a file of 300 functions that each call a few
+// others, the shape of file fix and suggest_split exist for.
+func BenchmarkPlanSplit(b *testing.B) {
+	var src strings.Builder
+	src.WriteString("package p\n")
+	for i := range 300 {
+		fmt.Fprintf(&src, "\nfunc f%d() {\n", i)
+		for _, j := range []int{i * 7 % 300, i * 13 % 300, (i + 1) % 300} {
+			fmt.Fprintf(&src, "\tf%d()\n", j)
+		}
+		src.WriteString(strings.Repeat("\t_ = 0\n", 10) + "}\n")
+	}
+	for b.Loop() {
+		if _, err := planSplit("p.go", []byte(src.String()), 0.3, 2000); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// scanStats describes one run for telemetry export.
+type scanStats struct {
+	start      time.Time
+	end        time.Time
+	files      int
+	violations int
+	threshold  int
+}
+
+// otlpAttr is an OTLP/JSON key-value attribute.
+type otlpAttr struct {
+	Key   string         `json:"key"`
+	Value map[string]any `json:"value"`
+}
+
+func stringAttr(key, v string) otlpAttr {
+	return otlpAttr{Key: key, Value: map[string]any{"stringValue": v}}
+}
+
+func intAttr(key string, v int) otlpAttr {
+	// OTLP/JSON encodes 64-bit integers as strings.
+	return otlpAttr{Key: key, Value: map[string]any{"intValue": strconv.Itoa(v)}}
+}
+
+// otlpEndpoint returns the configured OTLP/HTTP base URL, if any.
+func otlpEndpoint(flagValue string) string {
+	if flagValue != "" {
+		return strings.TrimSuffix(flagValue, "/")
+	}
+	return strings.TrimSuffix(os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"), "/")
+}
+
+// exportTelemetry sends a scan span and scan metrics to an OTLP/HTTP
+// collector using the JSON encoding, so no SDK dependency is needed.
+func exportTelemetry(endpoint string, s scanStats) error {
+	service := os.Getenv("OTEL_SERVICE_NAME")
+	if service == "" {
+		service = "token-lint"
+	}
+	resource := map[string]any{"attributes": []otlpAttr{stringAttr("service.name", service)}}
+	scope := map[string]any{"name": "token-lint"}
+	attrs := []otlpAttr{
+		intAttr("token_lint.files", s.files),
+		intAttr("token_lint.violations", s.violations),
+		intAttr("token_lint.threshold", s.threshold),
+	}
+
+	traces := map[string]any{
+		"resourceSpans": []any{map[string]any{
+			"resource": resource,
+			"scopeSpans": []any{map[string]any{
+				"scope": scope,
+				"spans": []any{map[string]any{
+					"traceId":           randomHex(16),
+					"spanId":            randomHex(8),
+					"name":              "token-lint.scan",
+					"kind":              1, // SPAN_KIND_INTERNAL
+					"startTimeUnixNano": strconv.FormatInt(s.start.UnixNano(), 10),
+					"endTimeUnixNano":   strconv.FormatInt(s.end.UnixNano(), 10),
+					"attributes":        attrs,
+				}},
+			}},
+		}},
+	}
+
+	now := strconv.FormatInt(s.end.UnixNano(), 10)
+	gauge := func(name, unit string, point map[string]any) map[string]any {
+		point["timeUnixNano"] = now
+		return map[string]any{
+			"name":  name,
+			"unit":  unit,
+			"gauge": map[string]any{"dataPoints": []any{point}},
+		}
+	}
+	metrics := map[string]any{
+		"resourceMetrics": []any{map[string]any{
+			"resource": resource,
+			"scopeMetrics": []any{map[string]any{
+				"scope": scope,
+				"metrics": []any{
+					gauge("token_lint.scan.duration", "s", map[string]any{"asDouble": s.end.Sub(s.start).Seconds()}),
+					gauge("token_lint.files", "{file}", map[string]any{"asInt": strconv.Itoa(s.files)}),
+					gauge("token_lint.violations", "{file}", map[string]any{"asInt": strconv.Itoa(s.violations)}),
+				},
+			}},
+		}},
+	}
+
+	if err := postOTLP(endpoint+"/v1/traces", traces); err != nil {
+		return err
+	}
+	return postOTLP(endpoint+"/v1/metrics", metrics)
+}
+
+func postOTLP(url string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	return nil
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestExportTelemetry(t *testing.T) {
+	var mu sync.Mutex
+	bodies := map[string]string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var v map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&v); err != nil {
+			t.Errorf("%s: invalid JSON: %v", r.URL.Path, err)
+		}
+		b, _ := json.Marshal(v)
+		mu.Lock()
+		bodies[r.URL.Path] = string(b)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	start := time.Now()
+	err := exportTelemetry(srv.URL, scanStats{
+		start:      start,
+		end:        start.Add(time.Second),
+		files:      12,
+		violations: 2,
+		threshold:  25000,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(bodies["/v1/traces"], `"name":"token-lint.scan"`) {
+		t.Errorf("traces payload missing scan span: %s", bodies["/v1/traces"])
+	}
+	for _, want := range []string{`"name":"token_lint.scan.duration"`, `"asInt":"12"`, `"asInt":"2"`} {
+		if !strings.Contains(bodies["/v1/metrics"], want) {
+			t.Errorf("metrics payload missing %s: %s", want, bodies["/v1/metrics"])
+		}
+	}
+}
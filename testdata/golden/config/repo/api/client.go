@@ -0,0 +1,8 @@
+// Package api talks to the server.
+package api
+
+// Client is an API client.
+type Client struct{ base string }
+
+// New returns a client for base.
+func New(base string) *Client { return &Client{base: base} }
@@ -0,0 +1,5 @@
+package api
+
+// MockClient is skipped through .tokenlintignore, whatever its size, and
+// would otherwise exceed the threshold with this long comment about it.
+type MockClient struct{ calls []string }
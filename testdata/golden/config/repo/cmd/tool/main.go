@@ -0,0 +1,8 @@
+// Command tool prints a greeting.
+package main
+
+import "fmt"
+
+func main() {
+	fmt.Println("hello from the tool")
+}
@@ -0,0 +1,4 @@
+// Package legacy is excluded by the config file however large it grows.
+package legacy
+
+var Names = []string{"one", "two", "three", "four", "five", "six", "seven", "eight", "nine", "ten"}
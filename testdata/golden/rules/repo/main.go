@@ -0,0 +1,8 @@
+// Command demo is a small program for the golden tests.
+package main
+
+import "fmt"
+
+func main() {
+	fmt.Println("hello")
+}
@@ -0,0 +1,39 @@
+// Package shop sells things. Its comment is deliberately long, far longer
+// than the package doc budget allows for a summary.
+package shop
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Cart holds items.
+type Cart struct{ items []string }
+
+// Invoice bills a cart.
+type Invoice struct{ id int }
+
+// Add adds an item.
+func (c *Cart) Add(item string) { c.items = append(c.items, item) }
+
+// Total counts the items.
+func (c *Cart) Total() int { return len(c.items) }
+
+// String renders the invoice.
+func (i Invoice) String() string { return fmt.Sprintf("invoice %d", i.id) }
+
+// Render renders the invoice for printing, with a header and a footer
+// that take up a fair amount of space in this file.
+func (i Invoice) Render() string {
+	var b strings.Builder
+	b.WriteString("==== INVOICE ====\n")
+	b.WriteString(i.String())
+	b.WriteString("\n==== THANK YOU ====\n")
+	return b.String()
+}
+
+// NewCart returns an empty cart.
+func NewCart() *Cart { return &Cart{} }
+
+// Discount is the default discount.
+var Discount = 0.1
@@ -0,0 +1,11 @@
+package shop
+
+import "testing"
+
+func TestCart(t *testing.T) {
+	c := NewCart()
+	c.Add("x")
+	if c.Total() != 1 {
+		t.Fatal("total")
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/befabri/token-lint/pkg/tokenlint"
+)
+
+// tokenizerConfig carries the settings a tokenizer backend may need.
+type tokenizerConfig struct {
+	ratio    float64 // tokens per character, for estimating backends
+	encoding string  // vocabulary name, for BPE backends
+	model    string  // model name, for API backends
+
+	// Limits for API backends; zero means unlimited.
+	qps         float64 // requests per second
+	concurrency int     // requests in flight at once
+	budget      int     // requests per run, retries included
+	batch       int     // small files counted per request
+}
+
+// Default limits for API backends.
+const (
+	defaultAPIQPS         = 10
+	defaultAPIConcurrency = 4
+	defaultAPIBatch       = 8
+)
+
+// tokenizers maps each -tokenizer name to its constructor.
+var tokenizers = map[string]func(tokenizerConfig) (tokenlint.Tokenizer, error){
+	"ratio":      func(c tokenizerConfig) (tokenlint.Tokenizer, error) { return tokenlint.RatioTokenizer(c.ratio), nil },
+	"bpe":        func(c tokenizerConfig) (tokenlint.Tokenizer, error) { return tokenlint.NewBPETokenizer(c.encoding) },
+	"claude-api": newClaudeTokenizer,
+}
+
+// newTokenizer returns the registered tokenizer with the given name.
+func newTokenizer(name string, c tokenizerConfig) (tokenlint.Tokenizer, error) {
+	ctor, ok := tokenizers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown tokenizer %q (want one of %v)", name, tokenizerNames())
+	}
+	return ctor(c)
+}
+
+// tokenizerNames returns the registered tokenizer names, sorted.
+func tokenizerNames() []string {
+	names := make([]string, 0, len(tokenizers))
+	for name := range tokenizers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// tokenizerErr returns the error that made a tokenizer's counts unreliable,
+// for backends that can fail, such as remote APIs.
+func tokenizerErr(t tokenlint.Tokenizer) error {
+	if e, ok := t.(interface{ Err() error }); ok {
+		return e.Err()
+	}
+	return nil
+}
+
+// tokenizerFallbacks reports how many counts a backend with a request
+// budget had to estimate with the ratio instead, and what the budget was.
+func tokenizerFallbacks(t tokenlint.Tokenizer) (estimated, budget int) {
+	if f, ok := t.(interface{ Fallbacks() (int, int) }); ok {
+		return f.Fallbacks()
+	}
+	return 0, 0
+}
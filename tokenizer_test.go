@@ -0,0 +1,30 @@
+package main
+
+import "testing"
+
+func TestNewTokenizer(t *testing.T) {
+	src := []byte("hello world")
+
+	tests := []struct {
+		name string
+		want int
+	}{
+		{"ratio", 5}, // 11 bytes * 0.5
+		{"bpe", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tok, err := newTokenizer(tt.name, tokenizerConfig{ratio: 0.5, encoding: "cl100k_base"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := tok.Count(src); got != tt.want {
+				t.Errorf("Count = %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := newTokenizer("nope", tokenizerConfig{}); err == nil {
+		t.Error("newTokenizer accepted an unknown name")
+	}
+}
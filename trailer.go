@@ -0,0 +1,102 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// stagedImpact summarizes how the staged changes affect token counts.
+type stagedImpact struct {
+	files int // staged Go files
+	delta int // staged tokens minus HEAD tokens
+	over  int // staged Go files over the threshold
+}
+
+// runCommitMsg implements `token-lint commit-msg`, meant to be called from
+// a commit-msg hook with the message file path. It appends a Token-Lint
+// trailer recording the token impact of the staged changes. Failures are
+// reported but never block the commit.
+func runCommitMsg(args []string) int {
+	fs := flag.NewFlagSet("token-lint commit-msg", flag.ContinueOnError)
+	threshold := fs.Int("threshold", defaultThreshold, "maximum tokens before warning")
+	ratio := fs.Float64("ratio", defaultRatio, "tokens per character ratio")
+
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 1
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: token-lint commit-msg [flags] MESSAGE_FILE")
+		return 1
+	}
+
+	pol, err := loadPolicy(fs, "", "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: token-lint: %v\n", err)
+		return 0
+	}
+	opts, err := pol.options(*threshold, *ratio)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: token-lint: %v\n", err)
+		return 0
+	}
+	impact, err := measureStaged(pol, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: token-lint: %v\n", err)
+		return 0
+	}
+	if impact.files == 0 {
+		return 0
+	}
+
+	_, err = gitOutput("interpret-trailers", "--in-place", "--if-exists", "replace",
+		"--trailer", tokenTrailer(impact), fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: token-lint: %v\n", err)
+	}
+	return 0
+}
+
+// measureStaged compares the staged contents of the Go files in scope
+// with HEAD.
+func measureStaged(pol *policy, opts analyzeOptions) (stagedImpact, error) {
+	var impact stagedImpact
+	root, err := repoRoot()
+	if err != nil {
+		return impact, err
+	}
+	staged, err := stagedFiles()
+	if err != nil {
+		return impact, err
+	}
+
+	for _, rel := range staged {
+		if !strings.HasSuffix(rel, ".go") || pol.skipsRel(root, rel) {
+			continue
+		}
+		impact.files++
+
+		// A missing blob means the file is new (HEAD) or deleted (index).
+		var before, after int
+		if content, err := gitBlob("HEAD", rel); err == nil {
+			before = opts.count(content)
+		}
+		if content, err := gitBlob("", rel); err == nil {
+			after = opts.count(content)
+		}
+		impact.delta += after - before
+		if after > opts.threshold {
+			impact.over++
+		}
+	}
+	return impact, nil
+}
+
+// tokenTrailer formats the commit trailer for impact.
+func tokenTrailer(impact stagedImpact) string {
+	return fmt.Sprintf("Token-Lint: %+d tokens (%d files over threshold)", impact.delta, impact.over)
+}
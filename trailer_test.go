@@ -0,0 +1,47 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCommitMsgTrailer(t *testing.T) {
+	dir := newTestRepo(t)
+	testCommit(t, dir, "", map[string]string{"a.go": strings.Repeat("a", 100), "gone.go": strings.Repeat("g", 10)})
+	t.Chdir(dir)
+
+	if err := os.WriteFile("a.go", []byte(strings.Repeat("a", 300)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile("new.go", []byte(strings.Repeat("n", 50)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	testGit(t, dir, "", "add", "a.go", "new.go")
+	testGit(t, dir, "", "rm", "--quiet", "gone.go")
+
+	impact, err := measureStaged(&policy{}, analyzeOptions{threshold: 200, ratio: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := stagedImpact{files: 3, delta: 200 + 50 - 10, over: 1}
+	if impact != want {
+		t.Errorf("measureStaged = %+v, want %+v", impact, want)
+	}
+
+	msg := filepath.Join(dir, "COMMIT_EDITMSG")
+	if err := os.WriteFile(msg, []byte("Grow a.go\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if code := runCommitMsg([]string{"-threshold", "200", "-ratio", "1", msg}); code != 0 {
+		t.Fatalf("runCommitMsg exit code = %d, want 0", code)
+	}
+	got, err := os.ReadFile(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Grow a.go\n\nToken-Lint: +240 tokens (1 files over threshold)\n"; string(got) != want {
+		t.Errorf("message = %q, want %q", got, want)
+	}
+}
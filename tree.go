@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// treeNode is a directory or file in the -format tree view, with the
+// cumulative tokens of everything below it.
+type treeNode struct {
+	name     string
+	tokens   int
+	files    int
+	over     bool // a file over its threshold
+	children map[string]*treeNode
+}
+
+// buildTree arranges results into a directory hierarchy rooted at their
+// deepest common directory.
+func buildTree(results []fileResult) *treeNode {
+	var paths [][]string
+	var common []string
+	for i, r := range results {
+		parts := strings.Split(filepath.ToSlash(filepath.Clean(r.path)), "/")
+		paths = append(paths, parts)
+		dir := parts[:len(parts)-1]
+		if i == 0 {
+			common = dir
+			continue
+		}
+		n := 0
+		for n < len(common) && n < len(dir) && common[n] == dir[n] {
+			n++
+		}
+		common = common[:n]
+	}
+
+	rootName := strings.Join(common, "/")
+	switch {
+	case rootName == "":
+		rootName = "."
+		if len(common) > 0 {
+			rootName = "/"
+		}
+	case rootName != "/":
+		rootName += "/"
+	}
+	root := &treeNode{name: rootName}
+	for i, parts := range paths {
+		r := results[i]
+		node := root
+		node.add(r)
+		for _, part := range parts[len(common):] {
+			child, ok := node.children[part]
+			if !ok {
+				child = &treeNode{name: part}
+				if node.children == nil {
+					node.children = make(map[string]*treeNode)
+				}
+				node.children[part] = child
+			}
+			node = child
+			node.add(r)
+		}
+		node.over = r.tokens > r.threshold && r.suppressed == ""
+	}
+	return root
+}
+
+func (n *treeNode) add(r fileResult) {
+	n.tokens += r.tokens
+	n.files++
+}
+
+// sorted returns n's children, heaviest first.
+func (n *treeNode) sorted() []*treeNode {
+	children := make([]*treeNode, 0, len(n.children))
+	for _, c := range n.children {
+		children = append(children, c)
+	}
+	sort.Slice(children, func(i, j int) bool {
+		if children[i].tokens != children[j].tokens {
+			return children[i].tokens > children[j].tokens
+		}
+		return children[i].name < children[j].name
+	})
+	return children
+}
+
+// writeTree writes the -format tree view: one line per directory and
+// file with its cumulative tokens, like du, heaviest subtree first.
+// Directories also show how many files they hold.
+func writeTree(w io.Writer, msg messages, root *treeNode) {
+	type line struct {
+		label string
+		node  *treeNode
+	}
+	var lines []line
+	var walk func(n *treeNode, prefix string)
+	walk = func(n *treeNode, prefix string) {
+		children := n.sorted()
+		for i, c := range children {
+			branch, indent := "├── ", "│   "
+			if i == len(children)-1 {
+				branch, indent = "└── ", "    "
+			}
+			name := quotePath(c.name)
+			if c.children != nil {
+				name += "/"
+			}
+			lines = append(lines, line{prefix + branch + name, c})
+			walk(c, prefix+indent)
+		}
+	}
+	lines = append(lines, line{quotePath(root.name), root})
+	walk(root, "")
+
+	width := 0
+	for _, l := range lines {
+		width = max(width, len([]rune(l.label)))
+	}
+	for _, l := range lines {
+		pad := strings.Repeat(" ", width-len([]rune(l.label)))
+		fmt.Fprintf(w, "%s%s %8d", l.label, pad, l.node.tokens)
+		switch {
+		case l.node.children != nil && l.node.files == 1:
+			fmt.Fprint(w, "  (1 file)")
+		case l.node.children != nil:
+			fmt.Fprintf(w, "  (%d files)", l.node.files)
+		case l.node.over:
+			fmt.Fprint(w, msg.f("exceeds"))
+		}
+		fmt.Fprintln(w)
+	}
+}
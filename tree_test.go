@@ -0,0 +1,37 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteTree(t *testing.T) {
+	results := []fileResult{
+		{path: filepath.Join("pkg", "a", "x.go"), tokens: 30, threshold: 100},
+		{path: filepath.Join("pkg", "a", "y.go"), tokens: 120, threshold: 100},
+		{path: filepath.Join("pkg", "b", "z.go"), tokens: 40, threshold: 100},
+		{path: filepath.Join("pkg", "main.go"), tokens: 10, threshold: 100},
+	}
+	var b strings.Builder
+	writeTree(&b, newMessages("en"), buildTree(results))
+	want := `pkg/              200  (4 files)
+├── a/            150  (2 files)
+│   ├── y.go      120 <- EXCEEDS LIMIT
+│   └── x.go       30
+├── b/             40  (1 file)
+│   └── z.go       40
+└── main.go        10
+`
+	if b.String() != want {
+		t.Errorf("tree =\n%s\nwant\n%s", b.String(), want)
+	}
+}
+
+func TestBuildTreeRelativeRoot(t *testing.T) {
+	results := []fileResult{{path: "main.go", tokens: 5}, {path: filepath.Join("sub", "x.go"), tokens: 7}}
+	root := buildTree(results)
+	if root.name != "." || root.tokens != 12 || root.files != 2 {
+		t.Errorf("root = %+v, want . with 12 tokens in 2 files", root)
+	}
+}
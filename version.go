@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"runtime/debug"
+	"strings"
+
+	"github.com/befabri/token-lint/pkg/tokenlint"
+)
+
+// Build metadata, set at release time with
+//
+//	-ldflags "-X main.version=v1.2.3 -X main.commit=abc123 -X main.date=2025-01-01T00:00:00Z"
+//
+// and otherwise filled in from the module build info where possible.
+var (
+	version = ""
+	commit  = ""
+	date    = ""
+)
+
+// buildInfo describes the running binary.
+type buildInfo struct {
+	version   string
+	commit    string
+	date      string
+	goVersion string
+	platform  string
+	deps      map[string]string // module path to version
+}
+
+// tokenizerModules are the dependencies that determine token counts; their
+// versions are reported so bug reports pin the exact vocabulary data.
+var tokenizerModules = []string{
+	"github.com/pkoukk/tiktoken-go",
+	"github.com/pkoukk/tiktoken-go-loader",
+}
+
+func currentBuildInfo() buildInfo {
+	b := buildInfo{
+		version:   version,
+		commit:    commit,
+		date:      date,
+		goVersion: runtime.Version(),
+		platform:  runtime.GOOS + "/" + runtime.GOARCH,
+		deps:      make(map[string]string),
+	}
+
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if b.version == "" {
+			b.version = info.Main.Version
+		}
+		for _, s := range info.Settings {
+			switch {
+			case s.Key == "vcs.revision" && b.commit == "":
+				b.commit = s.Value
+			case s.Key == "vcs.time" && b.date == "":
+				b.date = s.Value
+			}
+		}
+		for _, dep := range info.Deps {
+			b.deps[dep.Path] = dep.Version
+		}
+	}
+
+	if b.version == "" {
+		b.version = "(devel)"
+	}
+	return b
+}
+
+func (b buildInfo) write(w io.Writer) {
+	fmt.Fprintf(w, "token-lint %s\n", b.version)
+	fmt.Fprintf(w, "  commit:     %s\n", orUnknown(b.commit))
+	fmt.Fprintf(w, "  built:      %s\n", orUnknown(b.date))
+	fmt.Fprintf(w, "  go:         %s\n", b.goVersion)
+	fmt.Fprintf(w, "  platform:   %s\n", b.platform)
+
+	var mods []string
+	for _, path := range tokenizerModules {
+		mods = append(mods, path+" "+orUnknown(b.deps[path]))
+	}
+	fmt.Fprintf(w, "  tokenizers: ratio %g (built-in), bpe %s\n", defaultRatio, strings.Join(tokenlint.BPEEncodings, ", "))
+	fmt.Fprintf(w, "  vocab data: %s\n", strings.Join(mods, ", "))
+}
+
+func orUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
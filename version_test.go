@@ -0,0 +1,31 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuildInfoWrite(t *testing.T) {
+	b := buildInfo{
+		version:   "v1.2.3",
+		commit:    "abc123",
+		goVersion: "go1.25.0",
+		platform:  "windows/arm64",
+		deps:      map[string]string{"github.com/pkoukk/tiktoken-go": "v0.1.8"},
+	}
+
+	var out strings.Builder
+	b.write(&out)
+
+	for _, want := range []string{
+		"token-lint v1.2.3\n",
+		"commit:     abc123\n",
+		"built:      unknown\n",
+		"platform:   windows/arm64\n",
+		"github.com/pkoukk/tiktoken-go v0.1.8, github.com/pkoukk/tiktoken-go-loader unknown\n",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderView(t *testing.T) {
+	src := "package p\n\n// Doc comment.\nfunc f() {\n\treturn\n}\n\ntype T struct{}\n"
+
+	var out strings.Builder
+	renderView(&out, "p.go", []byte(src), 1, 20)
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+
+	want := []string{
+		"    1        | package p",
+		"    2        | ",
+		"             +-- func f (~36 tokens)",
+		"    3     20 | // Doc comment.",
+		"    4        | func f() {",
+		"    5     40 | \treturn",
+		"    6        | }",
+		"    7        | ",
+		"             +-- type T (~15 tokens)",
+		"    8     60 | type T struct{}",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want %d:\n%s", len(lines), len(want), out.String())
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("line %d = %q, want %q", i, lines[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/befabri/token-lint/pkg/tokenlint"
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce is how long file events must settle before the changed
+// files are re-analyzed; editors often save a file in several writes.
+const watchDebounce = 100 * time.Millisecond
+
+// watcher re-analyzes Go files as they change, for -watch.
+type watcher struct {
+	fsw   *fsnotify.Watcher
+	files func() ([]string, error) // lists the files in scope
+	opts  analyzeOptions
+	msg   messages
+	out   io.Writer
+	now   func() time.Time
+
+	hidden  bool                  // watch dot- and underscore-prefixed directories too
+	known   map[string]fileResult // by cleaned path
+	dirs    map[string]bool       // watched directories
+	changed map[string]bool       // paths with events since the last rescan
+}
+
+// watchFiles reports changes to the token counts of files until ctx is
+// canceled, starting from the already printed results. files lists the
+// files in scope; it is called again after each burst of changes so new
+// files are picked up and deleted ones dropped. roots are directories
+// watched with all their subdirectories, hidden ones only if hidden is
+// set, besides those holding files: the roots of ./... patterns and
+// directory arguments, which may have no Go files yet.
+func watchFiles(ctx context.Context, out io.Writer, msg messages, opts analyzeOptions, results []fileResult, roots []string, hidden bool, files func() ([]string, error)) int {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		opts.warnf("-watch: %v", err)
+		return 1
+	}
+	defer fsw.Close()
+
+	w := &watcher{
+		fsw:     fsw,
+		files:   files,
+		opts:    opts,
+		msg:     msg,
+		out:     out,
+		now:     time.Now,
+		hidden:  hidden,
+		known:   make(map[string]fileResult),
+		dirs:    make(map[string]bool),
+		changed: make(map[string]bool),
+	}
+	for _, r := range results {
+		w.known[filepath.Clean(r.path)] = r
+		w.watchDir(filepath.Dir(r.path))
+	}
+	for _, root := range roots {
+		w.watchTree(root)
+	}
+	timer := time.NewTimer(watchDebounce)
+	timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return 0
+		case ev, ok := <-fsw.Events:
+			if !ok {
+				return 0
+			}
+			if w.event(ev) {
+				timer.Reset(watchDebounce)
+			}
+		case err, ok := <-fsw.Errors:
+			if !ok {
+				return 0
+			}
+			w.opts.warnf("-watch: %v", err)
+		case <-timer.C:
+			w.rescan(ctx)
+		}
+	}
+}
+
+// event records ev and reports whether it calls for a rescan.
+func (w *watcher) event(ev fsnotify.Event) bool {
+	if ev.Has(fsnotify.Create) {
+		if info, err := os.Stat(ev.Name); err == nil && info.IsDir() {
+			w.watchTree(ev.Name)
+			return true // files may have been created before the watch
+		}
+	}
+	if !strings.HasSuffix(ev.Name, ".go") || ev.Op == fsnotify.Chmod {
+		return false
+	}
+	w.changed[filepath.Clean(ev.Name)] = true
+	return true
+}
+
+func (w *watcher) watchDir(dir string) {
+	dir = filepath.Clean(dir)
+	if w.dirs[dir] {
+		return
+	}
+	if err := w.fsw.Add(dir); err != nil {
+		w.opts.warnf("-watch: %v", err)
+		return
+	}
+	w.dirs[dir] = true
+}
+
+// watchTree watches root and the directories beneath it, skipping hidden
+// ones like file discovery does unless w.hidden is set.
+func (w *watcher) watchTree(root string) {
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil // unreadable entries are left to the scan to report
+		}
+		if path != root && !w.hidden && tokenlint.IsHidden(d.Name()) {
+			return filepath.SkipDir
+		}
+		w.watchDir(path)
+		return nil
+	})
+}
+
+// rescan lists the files in scope again, analyzes the changed and new ones
+// and prints a line for each, then forgets the removed ones.
+func (w *watcher) rescan(ctx context.Context) {
+	files, err := w.files()
+	if err != nil {
+		w.opts.warnf("%v", err)
+		return
+	}
+	current := make(map[string]bool, len(files))
+	var analyze []string
+	for _, path := range files {
+		path = filepath.Clean(path)
+		current[path] = true
+		if _, ok := w.known[path]; !ok || w.changed[path] {
+			analyze = append(analyze, path)
+			w.watchDir(filepath.Dir(path))
+		}
+	}
+	clear(w.changed)
+
+	stamp := w.now().Format(time.TimeOnly)
+	results, _ := analyzeFiles(ctx, analyze, w.opts)
+	for _, r := range results {
+		old, existed := w.known[r.path]
+		w.known[r.path] = r
+		switch {
+		case !existed:
+			io.WriteString(w.out, w.msg.f("watchAdded", stamp, quotePath(r.path), r.tokens, w.limitNote(-1, r)))
+		case r.tokens != old.tokens:
+			io.WriteString(w.out, w.msg.f("watchChanged", stamp, quotePath(r.path), r.tokens, r.tokens-old.tokens, w.limitNote(old.tokens, r)))
+		}
+	}
+
+	var removed []string
+	for path := range w.known {
+		if !current[path] {
+			removed = append(removed, path)
+		}
+	}
+	sort.Strings(removed)
+	for _, path := range removed {
+		delete(w.known, path)
+		io.WriteString(w.out, w.msg.f("watchRemoved", stamp, quotePath(path)))
+	}
+}
+
+// limitNote describes where r stands against its threshold, given its
+// previous token count or -1 for a new file.
+func (w *watcher) limitNote(before int, r fileResult) string {
+	if r.suppressed != "" {
+		return ""
+	}
+	over, wasOver := r.tokens > r.threshold, before > r.threshold
+	switch {
+	case over && before >= 0 && !wasOver:
+		return w.msg.f("watchCrossed", r.threshold)
+	case over:
+		return w.msg.f("watchOver", r.threshold)
+	case wasOver:
+		return w.msg.f("watchBack", r.threshold)
+	}
+	return ""
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+func TestWatcherRescan(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	grow := write("grow.go", "package a\n")
+	gone := write("gone.go", "package a\n")
+	same := write("same.go", "package a\n")
+
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fsw.Close()
+	var out strings.Builder
+	opts := analyzeOptions{threshold: 50, ratio: 1}
+	results, _ := analyzeFiles(context.Background(), []string{grow, gone, same}, opts)
+	w := &watcher{
+		fsw:     fsw,
+		opts:    opts,
+		msg:     newMessages("en"),
+		out:     &out,
+		now:     func() time.Time { return time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC) },
+		known:   make(map[string]fileResult),
+		dirs:    make(map[string]bool),
+		changed: make(map[string]bool),
+	}
+	for _, r := range results {
+		w.known[r.path] = r
+	}
+
+	write("grow.go", "package a\n"+strings.Repeat("x", 60)+"\n")
+	added := write("added.go", "package a\n")
+	os.Remove(gone)
+	w.changed[grow] = true
+	w.changed[same] = true // touched without changing its count
+	w.files = func() ([]string, error) { return []string{added, grow, same}, nil }
+	w.rescan(context.Background())
+
+	want := "15:04:05  " + added + ": ~10 tokens (new file)\n" +
+		"15:04:05  " + grow + ": ~71 tokens (+61), now over the 50 token limit\n" +
+		"15:04:05  " + gone + ": removed\n"
+	if out.String() != want {
+		t.Errorf("output:\n%s\nwant:\n%s", out.String(), want)
+	}
+	if _, ok := w.known[gone]; ok || len(w.changed) != 0 {
+		t.Errorf("after rescan known = %v, changed = %v", w.known, w.changed)
+	}
+}
+
+func TestWatcherLimitNote(t *testing.T) {
+	w := &watcher{msg: newMessages("en")}
+	tests := []struct {
+		before, after int
+		suppressed    string
+		want          string
+	}{
+		{10, 20, "", ""},
+		{10, 200, "", ", now over the 100 token limit"},
+		{150, 200, "", ", over the 100 token limit"},
+		{-1, 200, "", ", over the 100 token limit"},
+		{150, 50, "", ", back under the 100 token limit"},
+		{10, 200, "generated fixtures", ""},
+	}
+	for _, tt := range tests {
+		r := fileResult{tokens: tt.after, threshold: 100, suppressed: tt.suppressed}
+		if got := w.limitNote(tt.before, r); got != tt.want {
+			t.Errorf("limitNote(%d, %d tokens) = %q, want %q", tt.before, tt.after, got, tt.want)
+		}
+	}
+}
+
+func TestWatcherWatchTree(t *testing.T) {
+	dir := t.TempDir()
+	for _, sub := range []string{"empty", filepath.Join("empty", "deeper"), ".git", "_scratch"} {
+		if err := os.Mkdir(filepath.Join(dir, sub), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fsw.Close()
+	w := &watcher{fsw: fsw, dirs: make(map[string]bool)}
+	w.watchTree(dir)
+
+	// Directories without Go files are watched for files created later;
+	// hidden ones are skipped as file discovery skips them.
+	for sub, want := range map[string]bool{".": true, "empty": true, "empty/deeper": true, ".git": false, "_scratch": false} {
+		if got := w.dirs[filepath.Join(dir, filepath.FromSlash(sub))]; got != want {
+			t.Errorf("watching %s = %v, want %v", sub, got, want)
+		}
+	}
+}